@@ -0,0 +1,315 @@
+// Package animation plays keyframe timelines on the host side, streaming interpolated values through music mode.
+// It's not limited by device color flow limits (step count, minimum step duration, fixed transition curve)
+package animation
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// Keyframe defines device output at given moment of timeline
+type Keyframe struct {
+	At         time.Duration // offset from timeline beginning
+	RGB        int           // 0xRRGGBB
+	Brightness int           // 1-100
+	Easing     Easing        // transition curve from previous keyframe, Linear when nil
+}
+
+// Timeline is an ordered list of keyframes
+type Timeline struct {
+	Keyframes []Keyframe
+	Loop      bool // start over after last keyframe
+}
+
+// NewTimeline validates and sorts given keyframes by time
+func NewTimeline(loop bool, keyframes ...Keyframe) (Timeline, error) {
+	if len(keyframes) == 0 {
+		return Timeline{}, errors.New("timeline requires at least one keyframe")
+	}
+
+	sorted := make([]Keyframe, len(keyframes))
+	copy(sorted, keyframes)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At < sorted[j].At })
+
+	for _, k := range sorted {
+		if k.At < 0 {
+			return Timeline{}, errors.New("keyframe time cannot be negative")
+		}
+		if k.RGB < 0 || k.RGB > 0xffffff {
+			return Timeline{}, errors.New("rgb expected range: 0-0xFFFFFF")
+		}
+		if k.Brightness < 1 || k.Brightness > 100 {
+			return Timeline{}, errors.New("brightness expected range: 1-100")
+		}
+	}
+	return Timeline{sorted, loop}, nil
+}
+
+// FromFlowExpression converts device color flow into timeline, temperature steps are converted
+// to approximated color and CF_BRIGHTNESS_IGNORE keeps brightness of previous step. Like flows.Simulate,
+// timeline starts from the output of the last step (as looping flows do) and every step is a transition
+// into its color, sleep steps hold the previous output
+func FromFlowExpression(expression yl.FlowExpression, loop bool) (Timeline, error) {
+	states := expression.States()
+	if len(states) == 0 {
+		return Timeline{}, errors.New("flow expression is empty")
+	}
+
+	apply := func(k Keyframe, state yl.FlowState) Keyframe {
+		switch state.Mode {
+		case yl.CF_MODE_COLOR:
+			k.RGB = state.Value
+		case yl.CF_MODE_TEMP:
+			k.RGB = yl.KelvinToRGB(state.Value)
+		case yl.CF_MODE_SLEEP:
+			return k
+		}
+		if state.Brightness != yl.CF_BRIGHTNESS_IGNORE {
+			k.Brightness = state.Brightness
+		}
+		return k
+	}
+
+	initial := Keyframe{Brightness: 100}
+	for _, state := range states {
+		initial = apply(initial, state)
+	}
+
+	keyframes := []Keyframe{initial}
+	last := initial
+	for _, state := range states {
+		next := apply(last, state)
+		next.At = last.At + time.Duration(state.Duration)*time.Millisecond
+		keyframes = append(keyframes, next)
+		last = next
+	}
+	return NewTimeline(loop, keyframes...)
+}
+
+// Duration returns time of last keyframe
+func (t Timeline) Duration() time.Duration {
+	if len(t.Keyframes) == 0 {
+		return 0
+	}
+	return t.Keyframes[len(t.Keyframes)-1].At
+}
+
+// At returns interpolated color and brightness at given moment of timeline
+func (t Timeline) At(at time.Duration) (rgb, brightness int) {
+	if len(t.Keyframes) == 0 {
+		return 0, 0
+	}
+	if t.Loop && t.Duration() > 0 {
+		at %= t.Duration()
+	}
+
+	first := t.Keyframes[0]
+	if at <= first.At {
+		return first.RGB, first.Brightness
+	}
+
+	for i := 1; i < len(t.Keyframes); i++ {
+		to := t.Keyframes[i]
+		if at > to.At {
+			continue
+		}
+		from := t.Keyframes[i-1]
+
+		easing := to.Easing
+		if easing == nil {
+			easing = Linear
+		}
+		var progress float64 = 1
+		if span := to.At - from.At; span > 0 {
+			progress = easing(float64(at-from.At) / float64(span))
+		}
+
+		lerp := func(a, b int) int {
+			v := int(math.Round(float64(a) + float64(b-a)*progress))
+			if v < 0 {
+				return 0
+			}
+			return v
+		}
+		rgb = clampChannel(lerp(from.RGB>>16&0xff, to.RGB>>16&0xff))<<16 |
+			clampChannel(lerp(from.RGB>>8&0xff, to.RGB>>8&0xff))<<8 |
+			clampChannel(lerp(from.RGB&0xff, to.RGB&0xff))
+		brightness = lerp(from.Brightness, to.Brightness)
+		if brightness < 1 {
+			brightness = 1
+		} else if brightness > 100 {
+			brightness = 100
+		}
+		return rgb, brightness
+	}
+
+	last := t.Keyframes[len(t.Keyframes)-1]
+	return last.RGB, last.Brightness
+}
+
+// clampChannel keeps value in 0-255 range, easing curves may overshoot
+func clampChannel(v int) int {
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+// Sink receives interpolated values, *yl.Music can be used directly
+type Sink interface {
+	RGB(rgb, duration int)
+	Brightness(brightness, duration int)
+}
+
+// Player plays Timeline on given Sink, values are sent every interval, only when they change
+type Player struct {
+	sink     Sink
+	timeline Timeline
+	interval time.Duration
+
+	mtx       sync.Mutex
+	offset    time.Duration // timeline position when playback was (re)started
+	startedAt time.Time
+	paused    bool
+	started   bool
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewPlayer creates Player, interval determines update frequency, 50ms is a reasonable value for music mode
+func NewPlayer(sink Sink, timeline Timeline, interval time.Duration) (*Player, error) {
+	if interval <= 0 {
+		return nil, errors.New("interval required to be > 0")
+	}
+	return &Player{
+		sink:     sink,
+		timeline: timeline,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Play starts playback in background, Done channel is closed when playback is finished
+func (p *Player) Play() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.started {
+		return
+	}
+	p.started = true
+	p.startedAt = time.Now()
+	go p.run()
+}
+
+func (p *Player) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	// smooth transitions between updates, device rejects smooth durations shorter than 30ms
+	var transition int
+	if p.interval >= 30*time.Millisecond {
+		transition = int(p.interval / time.Millisecond)
+	}
+
+	lastRGB, lastBrightness := -1, -1
+	for {
+		position := p.Position()
+		rgb, brightness := p.timeline.At(position)
+		if rgb != lastRGB {
+			p.sink.RGB(rgb, transition)
+			lastRGB = rgb
+		}
+		if brightness != lastBrightness {
+			p.sink.Brightness(brightness, transition)
+			lastBrightness = brightness
+		}
+
+		if !p.timeline.Loop && position >= p.timeline.Duration() {
+			return
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Position returns current timeline position
+func (p *Player) Position() time.Duration {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.paused || p.startedAt.IsZero() {
+		return p.offset
+	}
+	return p.offset + time.Since(p.startedAt)
+}
+
+// Pause freezes playback at current position
+func (p *Player) Pause() {
+	position := p.Position()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.offset = position
+	p.paused = true
+}
+
+// Resume continues paused playback
+func (p *Player) Resume() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if !p.paused {
+		return
+	}
+	p.startedAt = time.Now()
+	p.paused = false
+}
+
+// Seek moves playback to given timeline position, before Play it sets starting position
+func (p *Player) Seek(position time.Duration) {
+	if position < 0 {
+		position = 0
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.offset = position
+	if !p.startedAt.IsZero() {
+		p.startedAt = time.Now()
+	}
+}
+
+// Stop stops playback, it can't be started again
+func (p *Player) Stop() {
+	p.mtx.Lock()
+	if !p.started {
+		p.started = true
+		close(p.done)
+	}
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	p.mtx.Unlock()
+
+	<-p.done
+}
+
+// Done returns channel which is closed when playback is finished or stopped
+func (p *Player) Done() <-chan struct{} {
+	return p.done
+}
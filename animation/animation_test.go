@@ -0,0 +1,221 @@
+package animation
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+)
+
+func TestNewTimeline(t *testing.T) {
+	timeline, err := NewTimeline(false,
+		Keyframe{At: 2 * time.Second, RGB: 0x0000ff, Brightness: 50},
+		Keyframe{At: 0, RGB: 0xff0000, Brightness: 100},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeline.Keyframes[0].At != 0 || timeline.Duration() != 2*time.Second {
+		t.Errorf("expected keyframes sorted by time, got %v", timeline.Keyframes)
+	}
+
+	invalid := []Keyframe{
+		{At: -time.Second, RGB: 0xff0000, Brightness: 100},
+		{At: 0, RGB: 0x1000000, Brightness: 100},
+		{At: 0, RGB: 0xff0000, Brightness: 0},
+	}
+	for _, k := range invalid {
+		if _, err := NewTimeline(false, k); err == nil {
+			t.Errorf("expected error for %+v", k)
+		}
+	}
+	if _, err := NewTimeline(false); err == nil {
+		t.Error("expected error for timeline without keyframes")
+	}
+}
+
+func TestTimelineAt(t *testing.T) {
+	timeline, err := NewTimeline(false,
+		Keyframe{At: time.Second, RGB: 0xff0000, Brightness: 100},
+		Keyframe{At: 3 * time.Second, RGB: 0x0000ff, Brightness: 50},
+		Keyframe{At: 4 * time.Second, RGB: 0x00ff00, Brightness: 1, Easing: Steps(2)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at         time.Duration
+		rgb        int
+		brightness int
+	}{
+		{0, 0xff0000, 100},
+		{time.Second, 0xff0000, 100},
+		{2 * time.Second, 0x800080, 75},
+		{3 * time.Second, 0x0000ff, 50},
+		{3400 * time.Millisecond, 0x0000ff, 50},
+		{3500 * time.Millisecond, 0x008080, 26},
+		{5 * time.Second, 0x00ff00, 1},
+	}
+	for _, test := range tests {
+		rgb, brightness := timeline.At(test.at)
+		if rgb != test.rgb || brightness != test.brightness {
+			t.Errorf("%v: expected %06x at %d%%, got %06x at %d%%", test.at, test.rgb, test.brightness, rgb, brightness)
+		}
+	}
+
+	timeline.Loop = true
+	if rgb, _ := timeline.At(6 * time.Second); rgb != 0x800080 {
+		t.Errorf("expected looped timeline at 2s, got %06x", rgb)
+	}
+}
+
+func TestFromFlowExpression(t *testing.T) {
+	expression, err := yl.NewFlowBuilder().
+		Color(0xff0000, 500*time.Millisecond, 100).
+		Sleep(200*time.Millisecond).
+		Temperature(2700, time.Second, yl.CF_BRIGHTNESS_IGNORE).
+		Color(0x0000ff, 300*time.Millisecond, 20).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeline, err := FromFlowExpression(expression, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeline.Duration() != expression.Duration() {
+		t.Errorf("expected duration %v, got %v", expression.Duration(), timeline.Duration())
+	}
+
+	// timeline plays the same output as device simulation of a single flow cycle
+	samples, err := flows.Simulate(flows.Flow{Count: 4, Action: yl.CF_ACTION_STAY, Expression: expression},
+		50*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range samples {
+		rgb, brightness := timeline.At(sample.At)
+		if rgb != sample.RGB || brightness != sample.Brightness {
+			t.Errorf("%v: simulated %06x at %d%%, timeline %06x at %d%%",
+				sample.At, sample.RGB, sample.Brightness, rgb, brightness)
+		}
+	}
+}
+
+// recorder is a Sink recording received values
+type recorder struct {
+	mtx         sync.Mutex
+	rgb         []int
+	brightness  []int
+	transitions []int
+}
+
+func (r *recorder) RGB(rgb, duration int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.rgb = append(r.rgb, rgb)
+	r.transitions = append(r.transitions, duration)
+}
+
+func (r *recorder) Brightness(brightness, duration int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.brightness = append(r.brightness, brightness)
+}
+
+func (r *recorder) last() (rgb, brightness int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.rgb[len(r.rgb)-1], r.brightness[len(r.brightness)-1]
+}
+
+func TestNewPlayerInterval(t *testing.T) {
+	timeline, _ := NewTimeline(false, Keyframe{RGB: 0xff0000, Brightness: 100})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := NewPlayer(&recorder{}, timeline, interval); err == nil {
+			t.Errorf("expected error for interval %v", interval)
+		}
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	timeline, err := NewTimeline(false,
+		Keyframe{At: 0, RGB: 0xff0000, Brightness: 100},
+		Keyframe{At: 200 * time.Millisecond, RGB: 0x0000ff, Brightness: 50},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recorder{}
+	player, err := NewPlayer(sink, timeline, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player.Play()
+	select {
+	case <-player.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("playback wasn't finished")
+	}
+
+	if rgb, brightness := sink.last(); rgb != 0x0000ff || brightness != 50 {
+		t.Errorf("expected playback finished at the last keyframe, got %06x at %d%%", rgb, brightness)
+	}
+	if sink.rgb[0] != 0xff0000 || len(sink.rgb) < 3 {
+		t.Errorf("expected interpolated values from the first keyframe, got %x", sink.rgb)
+	}
+	// intervals shorter than 30ms are sent as sudden changes
+	if sink.transitions[0] != 0 {
+		t.Errorf("expected sudden changes, got %d", sink.transitions[0])
+	}
+}
+
+func TestPlayerControl(t *testing.T) {
+	timeline, err := NewTimeline(false,
+		Keyframe{At: 0, RGB: 0xff0000, Brightness: 100},
+		Keyframe{At: time.Hour, RGB: 0x0000ff, Brightness: 100},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	player, err := NewPlayer(&recorder{}, timeline, 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player.Seek(30 * time.Minute)
+	if player.Position() != 30*time.Minute {
+		t.Errorf("expected position 30m before playback, got %v", player.Position())
+	}
+	player.Play()
+
+	player.Pause()
+	paused := player.Position()
+	time.Sleep(20 * time.Millisecond)
+	if player.Position() != paused || paused < 30*time.Minute {
+		t.Errorf("expected position frozen at %v, got %v", paused, player.Position())
+	}
+
+	player.Seek(45 * time.Minute)
+	if position := player.Position(); position != 45*time.Minute {
+		t.Errorf("expected paused position moved to 45m, got %v", position)
+	}
+
+	player.Resume()
+	time.Sleep(20 * time.Millisecond)
+	if position := player.Position(); position <= 45*time.Minute {
+		t.Errorf("expected playback resumed from 45m, got %v", position)
+	}
+
+	player.Stop()
+	select {
+	case <-player.Done():
+	default:
+		t.Error("expected closed Done channel after Stop")
+	}
+	player.Stop()
+}
@@ -0,0 +1,73 @@
+package animation
+
+import (
+	"math"
+)
+
+// Easing maps linear transition progress (0-1) to eased progress
+type Easing func(t float64) float64
+
+func Linear(t float64) float64 {
+	return t
+}
+
+func EaseIn(t float64) float64 {
+	return t * t * t
+}
+
+func EaseOut(t float64) float64 {
+	return 1 - math.Pow(1-t, 3)
+}
+
+func EaseInOut(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	return 1 - math.Pow(-2*t+2, 3)/2
+}
+
+// Steps returns easing which jumps in given amount of equal steps, like CSS steps(n, end)
+func Steps(n int) Easing {
+	if n < 1 {
+		n = 1
+	}
+	return func(t float64) float64 {
+		if t >= 1 {
+			return 1
+		}
+		return math.Floor(t*float64(n)) / float64(n)
+	}
+}
+
+// CubicBezier returns easing defined by cubic bezier curve with (0, 0) and (1, 1) end points,
+// like CSS cubic-bezier(x1, y1, x2, y2). x1 and x2 are clamped to 0-1 range
+func CubicBezier(x1, y1, x2, y2 float64) Easing {
+	x1 = math.Max(0, math.Min(1, x1))
+	x2 = math.Max(0, math.Min(1, x2))
+
+	bezier := func(p1, p2, s float64) float64 {
+		return 3*(1-s)*(1-s)*s*p1 + 3*(1-s)*s*s*p2 + s*s*s
+	}
+
+	return func(t float64) float64 {
+		if t <= 0 || t >= 1 {
+			return t
+		}
+		// finding curve parameter for given x with bisection, x(s) is monotonic for x1, x2 in 0-1 range
+		low, high := 0.0, 1.0
+		s := t
+		for i := 0; i < 32; i++ {
+			x := bezier(x1, x2, s)
+			if math.Abs(x-t) < 1e-6 {
+				break
+			}
+			if x < t {
+				low = s
+			} else {
+				high = s
+			}
+			s = (low + high) / 2
+		}
+		return bezier(y1, y2, s)
+	}
+}
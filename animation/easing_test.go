@@ -0,0 +1,50 @@
+package animation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEasingEndpoints(t *testing.T) {
+	easings := map[string]Easing{
+		"linear":       Linear,
+		"ease in":      EaseIn,
+		"ease out":     EaseOut,
+		"ease in out":  EaseInOut,
+		"steps":        Steps(4),
+		"steps zero":   Steps(0),
+		"cubic bezier": CubicBezier(0.42, 0, 0.58, 1),
+		"overshoot":    CubicBezier(0.3, -0.5, 0.7, 1.5),
+	}
+	for name, easing := range easings {
+		if v := easing(0); math.Abs(v) > 1e-9 {
+			t.Errorf("%s: expected 0 at the beginning, got %f", name, v)
+		}
+		if v := easing(1); math.Abs(v-1) > 1e-9 {
+			t.Errorf("%s: expected 1 at the end, got %f", name, v)
+		}
+	}
+}
+
+func TestEasingProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		easing   Easing
+		t        float64
+		expected float64
+	}{
+		{"ease in", EaseIn, 0.5, 0.125},
+		{"ease out", EaseOut, 0.5, 0.875},
+		{"ease in out", EaseInOut, 0.25, 0.0625},
+		{"ease in out middle", EaseInOut, 0.5, 0.5},
+		{"steps", Steps(4), 0.3, 0.25},
+		{"steps before end", Steps(4), 0.99, 0.75},
+		{"symmetric bezier", CubicBezier(0.42, 0, 0.58, 1), 0.5, 0.5},
+		{"linear bezier", CubicBezier(0.25, 0.25, 0.75, 0.75), 0.3, 0.3},
+	}
+	for _, test := range tests {
+		if v := test.easing(test.t); math.Abs(v-test.expected) > 1e-4 {
+			t.Errorf("%s: expected %f at %f, got %f", test.name, test.expected, test.t, v)
+		}
+	}
+}
@@ -1,19 +1,22 @@
 package yeelight
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net"
+	"strconv"
 	"sync"
+	"time"
 )
 
 // notes:
 // max 4 parallel opened TCP connections
 // quota: 60 commands per minute (for one device)
-// quota: 144 commands per minute for all devices
+// quota: 144 commands per minute for all devices (not enforced by Bulb, see Group and WaitQuota)
 // TODO: Returns response objects too, not only error
 // TODO: Export interface only, not whole struct
 type Bulb struct {
@@ -28,24 +31,53 @@ type Bulb struct {
 
 	conn       net.Conn
 	results    map[int]chan Response
+	closed     bool // connection is closed, response processor exited
 	resultsMtx sync.Mutex
+
+	subscribers    map[*Subscription]struct{}
+	subscribersMtx sync.Mutex
+
+	cache    *stateCache // nil when state cache is disabled
+	cacheMtx sync.Mutex
+
+	notifyQueue notifyQueue
+	notifySeq   int
+	notifying   bool // notification worker is running
+	notifyMtx   sync.Mutex
+}
+
+// Address returns "ip:port" address of the device
+func (b *Bulb) Address() string {
+	return net.JoinHostPort(b.Ip, strconv.Itoa(b.Port))
 }
 
 func (b *Bulb) Connect() error {
-	destination := fmt.Sprintf("%s:%d", b.Ip, b.Port)
+	return b.ConnectTimeout(0)
+}
 
-	conn, err := net.Dial("tcp", destination)
+// ConnectTimeout works like Connect, but gives up when connection isn't established in given time,
+// zero timeout means no timeout
+func (b *Bulb) ConnectTimeout(timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", b.Address(), timeout)
 	if err != nil {
 		return err
 	}
 
+	b.resultsMtx.Lock()
+	b.conn = conn
+	b.closed = false
+	b.resultsMtx.Unlock()
 	go b.responseProcessor()
 
-	b.conn = conn
 	return nil
 }
 
+// ErrConnectionClosed is returned by commands sent after connection to the device was lost or closed
+var ErrConnectionClosed = errors.New("connection closed")
+
+// Disconnect closes connection to the device, state cache is disabled
 func (b *Bulb) Disconnect() error {
+	b.DisableStateCache()
 	err := b.conn.Close()
 	if err != nil {
 		return err
@@ -56,57 +88,91 @@ func (b *Bulb) Disconnect() error {
 // NewBulb creates Bulb instance, default protocol port: 55443
 func NewBulb(ip string) *Bulb {
 	bulb := &Bulb{
-		standardCommands{},
-		commonCommands{},
-		backgroundLightCommands{},
-		ip,
-		55443, // 55443 is a constant protocol port
-		nil,
-		make(map[int]chan Response),
-		sync.Mutex{},
+		Ip:          ip,
+		Port:        55443, // 55443 is a constant protocol port
+		results:     make(map[int]chan Response),
+		subscribers: make(map[*Subscription]struct{}),
 	}
 	// I know It looks badly, but "It is working? It is working"
 	bulb.standardCommands.commander = bulb
 	bulb.commonCommands.commander = bulb
 	bulb.Bg.commander = bulb
 	bulb.Bg.prefix = "bg_"
+	bulb.Bg.commonCommands.commander = bulb
+	bulb.Bg.commonCommands.prefix = "bg_"
 	return bulb
 }
 
+// SetCalibration sets corrections applied to values sent by RGB, Brightness and Temperature commands
+// (including background light and music mode started afterwards), nil disables calibration
+func (b *Bulb) SetCalibration(calibration *Calibration) {
+	b.commonCommands.calibration = calibration
+	b.standardCommands.calibration = calibration
+	b.Bg.commonCommands.calibration = calibration
+}
+
+// SetBrightnessIgnoreSupport declares whether device accepts CF_BRIGHTNESS_IGNORE as color flow step
+// brightness. Documentation says it's supported, but some devices respond with general error,
+// so flows using it are rejected by StartColorFlow (including background light and music mode
+// started afterwards) until support is declared
+func (b *Bulb) SetBrightnessIgnoreSupport(supported bool) {
+	b.commonCommands.brightnessIgnore = supported
+	b.standardCommands.brightnessIgnore = supported
+	b.Bg.commonCommands.brightnessIgnore = supported
+}
+
 func (b *Bulb) executeCommand(c partialCommand) error {
-	respChan := make(chan Response)
+	_, err := b.queryCommand(c)
+	return err
+}
+
+// queryCommand sends command and waits for response, command result is returned
+func (b *Bulb) queryCommand(c partialCommand) ([]string, error) {
+	// buffered, so response processor never blocks on abandoned request
+	respChan := make(chan Response, 1)
 
 	// preparing request ID to be able to monitor and wait for response
 	b.resultsMtx.Lock()
+	if b.closed {
+		b.resultsMtx.Unlock()
+		return nil, ErrConnectionClosed
+	}
 	id, err := b.findFirstFreeIntKey()
 	if err != nil {
 		b.resultsMtx.Unlock()
-		return err
+		return nil, err
 	}
 	b.results[id] = respChan
 	b.resultsMtx.Unlock()
 
-	defer func(ch chan Response, id int) {
-		close(ch)
+	defer func(id int) {
+		b.resultsMtx.Lock()
 		delete(b.results, id)
-	}(respChan, id)
+		b.resultsMtx.Unlock()
+	}(id)
 
 	realCommand := newCompleteCommand(c, id)
 	message, err := json.Marshal(realCommand)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Printf("[%s] request: %s\n", b.Ip, message)
 	message = append(message, CR, LF)
 
+	// power is read before sending, device may notify about toggled power before responding
+	power := b.cachedPower(c)
 	_, err = b.conn.Write(message)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// waiting for response on that request
 	resp := <-respChan
-	return resp.ok()
+	if err := resp.ok(); err != nil {
+		return nil, err
+	}
+	b.updateCacheFromCommand(c, power)
+	return resp.result(), nil
 }
 
 func openSocket(host string, min, max int) (net.Listener, int, error) {
@@ -149,51 +215,85 @@ func keysExists(m map[string]interface{}, keys ...string) bool {
 // responseProcessor is run internally by Connect() function.
 // Tt's responsible for monitoring command responses and notifications
 func (b *Bulb) responseProcessor() {
-	var buff = make([]byte, 512)
 	var resp map[string]interface{}
+	reader := bufio.NewReader(b.conn)
 
 	for {
-		n, err := b.conn.Read(buff)
+		r, err := reader.ReadBytes(LF)
 		if err != nil {
 			break
 		}
+		r = bytes.TrimSpace(r)
+		if len(r) == 0 {
+			continue
+		}
 
-		responses := bytes.Split(buff[:n], []byte{CR, LF})
+		resp = make(map[string]interface{})
 
-		for _, r := range responses[:len(responses)-1] {
-			resp = make(map[string]interface{})
+		err = json.Unmarshal(r, &resp)
+		if err != nil {
+			log.Printf("OKResponse err: %s\n", r)
+			continue
+		}
 
-			err = json.Unmarshal(r, &resp)
+		switch {
+		case keysExists(resp, "id", "result"): // Command success
+			var unmarshaled OKResponse
+			err = json.Unmarshal(r, &unmarshaled)
 			if err != nil {
-				log.Printf("OKResponse err: %s\n", r)
+				log.Printf("second unmarshal error: %s\n", r)
 				continue
 			}
-
-			switch {
-			case keysExists(resp, "id", "result"): // Command success
-				var unmarshaled OKResponse
-				err = json.Unmarshal(r, &unmarshaled)
-				if err != nil {
-					log.Printf("second unmarshal error: %s\n", r)
-				}
-				b.results[unmarshaled.id()] <- &unmarshaled
-			case keysExists(resp, "id", "error"): // Command failed
-				var unmarshaled ERRResponse
-				err = json.Unmarshal(r, &unmarshaled)
-				if err != nil {
-					log.Printf("second unmarshal error: %s\n", r)
-				}
-				b.results[unmarshaled.id()] <- &unmarshaled
-			case keysExists(resp, "method", "params"): // Notification
-				// log.Printf("state change%s\n", r)
-			default:
-				log.Printf("unhandled response: %s\n", r)
+			b.deliver(&unmarshaled)
+		case keysExists(resp, "id", "error"): // Command failed
+			var unmarshaled ERRResponse
+			err = json.Unmarshal(r, &unmarshaled)
+			if err != nil {
+				log.Printf("second unmarshal error: %s\n", r)
+				continue
+			}
+			b.deliver(&unmarshaled)
+		case keysExists(resp, "method", "params"): // Notification
+			notification, err := parseNotification(r)
+			if err != nil {
+				log.Printf("notification unmarshal error: %s\n", r)
+				continue
 			}
+			b.updateCacheFromNotification(notification)
+			b.publish(notification)
+		default:
+			log.Printf("unhandled response: %s\n", r)
 		}
 	}
+
+	// connection is closed, pending requests won't get their responses
+	b.resultsMtx.Lock()
+	b.closed = true
+	for id, ch := range b.results {
+		ch <- &closedResponse{ID: id}
+	}
+	b.resultsMtx.Unlock()
+	b.closeSubscriptions()
+
 	log.Printf("response processor exited\n")
 }
 
+// deliver passes response to request waiting for it
+func (b *Bulb) deliver(resp Response) {
+	b.resultsMtx.Lock()
+	defer b.resultsMtx.Unlock()
+
+	ch, ok := b.results[resp.id()]
+	if !ok {
+		log.Printf("response for unknown request: %d\n", resp.id())
+		return
+	}
+	select {
+	case ch <- resp:
+	default:
+	}
+}
+
 // findFirstFreeIntKey finds available (unique) id which will be used as command identifier
 func (b *Bulb) findFirstFreeIntKey() (int, error) {
 	for i := 0; i < 100; i++ {
@@ -0,0 +1,101 @@
+package yeelight
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+)
+
+// Calibration describes corrections applied to values sent to the device, so bulbs of different
+// batches or models look the same. Zero values are treated as "no correction"
+type Calibration struct {
+	Gamma             float64 `json:"gamma"`              // brightness curve, output = 100 * (brightness / 100) ^ gamma
+	RedGain           float64 `json:"red_gain"`           // red channel multiplier
+	GreenGain         float64 `json:"green_gain"`         // green channel multiplier
+	BlueGain          float64 `json:"blue_gain"`          // blue channel multiplier
+	TemperatureOffset int     `json:"temperature_offset"` // kelvins added to requested temperature
+}
+
+// Validate checks if calibration values are reasonable
+func (c *Calibration) Validate() error {
+	if c.Gamma < 0 {
+		return errors.New("gamma cannot be negative")
+	}
+	if c.RedGain < 0 || c.GreenGain < 0 || c.BlueGain < 0 {
+		return errors.New("channel gains cannot be negative")
+	}
+	return nil
+}
+
+// Brightness returns corrected brightness, result stays in 1-100 range
+func (c *Calibration) Brightness(brightness int) int {
+	if c == nil || c.Gamma == 0 || c.Gamma == 1 {
+		return brightness
+	}
+
+	corrected := int(math.Round(100 * math.Pow(float64(brightness)/100, c.Gamma)))
+	if corrected < 1 {
+		return 1
+	}
+	if corrected > 100 {
+		return 100
+	}
+	return corrected
+}
+
+// RGB returns 0xRRGGBB color with applied channel gains, channels are clipped to 0-255 range
+func (c *Calibration) RGB(rgb int) int {
+	if c == nil {
+		return rgb
+	}
+
+	apply := func(channel int, gain float64) int {
+		if gain == 0 {
+			return channel
+		}
+		return int(math.Round(math.Max(0, math.Min(255, float64(channel)*gain))))
+	}
+	return apply(rgb>>16&0xff, c.RedGain)<<16 | apply(rgb>>8&0xff, c.GreenGain)<<8 | apply(rgb&0xff, c.BlueGain)
+}
+
+// Temperature returns temperature with applied offset, result stays in 1700-6500 range
+func (c *Calibration) Temperature(temp int) int {
+	if c == nil {
+		return temp
+	}
+
+	corrected := temp + c.TemperatureOffset
+	if corrected < 1700 {
+		return 1700
+	}
+	if corrected > 6500 {
+		return 6500
+	}
+	return corrected
+}
+
+// LoadCalibrations reads calibration profiles from JSON file, profiles are keyed by any bulb identifier
+// (IP address, device id or name), example:
+//   {
+//     "192.168.0.123": {"gamma": 1.8, "red_gain": 1, "green_gain": 0.9, "blue_gain": 0.85},
+//     "0x0000000002dfb19a": {"temperature_offset": -200}
+//   }
+func LoadCalibrations(path string) (map[string]Calibration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var calibrations map[string]Calibration
+	if err := json.Unmarshal(data, &calibrations); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for key, calibration := range calibrations {
+		if err := calibration.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: \"%s\": %v", path, key, err)
+		}
+	}
+	return calibrations, nil
+}
@@ -0,0 +1,124 @@
+package yeelight
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCalibrationBrightness(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration *Calibration
+		brightness  int
+		expected    int
+	}{
+		{"nil", nil, 40, 40},
+		{"zero gamma", &Calibration{}, 40, 40},
+		{"linear", &Calibration{Gamma: 1}, 40, 40},
+		{"gamma 2", &Calibration{Gamma: 2}, 50, 25},
+		{"gamma 0.5", &Calibration{Gamma: 0.5}, 25, 50},
+		{"clamped low", &Calibration{Gamma: 3}, 1, 1},
+		{"full", &Calibration{Gamma: 2.2}, 100, 100},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := test.calibration.Brightness(test.brightness); result != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCalibrationRGB(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration *Calibration
+		rgb         int
+		expected    int
+	}{
+		{"nil", nil, 0x123456, 0x123456},
+		{"zero gains", &Calibration{}, 0x123456, 0x123456},
+		{"half red", &Calibration{RedGain: 0.5}, 0x808080, 0x408080},
+		{"all gains", &Calibration{RedGain: 1, GreenGain: 0.5, BlueGain: 0.25}, 0xffffff, 0xff8040},
+		{"clipped", &Calibration{BlueGain: 2}, 0x0000c0, 0x0000ff},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := test.calibration.RGB(test.rgb); result != test.expected {
+				t.Errorf("expected %06x, got %06x", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCalibrationTemperature(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration *Calibration
+		temp        int
+		expected    int
+	}{
+		{"nil", nil, 4000, 4000},
+		{"offset", &Calibration{TemperatureOffset: -200}, 4000, 3800},
+		{"clamped low", &Calibration{TemperatureOffset: -500}, 2000, 1700},
+		{"clamped high", &Calibration{TemperatureOffset: 500}, 6300, 6500},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := test.calibration.Temperature(test.temp); result != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestCalibrationValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration Calibration
+		valid       bool
+	}{
+		{"zero", Calibration{}, true},
+		{"valid", Calibration{Gamma: 1.8, RedGain: 1, GreenGain: 0.9, BlueGain: 0.85}, true},
+		{"negative gamma", Calibration{Gamma: -1}, false},
+		{"negative gain", Calibration{GreenGain: -0.1}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.calibration.Validate(); (err == nil) != test.valid {
+				t.Errorf("expected valid=%v, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestLoadCalibrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "calibration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "calibration.json")
+	data := `{"192.168.0.123": {"gamma": 1.8, "red_gain": 1}, "0x0000000002dfb19a": {"temperature_offset": -200}}`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	calibrations, err := LoadCalibrations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calibrations) != 2 || calibrations["192.168.0.123"].Gamma != 1.8 ||
+		calibrations["0x0000000002dfb19a"].TemperatureOffset != -200 {
+		t.Errorf("unexpected calibrations: %+v", calibrations)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"bulb": {"blue_gain": -1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCalibrations(path); err == nil {
+		t.Error("expected error for negative gain")
+	}
+}
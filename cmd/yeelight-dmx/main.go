@@ -0,0 +1,180 @@
+// Command yeelight-dmx drives bulbs from lighting consoles over Art-Net and E1.31 (sACN), bulbs are patched
+// onto DMX channels by patch file (see dmx package) and updated through music mode:
+//   yeelight-dmx -config bulbs.json -patch patch.json
+//
+// The same command sends synthetic DMX (rainbow chase over patched bulbs), so setup can be tested
+// without console, for instance over loopback:
+//   yeelight-dmx -patch patch.json -send 127.0.0.1 -protocol sacn -duration 30s
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/dmx"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", defaultConfigPath(), "bulbs registry file")
+		patchPath  = flag.String("patch", "patch.json", "patch file")
+		artNet     = flag.String("artnet", ":6454", "Art-Net listen address, empty disables Art-Net")
+		sACN       = flag.Bool("sacn", true, "receive E1.31 (sACN) multicast and unicast packets")
+		sACNIface  = flag.String("sacn-iface", "", "network interface used for E1.31 multicast")
+		iface      = flag.String("iface", "", "network interface used for music mode")
+		interval   = flag.Duration("interval", 50*time.Millisecond, "minimum interval between updates of a bulb")
+		send       = flag.String("send", "", "send synthetic DMX to given address instead of receiving")
+		protocol   = flag.String("protocol", "artnet", "protocol of synthetic DMX, artnet or sacn")
+		rate       = flag.Int("rate", 30, "synthetic DMX frames per second")
+		period     = flag.Duration("period", 5*time.Second, "period of synthetic rainbow chase")
+		duration   = flag.Duration("duration", 0, "duration of synthetic DMX, 0 sends until interrupted")
+		verbose    = flag.Bool("v", false, "log commands sent to bulbs")
+	)
+	flag.Parse()
+
+	// library logs every command, daemon logs are kept separately
+	logger := log.New(os.Stderr, "yeelight-dmx: ", log.LstdFlags)
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	patch, err := dmx.LoadPatch(*patchPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if *send != "" {
+		p := dmx.ArtNet
+		switch *protocol {
+		case "artnet":
+		case "sacn":
+			p = dmx.E131
+		default:
+			logger.Fatalf("unknown protocol \"%s\", expected artnet or sacn", *protocol)
+		}
+		if err := chase(*send, p, patch, *rate, *period, *duration); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	reg, err := registry.Load(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer reg.Close()
+
+	receiver := dmx.NewReceiver(reg, patch)
+	receiver.SetMusicInterface(*iface)
+	receiver.SetInterval(*interval)
+	defer receiver.Close()
+
+	var conns []net.PacketConn
+	if *artNet != "" {
+		conn, err := dmx.ListenArtNet(*artNet)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+	if *sACN {
+		listeners, err := dmx.ListenE131(*sACNIface, patch.Universes())
+		if err != nil {
+			logger.Fatal(err)
+		}
+		conns = append(conns, listeners...)
+	}
+	if len(conns) == 0 {
+		logger.Fatal("both Art-Net and E1.31 are disabled")
+	}
+	for _, conn := range conns {
+		defer conn.Close()
+		go func(conn net.PacketConn) {
+			logger.Fatal(receiver.Serve(conn))
+		}(conn)
+	}
+
+	logger.Printf("receiving %d universe(s), %d bulb(s) patched", len(patch.Universes()), len(patch))
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+}
+
+// chase sends rainbow moving over patched bulbs, temperature bulbs cycle between warm and cold white
+func chase(address string, protocol dmx.Protocol, patch dmx.Patch, rate int, period, duration time.Duration) error {
+	sender, err := dmx.DialSender(address, protocol)
+	if err != nil {
+		return err
+	}
+	defer sender.Close()
+
+	if rate < 1 {
+		rate = 1
+	}
+	var (
+		started   = time.Now()
+		ticker    = time.NewTicker(time.Second / time.Duration(rate))
+		interrupt = make(chan os.Signal, 1)
+	)
+	defer ticker.Stop()
+	signal.Notify(interrupt, os.Interrupt)
+
+	for {
+		elapsed := time.Since(started)
+		if duration > 0 && elapsed > duration {
+			return nil
+		}
+		phase := float64(elapsed%period) / float64(period)
+
+		universes := make(map[int][]byte)
+		for i, f := range patch {
+			data, ok := universes[f.Universe]
+			if !ok {
+				data = make([]byte, 512)
+				universes[f.Universe] = data
+			}
+			offset := math.Mod(phase+float64(i)/float64(len(patch)), 1)
+
+			ch := data[f.Address-1:]
+			switch f.Mode {
+			case dmx.ModeRGB, dmx.ModeRGBDimmer:
+				rgb := yl.ColorFromHSV(offset*360, 100, 100).RGB()
+				ch[0], ch[1], ch[2] = byte(rgb>>16), byte(rgb>>8), byte(rgb)
+				if f.Mode == dmx.ModeRGBDimmer {
+					ch[3] = 255
+				}
+			case dmx.ModeTemperature:
+				ch[0] = byte(math.Round(127.5 - 127.5*math.Cos(offset*2*math.Pi)))
+				ch[1] = 255
+			}
+		}
+		for universe, data := range universes {
+			if err := sender.Send(universe, data); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
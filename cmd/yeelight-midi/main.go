@@ -0,0 +1,150 @@
+// Command yeelight-midi drives bulbs from registry file by MIDI (see midi package), Standard MIDI Files
+// are played in real time and raw MIDI devices are followed live:
+//   yeelight-midi -mapping show.json -file show.mid
+//   yeelight-midi -mapping show.json -file show.mid -dump
+//   yeelight-midi -mapping keys.json -input /dev/snd/midiC1D0
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/gethiox/yeelight-go/midi"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+func main() {
+	var (
+		configPath  = flag.String("config", defaultConfigPath(), "bulbs registry file")
+		mappingPath = flag.String("mapping", "", "channel mapping file (required)")
+		filePath    = flag.String("file", "", "Standard MIDI File to play")
+		inputPath   = flag.String("input", "", "raw MIDI device to follow, e.g. /dev/snd/midiC1D0")
+		loop        = flag.Bool("loop", false, "play file repeatedly")
+		dump        = flag.Bool("dump", false, "print cues of file and exit")
+		iface       = flag.String("iface", "", "network interface used for music mode")
+		interval    = flag.Duration("interval", 50*time.Millisecond, "minimum interval between updates of a bulb")
+		verbose     = flag.Bool("v", false, "log commands sent to bulbs")
+	)
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "yeelight-midi: ", log.LstdFlags)
+	if *mappingPath == "" || (*filePath == "") == (*inputPath == "") {
+		fmt.Fprintln(os.Stderr, "usage: yeelight-midi -mapping file (-file song.mid | -input device) [flags]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	mapping, err := midi.LoadMapping(*mappingPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	var cues []midi.Cue
+	if *filePath != "" {
+		file, err := midi.ReadFile(*filePath)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		cues = midi.Schedule(file, mapping)
+		if *dump {
+			for _, c := range cues {
+				fmt.Println(c)
+			}
+			return
+		}
+		logger.Printf("%s: format %d, %d track(s), %v, %d cue(s)", *filePath, file.Format, len(file.Tracks),
+			file.Duration().Round(time.Millisecond), len(cues))
+	}
+
+	reg, err := registry.Load(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer reg.Close()
+
+	player := midi.NewPlayer(reg)
+	player.SetMusicInterface(*iface)
+	player.SetInterval(*interval)
+	defer player.Close()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		player.Close()
+	}()
+
+	if *inputPath != "" {
+		follow(logger, player, mapping, *inputPath, *verbose)
+		return
+	}
+
+	if err := player.Prepare(midi.Targets(cues)); err != nil {
+		logger.Fatal(err)
+	}
+	for {
+		if !player.Play(cues) || !*loop {
+			break
+		}
+	}
+	// the last cues are sent by workers after update interval
+	time.Sleep(*interval)
+}
+
+// follow maps events of raw MIDI device until device is closed or interrupted
+func follow(logger *log.Logger, player *midi.Player, mapping midi.Mapping, path string, verbose bool) {
+	device, err := os.Open(path)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	go func() {
+		// closing device unblocks reader when player is closed by interrupt
+		<-player.Done()
+		_ = device.Close()
+	}()
+
+	var (
+		reader = midi.NewReader(device)
+		mapper = midi.NewMapper(mapping)
+	)
+	logger.Printf("following %s", path)
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			select {
+			case <-player.Done():
+			default:
+				if err != io.EOF {
+					logger.Print(err)
+				}
+			}
+			return
+		}
+		if verbose {
+			logger.Printf("channel %d: %v %d %d", event.Channel, event.Type, event.Key, event.Value)
+		}
+		for _, c := range mapper.Map(event) {
+			if err := player.Send(c); err != nil {
+				logger.Printf("%s: %v", c.Target, err)
+			}
+		}
+	}
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
@@ -0,0 +1,140 @@
+// Command yeelight-mqtt bridges bulbs from registry file with MQTT broker (see mqttbridge package),
+// registered bulbs are announced to Home Assistant by MQTT discovery:
+//   yeelight-mqtt -broker localhost:1883 -user homeassistant -password secret -config bulbs.json
+//   mosquitto_pub -t yeelight/0x0000000007e7bcaa/set -m '{"state": "ON", "color_temp": 370, "transition": 2}'
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gethiox/yeelight-go/mqtt"
+	"github.com/gethiox/yeelight-go/mqttbridge"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+func main() {
+	var (
+		broker          = flag.String("broker", "localhost:1883", "MQTT broker address")
+		clientID        = flag.String("client-id", "yeelight-mqtt", "MQTT client identifier")
+		username        = flag.String("user", "", "MQTT user name")
+		password        = flag.String("password", "", "MQTT password")
+		configPath      = flag.String("config", defaultConfigPath(), "bulbs registry file")
+		prefix          = flag.String("prefix", "yeelight", "prefix of bulb topics")
+		discoveryPrefix = flag.String("discovery-prefix", "homeassistant", "Home Assistant discovery prefix, empty disables discovery")
+		refresh         = flag.Duration("refresh", 5*time.Minute, "discovery interval, 0 disables discovery")
+		timeout         = flag.Duration("timeout", 2*time.Second, "discovery timeout")
+		verbose         = flag.Bool("v", false, "log commands sent to bulbs")
+	)
+	flag.Parse()
+
+	// library logs every command, bridge logs are kept separately
+	logger := log.New(os.Stderr, "yeelight-mqtt: ", log.LstdFlags)
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	reg, err := registry.Load(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer reg.Close()
+
+	var (
+		bridge    *mqttbridge.Bridge
+		bridgeMtx sync.Mutex
+	)
+	client, err := mqtt.Dial(*broker, mqtt.Options{
+		ClientID: *clientID,
+		Username: *username,
+		Password: *password,
+		Will: &mqtt.Message{
+			Topic:   mqttbridge.AvailabilityTopic(*prefix),
+			Payload: []byte("offline"),
+			Retain:  true,
+		},
+		OnConnect: func() {
+			// broker or Home Assistant could be restarted while connection was lost
+			bridgeMtx.Lock()
+			defer bridgeMtx.Unlock()
+			if bridge != nil {
+				if err := bridge.Announce(); err != nil {
+					logger.Printf("announcing bridge failed: %v", err)
+				}
+			}
+		},
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer client.Close()
+
+	bridgeMtx.Lock()
+	bridge = mqttbridge.New(client, reg)
+	bridge.SetPrefix(*prefix)
+	bridge.SetDiscoveryPrefix(*discoveryPrefix)
+	err = bridge.Start()
+	bridgeMtx.Unlock()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer bridge.Close()
+
+	if *refresh > 0 {
+		discover(reg, bridge, *configPath, *timeout, logger)
+		go func() {
+			for range time.Tick(*refresh) {
+				discover(reg, bridge, *configPath, *timeout, logger)
+			}
+		}()
+	}
+
+	logger.Printf("connected to %s, %d bulb(s) registered", *broker, len(reg.Entries()))
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+}
+
+// discover updates registry with found devices, new bulbs are announced and registry is saved
+func discover(reg *registry.Registry, bridge *mqttbridge.Bridge, configPath string, timeout time.Duration, logger *log.Logger) {
+	changed, err := reg.Refresh(timeout)
+	if err != nil {
+		logger.Printf("discovery failed: %v", err)
+		return
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	for _, entry := range changed {
+		logger.Printf("bulb \"%s\" found at %s", entry.Name, entry.Ip)
+		// connection to previous address is dropped, monitor reconnects to the new one
+		_ = reg.Disconnect(entry.ID)
+	}
+	if err := bridge.Announce(); err != nil {
+		logger.Printf("announcing bridge failed: %v", err)
+	}
+	bridge.Sync()
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		logger.Printf("saving registry failed: %v", err)
+		return
+	}
+	if err := reg.Save(); err != nil {
+		logger.Printf("saving registry failed: %v", err)
+	}
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
@@ -0,0 +1,79 @@
+// Command yeelight-osc controls bulbs from registry file by OSC messages of control surfaces and DAWs
+// (see osc package), addresses of control surface layout can be bound to actions by map file:
+//   yeelight-osc -listen :8000 -config bulbs.json -map touchosc.json
+//   oscsend localhost 8000 /yeelight/office/desk/rgb fff 1.0 0.5 0.0
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/gethiox/yeelight-go/osc"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+func main() {
+	var (
+		listen     = flag.String("listen", ":8000", "OSC (UDP) listen address")
+		configPath = flag.String("config", defaultConfigPath(), "bulbs registry file")
+		mapPath    = flag.String("map", "", "address map file")
+		prefix     = flag.String("prefix", "/yeelight", "prefix of default addresses, empty disables default addresses")
+		iface      = flag.String("iface", "", "network interface used for music mode")
+		interval   = flag.Duration("interval", 50*time.Millisecond, "minimum interval between updates of a bulb")
+		verbose    = flag.Bool("v", false, "log commands sent to bulbs")
+	)
+	flag.Parse()
+
+	// library logs every command, daemon logs are kept separately
+	logger := log.New(os.Stderr, "yeelight-osc: ", log.LstdFlags)
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	reg, err := registry.Load(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer reg.Close()
+
+	server := osc.New(reg)
+	server.SetPrefix(*prefix)
+	server.SetMusicInterface(*iface)
+	server.SetInterval(*interval)
+	if *mapPath != "" {
+		m, err := osc.LoadMap(*mapPath)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		server.SetMap(m)
+	}
+	defer server.Close()
+
+	conn, err := net.ListenPacket("udp", *listen)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		logger.Fatal(server.Serve(conn))
+	}()
+
+	logger.Printf("listening on %s, %d bulb(s) registered", *listen, len(reg.Entries()))
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
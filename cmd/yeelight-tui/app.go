@@ -0,0 +1,496 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+)
+
+// slider describes value adjusted with left and right keys
+type slider struct {
+	name     string
+	min, max int
+	step     int
+	prop     yl.Property
+	unit     string
+}
+
+var sliders = []slider{
+	{"Brightness", 1, 100, 5, yl.PROP_BRIGHT, "%"},
+	{"Temperature", 1700, 6500, 100, yl.PROP_CT, "K"},
+	{"Hue", 0, 359, 10, yl.PROP_HUE, "°"},
+}
+
+// light is a single row of bulbs list
+type light struct {
+	name string
+	ip   string
+	bulb *yl.Bulb // nil when device is offline
+	err  error    // connection error
+
+	pending map[int]int // slider values not sent yet, keyed by slider index
+}
+
+func (l *light) state() yl.State {
+	if l.bulb == nil {
+		return yl.State{}
+	}
+	state, _ := l.bulb.State()
+	return state
+}
+
+// value returns current slider value, pending value has precedence over reported state
+func (l *light) value(i int) int {
+	if value, ok := l.pending[i]; ok {
+		return value
+	}
+	value, _ := l.state().Int(sliders[i].prop)
+	return value
+}
+
+// color returns approximate color of the light for the preview
+func (l *light) color() int {
+	state := l.state()
+	mode, _ := state.Int(yl.PROP_COLOR_MODE)
+	switch mode {
+	case 1:
+		rgb, _ := state.Int(yl.PROP_RGB)
+		return rgb
+	case 2:
+		ct, _ := state.Int(yl.PROP_CT)
+		return yl.KelvinToRGB(ct)
+	case 3:
+		hue, _ := state.Int(yl.PROP_HUE)
+		sat, _ := state.Int(yl.PROP_SAT)
+		return yl.ColorFromHSV(float64(hue), float64(sat), 100).RGB()
+	}
+	return 0
+}
+
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeFlows       // flow preset picker
+	modePaint       // music mode, changes are applied immediately
+)
+
+type app struct {
+	term     *terminal
+	lights   []*light
+	selected int
+	slider   int
+	mode     mode
+	preset   int // selected flow preset in modeFlows
+
+	// paint mode state
+	music              *yl.Music
+	paintHue, paintSat int
+	paintBright        int
+	paintStarting      bool
+
+	status    string
+	statusErr bool
+
+	updates  chan struct{}   // state of any bulb changed
+	messages chan appMessage // results of commands executed in background
+}
+
+type appMessage struct {
+	text  string
+	err   bool
+	music *yl.Music // set when music mode was started
+}
+
+func newApp(term *terminal, lights []*light) *app {
+	a := &app{
+		term:     term,
+		lights:   lights,
+		updates:  make(chan struct{}, 1),
+		messages: make(chan appMessage, 16),
+	}
+
+	for _, l := range lights {
+		if l.bulb == nil {
+			continue
+		}
+		go a.watch(l.bulb)
+	}
+	return a
+}
+
+// watch forwards bulb notifications as redraw requests
+func (a *app) watch(bulb *yl.Bulb) {
+	subscription := bulb.Subscribe()
+	for range subscription.C {
+		select {
+		case a.updates <- struct{}{}:
+		default:
+		}
+	}
+	a.messages <- appMessage{text: fmt.Sprintf("%s: connection lost", bulb.Ip), err: true}
+}
+
+// run executes command in background, so slow device doesn't block user interface
+func (a *app) run(description string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			a.messages <- appMessage{text: fmt.Sprintf("%s: %v", description, err), err: true}
+			return
+		}
+		a.messages <- appMessage{text: description}
+	}()
+}
+
+func (a *app) loop() {
+	keys := make(chan key)
+	go readKeys(keys)
+
+	// slider changes are collected and sent periodically, so holding a key doesn't exceed quota
+	ticker := time.NewTicker(300 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		a.render()
+
+		select {
+		case k, ok := <-keys:
+			if !ok || !a.handleKey(k) {
+				a.stopPaint()
+				return
+			}
+		case <-a.updates:
+		case msg := <-a.messages:
+			if msg.music != nil {
+				a.music = msg.music
+				a.paintStarting = false
+				a.paint()
+				continue
+			}
+			a.status, a.statusErr = msg.text, msg.err
+		case <-ticker.C:
+			a.flush()
+		}
+	}
+}
+
+// current returns selected light
+func (a *app) current() *light {
+	if len(a.lights) == 0 {
+		return nil
+	}
+	return a.lights[a.selected]
+}
+
+// online returns selected light when it's connected, status message is set otherwise
+func (a *app) online() *light {
+	l := a.current()
+	if l == nil || l.bulb == nil {
+		a.status, a.statusErr = "bulb is offline", true
+		return nil
+	}
+	return l
+}
+
+// handleKey returns false when application should exit
+func (a *app) handleKey(k key) bool {
+	if k.code == keyInterrupt {
+		return false
+	}
+
+	switch a.mode {
+	case modeFlows:
+		a.handleFlowsKey(k)
+		return true
+	case modePaint:
+		a.handlePaintKey(k)
+		return true
+	}
+
+	switch {
+	case k.code == keyRune && k.r == 'q':
+		return false
+	case k.code == keyUp || k.code == keyRune && k.r == 'k':
+		if a.selected > 0 {
+			a.selected--
+		}
+	case k.code == keyDown || k.code == keyRune && k.r == 'j':
+		if a.selected < len(a.lights)-1 {
+			a.selected++
+		}
+	case k.code == keyTab:
+		a.slider = (a.slider + 1) % len(sliders)
+	case k.code == keyLeft || k.code == keyRune && k.r == 'h':
+		a.adjust(-1)
+	case k.code == keyRight || k.code == keyRune && k.r == 'l':
+		a.adjust(1)
+	case k.code == keyRune && k.r == ' ':
+		if l := a.online(); l != nil {
+			a.run(l.name+": toggle", l.bulb.Toggle)
+		}
+	case k.code == keyRune && k.r == 'f':
+		if a.online() != nil {
+			a.mode = modeFlows
+		}
+	case k.code == keyRune && k.r == 's':
+		if l := a.online(); l != nil {
+			a.run(l.name+": flow stopped", l.bulb.StopColorFlow)
+		}
+	case k.code == keyRune && k.r == 'p':
+		a.startPaint()
+	case k.code == keyRune && k.r == 'r':
+		for _, l := range a.lights {
+			if l.bulb != nil {
+				a.run(l.name+": synchronized", l.bulb.SyncState)
+			}
+		}
+	}
+	return true
+}
+
+// adjust changes focused slider of selected light by given amount of steps
+func (a *app) adjust(steps int) {
+	l := a.online()
+	if l == nil {
+		return
+	}
+	s := sliders[a.slider]
+
+	value := l.value(a.slider) + steps*s.step
+	if value < s.min {
+		value = s.min
+	}
+	if value > s.max {
+		value = s.max
+	}
+	l.pending[a.slider] = value
+}
+
+// flush sends pending slider values
+func (a *app) flush() {
+	for _, l := range a.lights {
+		if l.bulb == nil || len(l.pending) == 0 {
+			continue
+		}
+		for i, value := range l.pending {
+			bulb, value := l.bulb, value
+			transition := yl.Smooth(200 * time.Millisecond)
+
+			var fn func() error
+			switch sliders[i].prop {
+			case yl.PROP_BRIGHT:
+				fn = func() error { return bulb.SetBrightness(value, transition) }
+			case yl.PROP_CT:
+				fn = func() error { return bulb.SetTemperature(value, transition) }
+			case yl.PROP_HUE:
+				fn = func() error { return bulb.SetHSV(value, 100, transition) }
+			}
+			a.run(fmt.Sprintf("%s: %s %d%s", l.name, sliders[i].name, value, sliders[i].unit), fn)
+		}
+		l.pending = make(map[int]int)
+	}
+}
+
+func (a *app) handleFlowsKey(k key) {
+	names := flows.Names()
+
+	switch {
+	case k.code == keyEscape || k.code == keyRune && k.r == 'q':
+		a.mode = modeNormal
+	case k.code == keyUp || k.code == keyRune && k.r == 'k':
+		if a.preset > 0 {
+			a.preset--
+		}
+	case k.code == keyDown || k.code == keyRune && k.r == 'j':
+		if a.preset < len(names)-1 {
+			a.preset++
+		}
+	case k.code == keyEnter:
+		a.mode = modeNormal
+		l := a.online()
+		if l == nil {
+			return
+		}
+		flow, err := flows.Preset(names[a.preset])
+		if err != nil {
+			a.status, a.statusErr = err.Error(), true
+			return
+		}
+		bulb := l.bulb
+		a.run(fmt.Sprintf("%s: %s flow started", l.name, names[a.preset]), func() error { return flow.Start(bulb) })
+	}
+}
+
+func (a *app) startPaint() {
+	l := a.online()
+	if l == nil {
+		return
+	}
+
+	a.mode = modePaint
+	a.paintHue, a.paintSat = l.value(2), 100
+	a.paintBright = l.value(0)
+	if a.paintBright < 1 {
+		a.paintBright = 100
+	}
+	a.paintStarting = true
+	a.status, a.statusErr = "starting music mode...", false
+
+	bulb := l.bulb
+	go func() {
+		if err := bulb.PowerOn(0); err != nil {
+			a.messages <- appMessage{text: fmt.Sprintf("music mode: %v", err), err: true}
+			return
+		}
+		music, err := bulb.StartMusic(*iface)
+		if err != nil {
+			a.messages <- appMessage{text: fmt.Sprintf("music mode: %v", err), err: true}
+			return
+		}
+		a.messages <- appMessage{music: music}
+	}()
+}
+
+func (a *app) stopPaint() {
+	if a.music != nil {
+		_ = a.music.Stop()
+	}
+	a.music = nil
+	a.paintStarting = false
+}
+
+func (a *app) handlePaintKey(k key) {
+	switch {
+	case k.code == keyEscape || k.code == keyRune && (k.r == 'q' || k.r == 'p'):
+		a.stopPaint()
+		a.mode = modeNormal
+		a.status, a.statusErr = "music mode stopped", false
+		if l := a.current(); l != nil && l.bulb != nil {
+			a.run(l.name+": synchronized", l.bulb.SyncState)
+		}
+		return
+	case k.code == keyLeft || k.code == keyRune && k.r == 'h':
+		a.paintHue = (a.paintHue + 355) % 360
+	case k.code == keyRight || k.code == keyRune && k.r == 'l':
+		a.paintHue = (a.paintHue + 5) % 360
+	case k.code == keyUp || k.code == keyRune && k.r == 'k':
+		a.paintBright = clamp(a.paintBright+5, 1, 100)
+	case k.code == keyDown || k.code == keyRune && k.r == 'j':
+		a.paintBright = clamp(a.paintBright-5, 1, 100)
+	case k.code == keyRune && k.r == ']':
+		a.paintSat = clamp(a.paintSat+5, 0, 100)
+	case k.code == keyRune && k.r == '[':
+		a.paintSat = clamp(a.paintSat-5, 0, 100)
+	default:
+		return
+	}
+	a.paint()
+}
+
+// paint sends current paint color, music mode has no quota so changes are sent immediately
+func (a *app) paint() {
+	if a.music == nil {
+		return
+	}
+	a.music.SetHSV(a.paintHue, a.paintSat, yl.Sudden)
+	a.music.SetBrightness(a.paintBright, yl.Sudden)
+	a.status, a.statusErr = fmt.Sprintf("painting: hue %d°, saturation %d%%, brightness %d%%", a.paintHue, a.paintSat, a.paintBright), false
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func (a *app) render() {
+	width, height := a.term.size()
+	s := &screen{width: width, height: height}
+
+	s.line("%s Yeelight %s%s", styleReverse+styleBold, styleReset, styleDim+fmt.Sprintf(" %d bulb(s)", len(a.lights)))
+	s.line("")
+	s.line("%s   %-20s %-16s %-7s %-7s %-7s %-5s %-8s%s", styleBold, "NAME", "IP", "POWER", "BRIGHT", "CT", "HUE", "MODE", styleReset)
+	for i, l := range a.lights {
+		cursor := "  "
+		if i == a.selected {
+			cursor = styleBold + "> "
+		}
+		if l.bulb == nil {
+			s.line(" %s%-20s %-16s %soffline: %s", cursor, truncate(l.name, 20), l.ip, styleRed,
+				truncate(fmt.Sprint(l.err), width-50))
+			continue
+		}
+
+		state := l.state()
+		colorMode := map[string]string{"1": "rgb", "2": "ct", "3": "hsv"}[state.Get(yl.PROP_COLOR_MODE)]
+		if state.Get(yl.PROP_FLOWING) == "1" {
+			colorMode = "flow"
+		}
+		s.line(" %s%-20s %-16s %-7s %-7s %-7s %-5s %-8s %s", cursor, truncate(l.name, 20), l.ip,
+			state.Get(yl.PROP_POWER), state.Get(yl.PROP_BRIGHT), state.Get(yl.PROP_CT), state.Get(yl.PROP_HUE),
+			colorMode, swatch(l.color()))
+	}
+	s.line("")
+
+	if l := a.current(); l != nil && l.bulb != nil {
+		switch a.mode {
+		case modeNormal:
+			s.line(" %s%s%s", styleBold, l.name, styleReset)
+			for i, sl := range sliders {
+				marker, style := "  ", ""
+				if i == a.slider {
+					marker, style = "> ", styleBold
+				}
+				value := l.value(i)
+				s.line(" %s%s%-12s [%s] %d%s%s", marker, style, sl.name, bar(value, sl.min, sl.max, 30), value, sl.unit, styleReset)
+			}
+		case modeFlows:
+			s.line(" %sflow presets for %s%s", styleBold, l.name, styleReset)
+			for i, name := range flows.Names() {
+				if i == a.preset {
+					s.line("   %s %s %s", styleReverse, name, styleReset)
+				} else {
+					s.line("    %s", name)
+				}
+			}
+		case modePaint:
+			s.line(" %spaint mode: %s%s", styleBold, l.name, styleReset)
+			s.line("   %-12s [%s] %d°", "Hue", bar(a.paintHue, 0, 359, 30), a.paintHue)
+			s.line("   %-12s [%s] %d%%", "Saturation", bar(a.paintSat, 0, 100, 30), a.paintSat)
+			s.line("   %-12s [%s] %d%%", "Brightness", bar(a.paintBright, 1, 100, 30), a.paintBright)
+			s.line("   %s", swatch(yl.ColorFromHSV(float64(a.paintHue), float64(a.paintSat), 100).RGB()))
+		}
+		s.line("")
+	}
+
+	// status and help are kept at the bottom of the screen
+	for len(s.lines) < height-2 {
+		s.line("")
+	}
+	if a.statusErr {
+		s.line(" %s%s", styleRed, truncate(a.status, width-2))
+	} else {
+		s.line(" %s", truncate(a.status, width-2))
+	}
+	s.line(" %s%s", styleDim, truncate(a.help(), width-2))
+	s.flush()
+}
+
+func (a *app) help() string {
+	switch a.mode {
+	case modeFlows:
+		return "↑↓ select  enter start  esc back"
+	case modePaint:
+		if a.paintStarting {
+			return "esc back"
+		}
+		return "←→ hue  ↑↓ brightness  [ ] saturation  esc back"
+	}
+	return "↑↓ bulb  tab slider  ←→ adjust  space power  f flows  s stop flow  p paint  r refresh  q quit"
+}
@@ -0,0 +1,119 @@
+// Command yeelight-tui is a full-screen terminal application for live control of Yeelight devices.
+//
+// Bulbs are read from registry file (see registry package), devices are discovered and registered
+// when registry is empty. State of bulbs is kept in sync by device notifications
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+var (
+	configPath = flag.String("config", defaultConfigPath(), "bulbs registry file")
+	target     = flag.String("t", "all", "displayed bulbs: name or selector (room:<room>, tag:<tag>, pattern)")
+	timeout    = flag.Duration("timeout", 2*time.Second, "discovery timeout")
+	iface      = flag.String("iface", "", "network interface used by paint (music) mode")
+	logPath    = flag.String("log", "", "write library log into given file")
+)
+
+func main() {
+	flag.Parse()
+
+	log.SetOutput(ioutil.Discard)
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+
+	reg, err := registry.Load(*configPath)
+	if err != nil {
+		fatal(err)
+	}
+	defer reg.Close()
+
+	if len(reg.Entries()) == 0 {
+		fmt.Println("registry is empty, discovering devices...")
+		if _, err := reg.Refresh(*timeout); err != nil {
+			fatal(err)
+		}
+		if len(reg.Entries()) == 0 {
+			fatal(fmt.Errorf("no devices found"))
+		}
+		if err := os.MkdirAll(filepath.Dir(*configPath), 0755); err == nil {
+			_ = reg.Save()
+		}
+	}
+
+	lights, err := connect(reg, *target)
+	if err != nil {
+		fatal(err)
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		fatal(err)
+	}
+	newApp(term, lights).loop()
+	if err := term.Close(); err != nil {
+		fatal(err)
+	}
+}
+
+// connect connects to selected bulbs and enables their state cache, unreachable bulbs are kept as offline
+func connect(reg *registry.Registry, selector string) ([]*light, error) {
+	entries, err := reg.Select(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	lights := make([]*light, len(entries))
+	done := make(chan struct{})
+	for i, entry := range entries {
+		go func(i int, entry registry.Entry) {
+			defer func() { done <- struct{}{} }()
+
+			l := &light{name: entry.Name, ip: entry.Ip, pending: make(map[int]int)}
+			lights[i] = l
+
+			bulb, err := reg.Bulb(entry.ID)
+			if err != nil {
+				l.err = err
+				return
+			}
+			if err := bulb.EnableStateCache(time.Minute); err != nil {
+				l.err = err
+				return
+			}
+			l.bulb = bulb
+		}(i, entry)
+	}
+	for range entries {
+		<-done
+	}
+	return lights, nil
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "yeelight-tui: %v\n", err)
+	os.Exit(1)
+}
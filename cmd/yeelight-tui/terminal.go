@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// terminal switches controlling terminal into raw mode, raw mode is handled by stty
+// so no platform specific system calls are required
+type terminal struct {
+	saved string // stty settings restored on close
+}
+
+func openTerminal() (*terminal, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("terminal is required: %v", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	// alternate screen, hidden cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	return &terminal{saved: strings.TrimSpace(saved)}, nil
+}
+
+func (t *terminal) Close() error {
+	fmt.Print("\x1b[0m\x1b[?25h\x1b[?1049l")
+	_, err := stty(t.saved)
+	return err
+}
+
+// size returns terminal dimensions, default 80x24 is used when size is unknown
+func (t *terminal) size() (width, height int) {
+	out, err := stty("size")
+	if err == nil {
+		fields := strings.Fields(out)
+		if len(fields) == 2 {
+			rows, err1 := strconv.Atoi(fields[0])
+			cols, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+				return cols, rows
+			}
+		}
+	}
+	return 80, 24
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyEscape
+	keyTab
+	keyInterrupt
+)
+
+type key struct {
+	code keyCode
+	r    rune // set for keyRune
+}
+
+// readKeys decodes keys read from standard input, channel is closed when input is closed
+func readKeys(keys chan<- key) {
+	defer close(keys)
+
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range decodeKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// decodeKeys decodes single read from terminal, escape sequences are expected to be read at once
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch {
+		case bytes.HasPrefix(b, []byte("\x1b[")) || bytes.HasPrefix(b, []byte("\x1bO")):
+			if len(b) < 3 {
+				return append(keys, key{code: keyEscape})
+			}
+			switch b[2] {
+			case 'A':
+				keys = append(keys, key{code: keyUp})
+			case 'B':
+				keys = append(keys, key{code: keyDown})
+			case 'C':
+				keys = append(keys, key{code: keyRight})
+			case 'D':
+				keys = append(keys, key{code: keyLeft})
+			}
+			// skipping remaining part of unsupported sequences, for instance "\x1b[5~"
+			i := 2
+			for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+				i++
+			}
+			b = b[min(i+1, len(b)):]
+			continue
+		case b[0] == 0x1b:
+			keys = append(keys, key{code: keyEscape})
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, key{code: keyEnter})
+		case b[0] == '\t':
+			keys = append(keys, key{code: keyTab})
+		case b[0] == 0x03: // ctrl+c
+			keys = append(keys, key{code: keyInterrupt})
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, key{code: keyRune, r: r})
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// screen collects lines of a frame, frame is written at once for avoiding flickering
+type screen struct {
+	width, height int
+	lines         []string
+}
+
+// line adds formatted line, ANSI sequences are allowed (width is not checked for them)
+func (s *screen) line(format string, args ...interface{}) {
+	s.lines = append(s.lines, fmt.Sprintf(format, args...))
+}
+
+func (s *screen) flush() {
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+	for i, line := range s.lines {
+		if i >= s.height {
+			break
+		}
+		buf.WriteString(line)
+		buf.WriteString("\x1b[0m\x1b[K")
+		if i < s.height-1 {
+			buf.WriteString("\r\n")
+		}
+	}
+	buf.WriteString("\x1b[J")
+	os.Stdout.Write(buf.Bytes())
+}
+
+// truncate shortens plain text to given width
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// bar renders horizontal slider with given width
+func bar(value, min, max, width int) string {
+	filled := 0
+	if max > min {
+		filled = (value - min) * width / (max - min)
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > width {
+		filled = width
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// swatch renders color sample using 24-bit terminal colors
+func swatch(rgb int) string {
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm    \x1b[0m", rgb>>16&0xff, rgb>>8&0xff, rgb&0xff)
+}
+
+const (
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleReset   = "\x1b[0m"
+	styleRed     = "\x1b[31m"
+)
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+)
+
+// requireArgs checks amount of command arguments
+func requireArgs(args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %d argument(s), got %d", n, len(args))
+	}
+	return nil
+}
+
+func atoiArgs(args []string) ([]int, error) {
+	var values []int
+	for _, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number \"%s\"", arg)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func runDiscover(ctx *context, args []string) error {
+	flags := flag.NewFlagSet("discover", flag.ContinueOnError)
+	save := flags.Bool("save", false, "add found devices to config file and update their addresses")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	devices, err := yl.Discover(ctx.timeout)
+	if err != nil {
+		return err
+	}
+
+	if *save {
+		r, err := ctx.loadRegistry()
+		if err != nil {
+			return err
+		}
+		r.Update(devices)
+		if err := os.MkdirAll(filepath.Dir(ctx.configPath), 0755); err != nil {
+			return err
+		}
+		if err := r.Save(); err != nil {
+			return err
+		}
+	}
+
+	if ctx.json {
+		return printJSON(devices)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tIP\tMODEL\tPOWER")
+	for _, d := range devices {
+		power := "off"
+		if d.Power {
+			power = "on"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.ID, d.Name, d.Ip, d.Model, power)
+	}
+	return w.Flush()
+}
+
+func runPower(on bool) func(ctx *context, args []string) error {
+	return func(ctx *context, args []string) error {
+		if err := requireArgs(args, 0); err != nil {
+			return err
+		}
+		return ctx.each(func(b *yl.Bulb) error { return b.SetPower(on, ctx.duration) })
+	}
+}
+
+func runToggle(ctx *context, args []string) error {
+	if err := requireArgs(args, 0); err != nil {
+		return err
+	}
+	return ctx.each(func(b *yl.Bulb) error { return b.Toggle() })
+}
+
+func runRGB(ctx *context, args []string) error {
+	if err := requireArgs(args, 1); err != nil {
+		return err
+	}
+	color, err := yl.ParseColor(args[0])
+	if err != nil {
+		return err
+	}
+	return ctx.each(func(b *yl.Bulb) error { return b.SetColor(color, ctx.duration) })
+}
+
+func runHSV(ctx *context, args []string) error {
+	if err := requireArgs(args, 2); err != nil {
+		return err
+	}
+	values, err := atoiArgs(args)
+	if err != nil {
+		return err
+	}
+	return ctx.each(func(b *yl.Bulb) error { return b.SetHSV(values[0], values[1], ctx.duration) })
+}
+
+func runCT(ctx *context, args []string) error {
+	if err := requireArgs(args, 1); err != nil {
+		return err
+	}
+	kelvin, err := strconv.Atoi(strings.TrimSuffix(strings.ToUpper(args[0]), "K"))
+	if err != nil {
+		return fmt.Errorf("invalid temperature \"%s\"", args[0])
+	}
+	return ctx.each(func(b *yl.Bulb) error { return b.SetTemperature(kelvin, ctx.duration) })
+}
+
+func runBright(ctx *context, args []string) error {
+	if err := requireArgs(args, 1); err != nil {
+		return err
+	}
+	values, err := atoiArgs(args)
+	if err != nil {
+		return err
+	}
+	return ctx.each(func(b *yl.Bulb) error { return b.SetBrightness(values[0], ctx.duration) })
+}
+
+// loadFlow reads flow from file, or preset when file doesn't exist
+func loadFlow(name string) (flows.Flow, error) {
+	if _, err := os.Stat(name); err == nil {
+		return flows.Load(name)
+	}
+	flow, err := flows.Preset(name)
+	if err != nil {
+		return flows.Flow{}, fmt.Errorf("\"%s\" is neither flow file nor preset (%s)", name, strings.Join(flows.Names(), ", "))
+	}
+	return flow, nil
+}
+
+func runFlow(ctx *context, args []string) error {
+	if err := requireArgs(args, 1); err != nil {
+		return err
+	}
+	if args[0] == "stop" {
+		return ctx.each(func(b *yl.Bulb) error { return b.StopColorFlow() })
+	}
+
+	flow, err := loadFlow(args[0])
+	if err != nil {
+		return err
+	}
+	return ctx.each(func(b *yl.Bulb) error { return flow.Start(b) })
+}
+
+func runScene(ctx *context, args []string) error {
+	if len(args) < 2 {
+		return errors.New("scene type and values are required")
+	}
+
+	var scene yl.Scene
+	kind, values := args[0], args[1:]
+	switch kind {
+	case "color":
+		if err := requireArgs(values, 2); err != nil {
+			return err
+		}
+		color, err := yl.ParseColor(values[0])
+		if err != nil {
+			return err
+		}
+		brightness, err := strconv.Atoi(values[1])
+		if err != nil {
+			return fmt.Errorf("invalid brightness \"%s\"", values[1])
+		}
+		if color.IsTemperature() {
+			scene = yl.NewTemperatureScene(color.Kelvin(), brightness)
+		} else {
+			scene = yl.NewColorScene(color.RGB(), brightness)
+		}
+	case "hsv":
+		numbers, err := atoiArgs(values)
+		if err != nil {
+			return err
+		}
+		if err := requireArgs(values, 3); err != nil {
+			return err
+		}
+		scene = yl.NewHSVScene(numbers[0], numbers[1], numbers[2])
+	case "ct":
+		numbers, err := atoiArgs(values)
+		if err != nil {
+			return err
+		}
+		if err := requireArgs(values, 2); err != nil {
+			return err
+		}
+		scene = yl.NewTemperatureScene(numbers[0], numbers[1])
+	case "flow":
+		if err := requireArgs(values, 1); err != nil {
+			return err
+		}
+		flow, err := loadFlow(values[0])
+		if err != nil {
+			return err
+		}
+		scene = yl.NewColorFlowScene(flow.Count, flow.Action, flow.Expression)
+	case "delayoff":
+		numbers, err := atoiArgs(values)
+		if err != nil {
+			return err
+		}
+		if err := requireArgs(values, 2); err != nil {
+			return err
+		}
+		scene = yl.NewAutoDelayOffScene(numbers[0], numbers[1])
+	default:
+		return fmt.Errorf("unknown scene \"%s\", expected color, hsv, ct, flow or delayoff", kind)
+	}
+
+	return ctx.each(func(b *yl.Bulb) error { return b.SetScene(scene) })
+}
+
+func runTimer(ctx *context, args []string) error {
+	if err := requireArgs(args, 1); err != nil {
+		return err
+	}
+	if args[0] == "off" {
+		return ctx.each(func(b *yl.Bulb) error { return b.CronDel(yl.CRON_TYPE_POWER_OFF) })
+	}
+
+	minutes, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid minutes \"%s\"", args[0])
+	}
+	return ctx.each(func(b *yl.Bulb) error { return b.CronAdd(yl.CRON_TYPE_POWER_OFF, minutes) })
+}
+
+func runName(ctx *context, args []string) error {
+	if err := requireArgs(args, 1); err != nil {
+		return err
+	}
+	group, err := ctx.bulbs()
+	if err != nil {
+		return err
+	}
+	if len(group.Bulbs()) != 1 {
+		return fmt.Errorf("name can be set on a single bulb only, %d selected", len(group.Bulbs()))
+	}
+	return group.Bulbs()[0].SetName(args[0])
+}
+
+var defaultProps = []yl.Property{
+	yl.PROP_NAME, yl.PROP_POWER, yl.PROP_BRIGHT, yl.PROP_COLOR_MODE, yl.PROP_RGB, yl.PROP_CT,
+	yl.PROP_HUE, yl.PROP_SAT, yl.PROP_FLOWING, yl.PROP_DELAYOFF,
+}
+
+func runProps(ctx *context, args []string) error {
+	props := defaultProps
+	if len(args) > 0 {
+		props = nil
+		for _, arg := range args {
+			props = append(props, yl.Property(arg))
+		}
+	}
+
+	group, err := ctx.bulbs()
+	if err != nil {
+		return err
+	}
+
+	var (
+		results    = make(map[string]map[string]interface{})
+		resultsMtx sync.Mutex
+	)
+	err = group.Each(func(b *yl.Bulb) error {
+		values, err := b.Prop(props...)
+		if err != nil {
+			return err
+		}
+		resultsMtx.Lock()
+		results[b.Address()] = values
+		resultsMtx.Unlock()
+		return nil
+	})
+
+	if ctx.json {
+		if printErr := printJSON(results); printErr != nil {
+			return printErr
+		}
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "BULB")
+	for _, prop := range props {
+		fmt.Fprintf(w, "\t%s", strings.ToUpper(string(prop)))
+	}
+	fmt.Fprintln(w)
+	for _, b := range group.Bulbs() {
+		values, ok := results[b.Address()]
+		if !ok {
+			continue
+		}
+		fmt.Fprint(w, b.Address())
+		for _, prop := range props {
+			fmt.Fprintf(w, "\t%v", values[string(prop)])
+		}
+		fmt.Fprintln(w)
+	}
+	if flushErr := w.Flush(); flushErr != nil {
+		return flushErr
+	}
+	return err
+}
+
+func runWatch(ctx *context, args []string) error {
+	if err := requireArgs(args, 0); err != nil {
+		return err
+	}
+	group, err := ctx.bulbs()
+	if err != nil {
+		return err
+	}
+
+	type event struct {
+		Time   time.Time         `json:"time"`
+		Bulb   string            `json:"bulb"`
+		Params map[string]string `json:"params"`
+	}
+
+	var (
+		jobs   sync.WaitGroup
+		outMtx sync.Mutex
+	)
+	for _, bulb := range group.Bulbs() {
+		jobs.Add(1)
+		go func(bulb *yl.Bulb) {
+			defer jobs.Done()
+
+			subscription := bulb.Subscribe()
+			for notification := range subscription.C {
+				outMtx.Lock()
+				if ctx.json {
+					_ = json.NewEncoder(os.Stdout).Encode(event{time.Now(), bulb.Ip, notification.Params})
+				} else {
+					fmt.Printf("%s %s %s\n", time.Now().Format("15:04:05.000"), bulb.Ip, formatParams(notification.Params))
+				}
+				outMtx.Unlock()
+			}
+			fmt.Fprintf(os.Stderr, "%s: connection lost\n", bulb.Ip)
+		}(bulb)
+	}
+	jobs.Wait()
+	return nil
+}
+
+func formatParams(params map[string]string) string {
+	var pairs []string
+	for k, v := range params {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+func runMusic(ctx *context, args []string) error {
+	if err := requireArgs(args, 0); err != nil {
+		return err
+	}
+	group, err := ctx.bulbs()
+	if err != nil {
+		return err
+	}
+
+	var (
+		musics    []*yl.Music
+		musicsMtx sync.Mutex
+	)
+	defer func() {
+		for _, music := range musics {
+			_ = music.Stop()
+		}
+	}()
+	err = group.Each(func(b *yl.Bulb) error {
+		if err := b.PowerOn(0); err != nil {
+			return err
+		}
+		music, err := b.StartMusic(ctx.iface)
+		if err != nil {
+			return err
+		}
+		musicsMtx.Lock()
+		musics = append(musics, music)
+		musicsMtx.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		color, err := yl.ParseColor(fields[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "line %d: %v\n", line, err)
+			continue
+		}
+		brightness := -1
+		if len(fields) > 1 {
+			brightness, err = strconv.Atoi(fields[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "line %d: invalid brightness \"%s\"\n", line, fields[1])
+				continue
+			}
+		}
+
+		for _, music := range musics {
+			music.SetColor(color, ctx.duration)
+			if brightness != -1 {
+				music.SetBrightness(brightness, ctx.duration)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func runPresets(ctx *context, args []string) error {
+	for _, name := range flows.Names() {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,205 @@
+// Command yeelight controls Yeelight devices from the shell.
+//
+// Usage:
+//   yeelight [flags] <command> [arguments]
+//
+// Bulbs are selected with -t flag: IP address, name or selector of bulbs registered in config file
+// ("kitchen", "office/*", "room:kitchen", "tag:ceiling", "all"), see "yeelight help".
+// Target is required by commands changing bulbs state, read-only commands target all bulbs by default
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+type command struct {
+	usage       string
+	description string
+	run         func(ctx *context, args []string) error
+}
+
+var commands = map[string]command{
+	"discover": {"discover [-save]", "search for devices in local network", runDiscover},
+	"on":       {"on", "turn bulbs on", runPower(true)},
+	"off":      {"off", "turn bulbs off", runPower(false)},
+	"toggle":   {"toggle", "toggle bulbs power", runToggle},
+	"rgb":      {"rgb <color>", "set color: #ff8800, orange or 3000K", runRGB},
+	"hsv":      {"hsv <hue> <saturation>", "set color in HSV form", runHSV},
+	"ct":       {"ct <kelvin>", "set color temperature (1700-6500)", runCT},
+	"bright":   {"bright <1-100>", "set brightness", runBright},
+	"flow":     {"flow <preset|file>|stop", "start color flow from preset or flow file, or stop running flow", runFlow},
+	"scene":    {"scene <color|hsv|ct|flow|delayoff> <values...>", "set scene, works also on turned off bulbs", runScene},
+	"timer":    {"timer <minutes>|off", "turn bulbs off after given amount of minutes", runTimer},
+	"name":     {"name <name>", "set device name (single bulb only)", runName},
+	"props":    {"props [property...]", "show device properties", runProps},
+	"watch":    {"watch", "stream property change notifications", runWatch},
+	"music":    {"music", "stream colors from stdin (\"<color> [brightness]\" per line) in music mode", runMusic},
+	"presets":  {"presets", "list available flow presets", runPresets},
+}
+
+// readOnlyCommands don't change bulbs state, so they target all bulbs when -t flag is not given
+var readOnlyCommands = map[string]bool{
+	"props": true,
+	"watch": true,
+}
+
+// context holds global flags and lazily resolved bulbs
+type context struct {
+	target     string
+	configPath string
+	duration   time.Duration
+	json       bool
+	timeout    time.Duration
+	iface      string
+
+	registry *registry.Registry
+	group    *yl.Group
+}
+
+func main() {
+	ctx := &context{}
+
+	flag.StringVar(&ctx.target, "t", "", "target bulbs: IP address, name or selector (room:<room>, tag:<tag>, pattern, all)")
+	flag.StringVar(&ctx.configPath, "config", defaultConfigPath(), "bulbs registry file")
+	flag.DurationVar(&ctx.duration, "d", 0, "transition duration, for instance 500ms (0 for sudden change)")
+	flag.BoolVar(&ctx.json, "json", false, "print output in JSON format")
+	flag.DurationVar(&ctx.timeout, "timeout", 2*time.Second, "discovery timeout")
+	flag.StringVar(&ctx.iface, "iface", "", "network interface used by music mode")
+	verbose := flag.Bool("v", false, "print sent commands")
+	flag.Usage = usage
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	if flag.NArg() == 0 || flag.Arg(0) == "help" {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command \"%s\"\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	if ctx.target == "" && readOnlyCommands[flag.Arg(0)] {
+		ctx.target = "all"
+	}
+
+	err := cmd.run(ctx, flag.Args()[1:])
+	if ctx.registry != nil {
+		ctx.registry.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: yeelight [flags] <command> [arguments]\n\nCommands:\n")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-50s %s\n", commands[name].usage, commands[name].description)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
+
+// loadRegistry reads bulbs registry from config file
+func (ctx *context) loadRegistry() (*registry.Registry, error) {
+	if ctx.registry != nil {
+		return ctx.registry, nil
+	}
+
+	r, err := registry.Load(ctx.configPath)
+	if err != nil {
+		return nil, err
+	}
+	ctx.registry = r
+	return r, nil
+}
+
+// bulbs returns group of connected target bulbs
+func (ctx *context) bulbs() (*yl.Group, error) {
+	if ctx.group != nil {
+		return ctx.group, nil
+	}
+
+	var targets []string
+	for _, target := range strings.Split(ctx.target, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no target bulbs given, use -t flag (for instance -t all)")
+	}
+
+	group := yl.NewGroup()
+	for _, target := range targets {
+		// IP addresses are used directly, without registry
+		if net.ParseIP(target) != nil {
+			bulb := yl.NewBulb(target)
+			if err := bulb.Connect(); err != nil {
+				return nil, fmt.Errorf("%s: %v", target, err)
+			}
+			group.Add(bulb)
+			continue
+		}
+
+		r, err := ctx.loadRegistry()
+		if err != nil {
+			return nil, err
+		}
+		selected, err := r.Group(target)
+		if _, partial := err.(yl.GroupError); partial && len(selected.Bulbs()) > 0 {
+			// unreachable bulbs are reported, reachable ones are still controlled
+			fmt.Fprintf(os.Stderr, "%s: %v\n", target, err)
+		} else if err != nil {
+			return nil, err
+		}
+		group.Add(selected.Bulbs()...)
+	}
+
+	ctx.group = group
+	return group, nil
+}
+
+// each runs given function for every target bulb
+func (ctx *context) each(fn func(bulb *yl.Bulb) error) error {
+	group, err := ctx.bulbs()
+	if err != nil {
+		return err
+	}
+	return group.Each(fn)
+}
@@ -0,0 +1,157 @@
+// Command yeelightd is a daemon exposing bulbs over HTTP REST API (see gateway package),
+// so devices can be controlled by services not written in Go.
+//
+// Bulbs are read from registry file, discovery keeps their addresses up to date:
+//   yeelightd -listen :8080 -config bulbs.json -refresh 5m
+//   curl -X PUT -d '{"color": "orange", "brightness": 50}' localhost:8080/bulbs/office/desk/color
+//   curl -N 'localhost:8080/events?bulb=office/*&prop=power,bright'
+//
+// Hue bridge emulation makes bulbs available to apps speaking Hue API (see hue package):
+//   yeelightd -hue :80 -hue-address 192.168.0.10:80
+//
+// WLED JSON API of any bulb or group is served under /wled/{target}/json/..., targets can be served on
+// dedicated addresses for WLED clients which don't support paths (see wled package):
+//   yeelightd -wled office/desk=:8081,room:kitchen=:8082
+//   curl -d '{"on": true, "bri": 128, "seg": [{"col": [[255, 120, 0]]}]}' localhost:8081/json/state
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gethiox/yeelight-go/gateway"
+	"github.com/gethiox/yeelight-go/hue"
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/wled"
+)
+
+func main() {
+	var (
+		listen      = flag.String("listen", ":8080", "HTTP listen address")
+		configPath  = flag.String("config", defaultConfigPath(), "bulbs registry file")
+		refresh     = flag.Duration("refresh", 5*time.Minute, "discovery interval, 0 disables discovery")
+		timeout     = flag.Duration("timeout", 2*time.Second, "discovery timeout")
+		concurrency = flag.Int("concurrency", 4, "maximum number of bulbs controlled in parallel by group requests")
+		hueListen   = flag.String("hue", "", "Hue bridge emulation listen address, empty disables emulation")
+		hueAddress  = flag.String("hue-address", "", "address of Hue bridge advertised to apps, listen address by default")
+		hueIface    = flag.String("hue-iface", "", "network interface used for Hue bridge discovery")
+		wledTargets = flag.String("wled", "", "comma separated target=address pairs of WLED devices served on dedicated addresses")
+		verbose     = flag.Bool("v", false, "log commands sent to bulbs")
+	)
+	flag.Parse()
+
+	// library logs every command, daemon logs are kept separately
+	logger := log.New(os.Stderr, "yeelightd: ", log.LstdFlags)
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	reg, err := registry.Load(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer reg.Close()
+
+	server := gateway.New(reg)
+	server.SetConcurrency(*concurrency)
+	server.Monitor()
+
+	if *refresh > 0 {
+		discover(server, *configPath, *timeout, logger)
+		go func() {
+			for range time.Tick(*refresh) {
+				discover(server, *configPath, *timeout, logger)
+			}
+		}()
+	}
+
+	wledServer := wled.NewServer(reg)
+	server.Handle("/wled/", http.StripPrefix("/wled", wledServer))
+	if *wledTargets != "" {
+		serveWLED(wledServer, *wledTargets, logger)
+	}
+
+	if *hueListen != "" {
+		address := *hueAddress
+		if address == "" {
+			address = *hueListen
+		}
+		serveHue(reg, *hueListen, address, *hueIface, logger)
+	}
+
+	logger.Printf("listening on %s, %d bulb(s) registered", *listen, len(reg.Entries()))
+	logger.Fatal(http.ListenAndServe(*listen, server))
+}
+
+// discover updates registry with found devices and saves it when anything has changed
+func discover(server *gateway.Server, configPath string, timeout time.Duration, logger *log.Logger) {
+	changed, err := server.Discover(timeout)
+	if err != nil {
+		logger.Printf("discovery failed: %v", err)
+		return
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	for _, entry := range changed {
+		logger.Printf("bulb \"%s\" found at %s", entry.Name, entry.Ip)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		logger.Printf("saving registry failed: %v", err)
+		return
+	}
+	if err := server.Registry().Save(); err != nil {
+		logger.Printf("saving registry failed: %v", err)
+	}
+}
+
+// serveHue starts Hue bridge emulation with discovery responder
+func serveHue(reg *registry.Registry, listen, address, iface string, logger *log.Logger) {
+	bridge, err := hue.New(reg, address)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	conn, err := hue.ListenSSDP(iface)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	go func() {
+		logger.Fatal(bridge.ServeSSDP(conn))
+	}()
+	go func() {
+		logger.Fatal(http.ListenAndServe(listen, bridge))
+	}()
+	logger.Printf("Hue bridge %s listening on %s", bridge.ID(), listen)
+}
+
+// serveWLED serves WLED devices on dedicated addresses, targets are given as "target=address" pairs
+func serveWLED(server *wled.Server, targets string, logger *log.Logger) {
+	for _, pair := range strings.Split(targets, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			logger.Fatalf("invalid WLED target \"%s\", expected target=address", pair)
+		}
+		target, address := pair[:i], pair[i+1:]
+
+		go func() {
+			logger.Fatal(http.ListenAndServe(address, server.Light(target)))
+		}()
+		logger.Printf("WLED device \"%s\" listening on %s", target, address)
+	}
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
@@ -0,0 +1,292 @@
+package yeelight
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type colorModel int
+
+const (
+	colorModelRGB colorModel = iota
+	colorModelHSV
+	colorModelTemperature
+)
+
+// Color holds color together with the model it was created with,
+// thanks to that SetColor can choose between set_rgb, set_hsv and set_ct_abx commands.
+// Conversions between models are approximated, device rendering differs anyway
+type Color struct {
+	model colorModel
+
+	rgb                    int     // 0xRRGGBB for RGB model
+	hue, saturation, value float64 // for HSV model, hue range: 0-360, saturation and value range: 0-100
+	kelvin                 int     // for temperature model
+}
+
+// ColorFromRGB creates color from 0xRRGGBB value
+func ColorFromRGB(rgb int) Color {
+	return Color{model: colorModelRGB, rgb: rgb}
+}
+
+// ColorFromHSV creates color from hue (0-360), saturation (0-100) and value (0-100), hue out of range
+// is wrapped (-30 is 330). Value is not supported by set_hsv command, use Brightness instead
+func ColorFromHSV(hue, saturation, value float64) Color {
+	hue = math.Mod(hue, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	return Color{model: colorModelHSV, hue: hue, saturation: saturation, value: value}
+}
+
+// ColorFromHSL creates color from hue (0-360), saturation (0-100) and lightness (0-100)
+func ColorFromHSL(hue, saturation, lightness float64) Color {
+	s, l := saturation/100, lightness/100
+	v := l + s*math.Min(l, 1-l)
+	var sv float64
+	if v != 0 {
+		sv = 2 * (1 - l/v)
+	}
+	return ColorFromHSV(hue, sv*100, v*100)
+}
+
+// ColorFromXY creates color from CIE 1931 xy chromaticity coordinates with full brightness
+func ColorFromXY(x, y float64) Color {
+	if y == 0 {
+		return ColorFromRGB(0)
+	}
+
+	// xyY to XYZ with Y = 1, then XYZ to linear sRGB (D65)
+	X, Y, Z := x/y, 1.0, (1-x-y)/y
+	r := 3.2404542*X - 1.5371385*Y - 0.4985314*Z
+	g := -0.9692660*X + 1.8760108*Y + 0.0415560*Z
+	b := 0.0556434*X - 0.2040259*Y + 1.0572252*Z
+
+	// color outside of sRGB gamut is clipped, then normalized to full brightness
+	r, g, b = math.Max(r, 0), math.Max(g, 0), math.Max(b, 0)
+	if max := math.Max(r, math.Max(g, b)); max > 0 {
+		r, g, b = r/max, g/max, b/max
+	}
+	return ColorFromRGB(toChannel(gammaCompress(r))<<16 | toChannel(gammaCompress(g))<<8 | toChannel(gammaCompress(b)))
+}
+
+// ColorFromKelvin creates white color of given temperature, device supports range 1700-6500
+func ColorFromKelvin(kelvin int) Color {
+	return Color{model: colorModelTemperature, kelvin: kelvin}
+}
+
+// ParseColor parses color in one of forms: "#ff8800", "#f80", "0xff8800", "3000K" or CSS color name ("orange")
+func ParseColor(s string) (Color, error) {
+	lower := strings.ToLower(strings.TrimSpace(s))
+
+	if rgb, ok := cssColors[lower]; ok {
+		return ColorFromRGB(rgb), nil
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "k"):
+		kelvin, err := strconv.Atoi(lower[:len(lower)-1])
+		if err != nil || kelvin <= 0 {
+			return Color{}, fmt.Errorf("invalid temperature \"%s\"", s)
+		}
+		return ColorFromKelvin(kelvin), nil
+	case strings.HasPrefix(lower, "#"), strings.HasPrefix(lower, "0x"):
+		hex := strings.TrimPrefix(strings.TrimPrefix(lower, "#"), "0x")
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return Color{}, fmt.Errorf("invalid color \"%s\"", s)
+		}
+		return ColorFromRGB(int(rgb)), nil
+	}
+	return Color{}, fmt.Errorf("invalid color \"%s\", expected #rrggbb, temperature like 3000K or color name", s)
+}
+
+// IsTemperature returns true when color was created from temperature, see Kelvin
+func (c Color) IsTemperature() bool {
+	return c.model == colorModelTemperature
+}
+
+// RGB returns color in 0xRRGGBB form
+func (c Color) RGB() int {
+	switch c.model {
+	case colorModelHSV:
+		return hsvToRGB(c.hue, c.saturation, c.value)
+	case colorModelTemperature:
+		return KelvinToRGB(c.kelvin)
+	}
+	return c.rgb
+}
+
+// HSV returns hue (0-360), saturation (0-100) and value (0-100)
+func (c Color) HSV() (hue, saturation, value float64) {
+	if c.model == colorModelHSV {
+		return c.hue, c.saturation, c.value
+	}
+
+	r, g, b := channels(c.RGB())
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+
+	switch {
+	case delta == 0:
+		hue = 0
+	case max == r:
+		hue = 60 * math.Mod((g-b)/delta, 6)
+	case max == g:
+		hue = 60 * ((b-r)/delta + 2)
+	default:
+		hue = 60 * ((r-g)/delta + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+	if max > 0 {
+		saturation = delta / max * 100
+	}
+	return hue, saturation, max * 100
+}
+
+// deviceHSV returns hue (0-359) and saturation (0-100) rounded as accepted by set_hsv command,
+// hue rounded up to 360 is wrapped to 0
+func (c Color) deviceHSV() (hue, saturation int) {
+	h, s, _ := c.HSV()
+	return int(math.Round(h)) % 360, int(math.Round(s))
+}
+
+// HSL returns hue (0-360), saturation (0-100) and lightness (0-100)
+func (c Color) HSL() (hue, saturation, lightness float64) {
+	hue, sv, v := c.HSV()
+	sv, v = sv/100, v/100
+
+	l := v * (1 - sv/2)
+	var s float64
+	if l != 0 && l != 1 {
+		s = (v - l) / math.Min(l, 1-l)
+	}
+	return hue, s * 100, l * 100
+}
+
+// XY returns CIE 1931 xy chromaticity coordinates, black is reported as D65 white point
+func (c Color) XY() (x, y float64) {
+	r, g, b := channels(c.RGB())
+	r, g, b = gammaExpand(r), gammaExpand(g), gammaExpand(b)
+
+	X := 0.4124564*r + 0.3575761*g + 0.1804375*b
+	Y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	Z := 0.0193339*r + 0.1191920*g + 0.9503041*b
+	sum := X + Y + Z
+	if sum == 0 {
+		return 0.3127, 0.3290
+	}
+	return X / sum, Y / sum
+}
+
+// Kelvin returns color temperature, for colors not created from temperature it's approximated
+// correlated color temperature (McCamy's formula), meaningful for colors close to white only
+func (c Color) Kelvin() int {
+	if c.model == colorModelTemperature {
+		return c.kelvin
+	}
+
+	x, y := c.XY()
+	n := (x - 0.3320) / (0.1858 - y)
+	return int(math.Round(449*n*n*n + 3525*n*n + 6823.3*n + 5520.33))
+}
+
+// Hex returns color in "#rrggbb" form
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%06x", c.RGB())
+}
+
+func (c Color) String() string {
+	switch c.model {
+	case colorModelHSV:
+		return fmt.Sprintf("hsv(%.0f, %.0f%%, %.0f%%)", c.hue, c.saturation, c.value)
+	case colorModelTemperature:
+		return fmt.Sprintf("%dK", c.kelvin)
+	}
+	return c.Hex()
+}
+
+func hsvToRGB(hue, saturation, value float64) int {
+	s, v := saturation/100, value/100
+	chroma := v * s
+	h := math.Mod(hue, 360) / 60
+	x := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case h < 1:
+		r, g, b = chroma, x, 0
+	case h < 2:
+		r, g, b = x, chroma, 0
+	case h < 3:
+		r, g, b = 0, chroma, x
+	case h < 4:
+		r, g, b = 0, x, chroma
+	case h < 5:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	m := v - chroma
+	return toChannel(r+m)<<16 | toChannel(g+m)<<8 | toChannel(b+m)
+}
+
+// channels splits 0xRRGGBB into 0-1 ranged channels
+func channels(rgb int) (r, g, b float64) {
+	return float64(rgb>>16&0xff) / 255, float64(rgb>>8&0xff) / 255, float64(rgb&0xff) / 255
+}
+
+// toChannel converts 0-1 ranged channel into 0-255 value
+func toChannel(v float64) int {
+	return int(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// gammaExpand converts sRGB channel into linear value
+func gammaExpand(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// gammaCompress converts linear value into sRGB channel
+func gammaCompress(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// KelvinToRGB approximates color of black body radiation in given temperature, returned as 0xRRGGBB
+func KelvinToRGB(kelvin int) int {
+	temp := float64(kelvin) / 100
+	clamp := func(v float64) int {
+		return int(math.Round(math.Max(0, math.Min(255, v))))
+	}
+
+	var r, g, b float64
+	if temp <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(temp) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(temp-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(temp-60, -0.0755148492)
+	}
+	switch {
+	case temp >= 66:
+		b = 255
+	case temp <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(temp-10) - 305.0447927307
+	}
+	return clamp(r)<<16 | clamp(g)<<8 | clamp(b)
+}
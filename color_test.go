@@ -0,0 +1,157 @@
+package yeelight
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestColorFromHSV(t *testing.T) {
+	tests := []struct {
+		name string
+		hue  float64
+		want float64
+	}{
+		{"in range", 120, 120},
+		{"full circle", 360, 0},
+		{"above full circle", 390, 30},
+		{"negative", -30, 330},
+		{"negative full circle", -360, 0},
+		{"negative above full circle", -750, 330},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hue, _, _ := ColorFromHSV(test.hue, 100, 100).HSV()
+			if hue != test.want {
+				t.Errorf("expected hue %v, got %v", test.want, hue)
+			}
+		})
+	}
+}
+
+func TestColorRoundTrip(t *testing.T) {
+	for _, rgb := range []int{0x000000, 0xffffff, 0xff0000, 0x00ff00, 0x0000ff, 0xff8800, 0x123456, 0x808080} {
+		c := ColorFromRGB(rgb)
+
+		if got := ColorFromHSV(c.HSV()).RGB(); got != rgb {
+			t.Errorf("%06x: HSV round trip returned %06x", rgb, got)
+		}
+		if got := ColorFromHSL(c.HSL()).RGB(); got != rgb {
+			t.Errorf("%06x: HSL round trip returned %06x", rgb, got)
+		}
+	}
+
+	// xy loses brightness, only colors of full brightness survive round trip
+	for _, rgb := range []int{0xffffff, 0xff0000, 0x00ff00, 0x0000ff, 0xff8800} {
+		if got := ColorFromXY(ColorFromRGB(rgb).XY()).RGB(); got != rgb {
+			t.Errorf("%06x: xy round trip returned %06x", rgb, got)
+		}
+	}
+}
+
+func TestColorHSVEdgeCases(t *testing.T) {
+	tests := []struct {
+		name  string
+		color Color
+		rgb   int
+	}{
+		{"negative hue", ColorFromHSV(-120, 100, 100), 0x0000ff},
+		{"full circle hue", ColorFromHSV(360, 100, 100), 0xff0000},
+		{"zero saturation", ColorFromHSV(200, 0, 50), 0x808080},
+		{"zero value", ColorFromHSV(200, 100, 0), 0x000000},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.color.RGB(); got != test.rgb {
+				t.Errorf("expected %06x, got %06x", test.rgb, got)
+			}
+		})
+	}
+
+	// grays have no hue, saturation is zero
+	if hue, saturation, value := ColorFromRGB(0x808080).HSV(); hue != 0 || saturation != 0 || math.Abs(value-50.2) > 0.1 {
+		t.Errorf("unexpected gray HSV: %v, %v, %v", hue, saturation, value)
+	}
+}
+
+func TestColorTemperature(t *testing.T) {
+	for _, kelvin := range []int{2700, 4000, 6500} {
+		c := ColorFromKelvin(kelvin)
+		if !c.IsTemperature() || c.Kelvin() != kelvin {
+			t.Errorf("expected temperature %dK, got %v", kelvin, c)
+		}
+
+		// approximation through RGB is expected to stay close to the source temperature
+		approximated := ColorFromRGB(c.RGB()).Kelvin()
+		if diff := approximated - kelvin; diff < -kelvin/10 || diff > kelvin/10 {
+			t.Errorf("%dK approximated as %dK", kelvin, approximated)
+		}
+
+		// converted to HSV and back the color stays the same
+		if got := ColorFromHSV(c.HSV()).RGB(); got != c.RGB() {
+			t.Errorf("%dK: HSV round trip returned %06x, expected %06x", kelvin, got, c.RGB())
+		}
+	}
+	if ColorFromRGB(0xffffff).IsTemperature() {
+		t.Error("RGB color reported as temperature")
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Color
+		valid bool
+	}{
+		{"#ff8800", ColorFromRGB(0xff8800), true},
+		{"#F80", ColorFromRGB(0xff8800), true},
+		{"0xff8800", ColorFromRGB(0xff8800), true},
+		{" orange ", ColorFromRGB(0xffa500), true},
+		{"3000K", ColorFromKelvin(3000), true},
+		{"3000k", ColorFromKelvin(3000), true},
+		{"#ff88", Color{}, false},
+		{"#gg8800", Color{}, false},
+		{"-100K", Color{}, false},
+		{"K", Color{}, false},
+		{"not a color", Color{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			c, err := ParseColor(test.input)
+			if (err == nil) != test.valid {
+				t.Fatalf("expected valid %v, got %v", test.valid, err)
+			}
+			if c != test.want {
+				t.Errorf("expected %v, got %v", test.want, c)
+			}
+		})
+	}
+}
+
+func TestSetColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		color Color
+		want  partialCommand
+	}{
+		{"rgb", ColorFromRGB(0xff8800), partialCommand{"set_rgb", params{0xff8800, "sudden", 0}}},
+		{"hsv", ColorFromHSV(120.4, 50.6, 100), partialCommand{"set_hsv", params{120, 51, "sudden", 0}}},
+		{"hsv negative hue", ColorFromHSV(-30, 100, 100), partialCommand{"set_hsv", params{330, 100, "sudden", 0}}},
+		{"hsv hue rounded to full circle", ColorFromHSV(359.6, 100, 100), partialCommand{"set_hsv", params{0, 100, "sudden", 0}}},
+		{"temperature", ColorFromKelvin(2700), partialCommand{"set_ct_abx", params{2700, "sudden", 0}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &recorder{}
+			bulb := NewBulb("127.0.0.1")
+			bulb.commonCommands.commander = r
+
+			if err := bulb.SetColor(test.color, 0); err != nil {
+				t.Fatal(err)
+			}
+			if len(r.commands) != 1 || !reflect.DeepEqual(r.commands[0], test.want) {
+				t.Errorf("expected %v, got %v", test.want, r.commands)
+			}
+		})
+	}
+}
@@ -2,12 +2,17 @@ package yeelight
 
 import (
 	"errors"
+	"time"
 )
 
 // Common commands defines shared commands for standard mode and background mode
 type commonCommands struct {
 	commander commander
 	prefix    string // used as a prefix in command names, empty by default. can support background commands by "bg_".
+
+	calibration *Calibration // optional corrections applied to sent values, nil when not calibrated
+
+	brightnessIgnore bool // device accepts CF_BRIGHTNESS_IGNORE in color flow steps
 }
 
 // Temperature sets device temperature, range 1700-6500
@@ -22,7 +27,7 @@ func (c *commonCommands) Temperature(temp, duration int) error {
 	}
 
 	return c.commander.executeCommand(
-		partialCommand{c.prefix + "set_ct_abx", params{temp, effect, duration}},
+		partialCommand{c.prefix + "set_ct_abx", params{c.calibration.Temperature(temp), effect, duration}},
 	)
 }
 
@@ -42,11 +47,12 @@ func (c *commonCommands) RGB(rgb, duration int) error {
 	}
 
 	return c.commander.executeCommand(
-		partialCommand{c.prefix + "set_rgb", params{rgb, effect, duration}},
+		partialCommand{c.prefix + "set_rgb", params{c.calibration.RGB(rgb), effect, duration}},
 	)
 }
 
 // HSV sets device color in HSV form. hue range: 0-359, saturation range: 0-100
+// Calibration is not applied to HSV values, use RGB or SetColor with RGB color instead
 func (c *commonCommands) HSV(hue, saturation, duration int) error {
 	if hue < 0 || hue > 359 {
 		return errors.New("hue expected range: 0-359")
@@ -66,6 +72,62 @@ func (c *commonCommands) HSV(hue, saturation, duration int) error {
 	)
 }
 
+// SetTemperature behaves like Temperature, transition duration is given as time.Duration.
+// Sudden or Smooth(d) may be used for explicit effect, durations are rounded to the nearest millisecond
+// and non-zero durations shorter than MinSmoothDuration are rejected
+func (c *commonCommands) SetTemperature(temp int, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.Temperature(temp, duration)
+}
+
+// SetRGB behaves like RGB, transition duration is given as time.Duration, see SetTemperature
+func (c *commonCommands) SetRGB(rgb int, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.RGB(rgb, duration)
+}
+
+// SetHSV behaves like HSV, transition duration is given as time.Duration, see SetTemperature
+func (c *commonCommands) SetHSV(hue, saturation int, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.HSV(hue, saturation, duration)
+}
+
+// SetBrightness behaves like Brightness, transition duration is given as time.Duration, see SetTemperature
+func (c *commonCommands) SetBrightness(brightness int, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.Brightness(brightness, duration)
+}
+
+// SetColor sets device color using command matching the way Color was created:
+// set_ct_abx for temperature, set_hsv for HSV (value is ignored) and set_rgb for every other color
+func (c *commonCommands) SetColor(color Color, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+
+	switch color.model {
+	case colorModelTemperature:
+		return c.Temperature(color.kelvin, duration)
+	case colorModelHSV:
+		hue, saturation := color.deviceHSV()
+		return c.HSV(hue, saturation, duration)
+	}
+	return c.RGB(color.RGB(), duration)
+}
+
 // Brightness sets device brightness in range 1-100
 func (c *commonCommands) Brightness(brightness, duration int) error {
 	if brightness < 1 || brightness > 100 {
@@ -78,13 +140,17 @@ func (c *commonCommands) Brightness(brightness, duration int) error {
 	}
 
 	return c.commander.executeCommand(
-		partialCommand{c.prefix + "set_bright", params{brightness, effect, duration}},
+		partialCommand{c.prefix + "set_bright", params{c.calibration.Brightness(brightness), effect, duration}},
 	)
 }
 
 // StartColorFlow sets device in color flow mode, FlowExpression determines wanted animation..
 // It can be changing brightness, color or temperature.
+// Expressions using CF_BRIGHTNESS_IGNORE are rejected unless device supports it, see Bulb.SetBrightnessIgnoreSupport
 func (c *commonCommands) StartColorFlow(count int, action CfAction, flowExpression FlowExpression) error {
+	if flowExpression.IgnoresBrightness() && !c.brightnessIgnore {
+		return errors.New("CF_BRIGHTNESS_IGNORE is not supported by device")
+	}
 	return c.commander.executeCommand(
 		partialCommand{c.prefix + "start_cf", params{count, action, flowExpression.encode()}},
 	)
@@ -97,9 +163,17 @@ func (c *commonCommands) StopColorFlow() error {
 }
 
 // SetScene can change state to given Scene, even if current device state is "off"
-// Not implemented! TODO: IMPLEMENT
 func (c *commonCommands) SetScene(scene Scene) error {
-	return errors.New("not implemented")
+	if scene == nil {
+		return errors.New("scene is required")
+	}
+	p, err := scene.toParams()
+	if err != nil {
+		return err
+	}
+	return c.commander.executeCommand(
+		partialCommand{c.prefix + "set_scene", p},
+	)
 }
 
 // Sets current state as default
@@ -143,6 +217,27 @@ func (c *commonCommands) PowerOff(duration int) error {
 	)
 }
 
+// SetPower turns device on or off, transition duration is given as time.Duration, see SetTemperature
+func (c *commonCommands) SetPower(on bool, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	if on {
+		return c.PowerOn(duration)
+	}
+	return c.PowerOff(duration)
+}
+
+// SetPowerWithMode behaves like PowerOnWithMode, transition duration is given as time.Duration
+func (c *commonCommands) SetPowerWithMode(mode Mode, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.PowerOnWithMode(duration, mode)
+}
+
 // Toggle is a Built-in method which toggles device state.
 // Only limitation is that fade effect can't be modified here, use PowerOn and PowerOff instead
 func (c *commonCommands) Toggle() error {
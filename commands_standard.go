@@ -10,13 +10,36 @@ import (
 
 type standardCommands struct {
 	commander commander
+
+	calibration      *Calibration // passed to music mode
+	brightnessIgnore bool         // passed to music mode
 }
 
-// Prop reads given properties
-// Not implemented! TODO: TODO
+// Prop reads given properties, values are returned as strings keyed by property name.
+// Properties not supported by device are returned as empty strings
 func (c *standardCommands) Prop(props ...Property) (map[string]interface{}, error) {
 	var data = make(map[string]interface{})
-	return data, errors.New("not implemented")
+	if len(props) == 0 {
+		return data, errors.New("at least one property is required")
+	}
+
+	var p params
+	for _, prop := range props {
+		p = append(p, string(prop))
+	}
+
+	result, err := c.commander.queryCommand(partialCommand{"get_prop", p})
+	if err != nil {
+		return data, err
+	}
+	if len(result) != len(props) {
+		return data, fmt.Errorf("expected %d property values, got %d", len(props), len(result))
+	}
+
+	for i, prop := range props {
+		data[string(prop)] = result[i]
+	}
+	return data, nil
 }
 
 // CronAdd sets timer which invokes given CronType operation (power off is only supported)
@@ -29,6 +52,15 @@ func (c *standardCommands) CronAdd(jobType CronType, minutes int) error {
 	)
 }
 
+// SetCron behaves like CronAdd, delay is rounded up to full minutes (range 1-60 minutes)
+func (c *standardCommands) SetCron(jobType CronType, delay time.Duration) error {
+	if delay <= 0 {
+		return errors.New("delay required to be > 0")
+	}
+	minutes := int((delay + time.Minute - 1) / time.Minute)
+	return c.CronAdd(jobType, minutes)
+}
+
 // Not implemented! TODO: TODO
 func (c *standardCommands) CronGet(jobType CronType) error {
 	return errors.New("not implemented")
@@ -89,6 +121,33 @@ func (c *standardCommands) AdjustColor(percentage, duration int) error {
 	)
 }
 
+// SetBrightnessBy behaves like AdjustBright, transition duration is given as time.Duration
+func (c *standardCommands) SetBrightnessBy(percentage int, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.AdjustBright(percentage, duration)
+}
+
+// SetTemperatureBy behaves like AdjustTemperature, transition duration is given as time.Duration
+func (c *standardCommands) SetTemperatureBy(percentage int, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.AdjustTemperature(percentage, duration)
+}
+
+// SetColorBy behaves like AdjustColor, transition duration is given as time.Duration
+func (c *standardCommands) SetColorBy(percentage int, d time.Duration) error {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return err
+	}
+	return c.AdjustColor(percentage, duration)
+}
+
 // SetName sets device name
 func (c *standardCommands) SetName(name string) error {
 	return c.commander.executeCommand(
@@ -143,7 +202,7 @@ func findIPv4Addr(iface net.Interface) (net.IP, error) {
 // Interface name can be passed to select exact interface for music server on first assigned IPv4 address
 // (bulb needs to connect to opened socket by client), empty string may be passed ("") for
 // trying to connect on first available (up and non-loopback) interface and first assigned IPv4 address
-func (c *standardCommands) StartMusic(ifaceName string) (musicSupportedCommands, error) {
+func (c *standardCommands) StartMusic(ifaceName string) (*Music, error) {
 	// TODO: Check "ignored" error when iptables not realoaded (personal archlinux issue)
 	var (
 		ifacesToTry []net.Interface
@@ -242,6 +301,8 @@ func (c *standardCommands) StartMusic(ifaceName string) (musicSupportedCommands,
 		if music == nil {
 			return nil, errors.New("[music] Connection failed")
 		}
+		music.SetCalibration(c.calibration)
+		music.commands.brightnessIgnore = c.brightnessIgnore
 
 		return music, nil
 	case <-time.After(time.Second * 2): // 2 second timeout
@@ -254,12 +315,38 @@ func (c *standardCommands) StartMusic(ifaceName string) (musicSupportedCommands,
 	}
 }
 
+const (
+	// Sudden changes state immediately
+	Sudden time.Duration = 0
+	// MinSmoothDuration is the shortest smooth transition accepted by device
+	MinSmoothDuration = 30 * time.Millisecond
+)
+
+// Smooth returns transition duration for smooth effect, durations shorter than MinSmoothDuration are extended to it
+func Smooth(d time.Duration) time.Duration {
+	if d < MinSmoothDuration {
+		return MinSmoothDuration
+	}
+	return d
+}
+
+// milliseconds converts duration into milliseconds expected by device, rounding to the nearest millisecond
+func milliseconds(d time.Duration) (int, error) {
+	if d < 0 {
+		return 0, errors.New("duration cannot be negative")
+	}
+	return int((d + time.Millisecond/2) / time.Millisecond), nil
+}
+
 // chooseEffect returns effect string Accordingly to given duration value.
-// Chooses between "sudden" and "smooth".
+// Chooses between "sudden" and "smooth", smooth effect requires at least 30 milliseconds
 func chooseEffect(duration int) (string, error) {
-	// if duration != 0 && duration < 30 {
-	//	return "", errors.New("Ooops")
-	// }
+	if duration < 0 {
+		return "", errors.New("duration cannot be negative")
+	}
+	if duration != 0 && duration < int(MinSmoothDuration/time.Millisecond) {
+		return "", errors.New("duration expected 0 (sudden) or >= 30 milliseconds (smooth)")
+	}
 
 	if duration == 0 {
 		return "sudden", nil
@@ -0,0 +1,129 @@
+package yeelight
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMilliseconds(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     int
+		valid    bool
+	}{
+		{0, 0, true},
+		{time.Second, 1000, true},
+		{30 * time.Millisecond, 30, true},
+		{1499 * time.Microsecond, 1, true},
+		{1500 * time.Microsecond, 2, true},
+		{400 * time.Microsecond, 0, true},
+		{-time.Millisecond, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.duration.String(), func(t *testing.T) {
+			got, err := milliseconds(test.duration)
+			if (err == nil) != test.valid {
+				t.Fatalf("expected valid %v, got %v", test.valid, err)
+			}
+			if got != test.want {
+				t.Errorf("expected %d, got %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestChooseEffect(t *testing.T) {
+	tests := []struct {
+		duration int
+		want     string
+		valid    bool
+	}{
+		{0, "sudden", true},
+		{1, "", false},
+		{29, "", false},
+		{30, "smooth", true},
+		{500, "smooth", true},
+		{-1, "", false},
+	}
+	for _, test := range tests {
+		effect, err := chooseEffect(test.duration)
+		if (err == nil) != test.valid {
+			t.Errorf("%d: expected valid %v, got %v", test.duration, test.valid, err)
+		}
+		if effect != test.want {
+			t.Errorf("%d: expected %q, got %q", test.duration, test.want, effect)
+		}
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	tests := []struct {
+		duration, want time.Duration
+	}{
+		{0, MinSmoothDuration},
+		{10 * time.Millisecond, MinSmoothDuration},
+		{MinSmoothDuration, MinSmoothDuration},
+		{time.Second, time.Second},
+	}
+	for _, test := range tests {
+		if got := Smooth(test.duration); got != test.want {
+			t.Errorf("Smooth(%v): expected %v, got %v", test.duration, test.want, got)
+		}
+	}
+}
+
+func TestDurationCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(b *Bulb) error
+		want partialCommand
+	}{
+		{"rgb sudden", func(b *Bulb) error { return b.SetRGB(0xff0000, Sudden) },
+			partialCommand{"set_rgb", params{0xff0000, "sudden", 0}}},
+		{"rgb smooth", func(b *Bulb) error { return b.SetRGB(0xff0000, Smooth(0)) },
+			partialCommand{"set_rgb", params{0xff0000, "smooth", 30}}},
+		{"hsv rounded", func(b *Bulb) error { return b.SetHSV(120, 100, 500400*time.Microsecond) },
+			partialCommand{"set_hsv", params{120, 100, "smooth", 500}}},
+		{"temperature", func(b *Bulb) error { return b.SetTemperature(2700, time.Second) },
+			partialCommand{"set_ct_abx", params{2700, "smooth", 1000}}},
+		{"brightness", func(b *Bulb) error { return b.SetBrightness(50, 2*time.Second) },
+			partialCommand{"set_bright", params{50, "smooth", 2000}}},
+		{"power on", func(b *Bulb) error { return b.SetPower(true, 300*time.Millisecond) },
+			partialCommand{"set_power", params{"on", "smooth", 300}}},
+		{"power off", func(b *Bulb) error { return b.SetPower(false, Sudden) },
+			partialCommand{"set_power", params{"off", "sudden", 0}}},
+		{"brightness by", func(b *Bulb) error { return b.SetBrightnessBy(-20, 100*time.Millisecond) },
+			partialCommand{"adjust_bright", params{-20, 100}}},
+		{"background rgb", func(b *Bulb) error { return b.Bg.SetRGB(0x00ff00, time.Second) },
+			partialCommand{"bg_set_rgb", params{0x00ff00, "smooth", 1000}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &recorder{}
+			bulb := NewBulb("127.0.0.1")
+			bulb.commonCommands.commander = r
+			bulb.standardCommands.commander = r
+			bulb.Bg.commonCommands.commander = r
+
+			if err := test.call(bulb); err != nil {
+				t.Fatal(err)
+			}
+			if len(r.commands) != 1 || !reflect.DeepEqual(r.commands[0], test.want) {
+				t.Errorf("expected %v, got %v", test.want, r.commands)
+			}
+		})
+	}
+
+	r := &recorder{}
+	bulb := NewBulb("127.0.0.1")
+	bulb.commonCommands.commander = r
+	for _, d := range []time.Duration{time.Millisecond, 29 * time.Millisecond, -time.Second} {
+		if err := bulb.SetRGB(0xff0000, d); err == nil {
+			t.Errorf("%v: expected error", d)
+		}
+	}
+	if len(r.commands) != 0 {
+		t.Errorf("expected no commands sent, got %v", r.commands)
+	}
+}
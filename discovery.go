@@ -0,0 +1,168 @@
+package yeelight
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	discoveryAddress = "239.255.255.250:1982"
+	discoveryMessage = "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1982\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"ST: wifi_bulb\r\n"
+)
+
+// Device describes bulb found by discovery (search response or advertisement)
+type Device struct {
+	ID      string   // unique device identifier, for instance "0x000000000015243f"
+	Model   string   // product model, for instance "color", "mono", "stripe", "ceiling"
+	FwVer   int      // firmware version
+	Support []string // supported methods
+	Ip      string
+	Port    int
+
+	Name      string
+	Power     bool
+	Bright    int
+	ColorMode int // 1: rgb mode / 2: color temperature mode / 3: hsv mode
+	CT        int
+	RGB       int
+	Hue       int
+	Sat       int
+}
+
+// Supports returns true when device reports support for given method (for instance "bg_set_rgb")
+func (d Device) Supports(method string) bool {
+	for _, m := range d.Support {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Bulb creates Bulb instance for discovered device, it has to be connected before use
+func (d Device) Bulb() *Bulb {
+	bulb := NewBulb(d.Ip)
+	if d.Port != 0 {
+		bulb.Port = d.Port
+	}
+	return bulb
+}
+
+// Discover searches for devices in local network, responses are collected for given amount of time
+func Discover(timeout time.Duration) ([]Device, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open discovery socket: %v", err)
+	}
+	defer conn.Close()
+
+	address, err := net.ResolveUDPAddr("udp4", discoveryAddress)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo([]byte(discoveryMessage), address); err != nil {
+		return nil, fmt.Errorf("failed to send discovery message: %v", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var (
+		devices []Device
+		seen    = make(map[string]bool)
+		buf     = make([]byte, 2048)
+	)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return devices, err
+		}
+
+		device, err := ParseDiscoveryMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		if seen[device.ID] {
+			continue
+		}
+		seen[device.ID] = true
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// ParseDiscoveryMessage parses search response or advertisement (NOTIFY) message sent by device
+func ParseDiscoveryMessage(message []byte) (Device, error) {
+	reader := bufio.NewReader(bytes.NewReader(message))
+
+	statusLine, err := reader.ReadString('\n')
+	if err != nil {
+		return Device{}, errors.New("incomplete discovery message")
+	}
+	statusLine = strings.TrimSpace(statusLine)
+	if !strings.HasPrefix(statusLine, "HTTP/1.1 200") && !strings.HasPrefix(statusLine, "NOTIFY") {
+		return Device{}, fmt.Errorf("unexpected discovery message: %s", statusLine)
+	}
+
+	headers := make(http.Header)
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, ":"); i > 0 {
+			headers.Add(strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]))
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	location, err := url.Parse(headers.Get("Location"))
+	if err != nil || location.Scheme != "yeelight" {
+		return Device{}, fmt.Errorf("invalid device location: \"%s\"", headers.Get("Location"))
+	}
+	port, err := strconv.Atoi(location.Port())
+	if err != nil {
+		return Device{}, fmt.Errorf("invalid device port: \"%s\"", location.Port())
+	}
+
+	atoi := func(key string) int {
+		value, _ := strconv.Atoi(headers.Get(key))
+		return value
+	}
+
+	device := Device{
+		ID:        headers.Get("id"),
+		Model:     headers.Get("model"),
+		FwVer:     atoi("fw_ver"),
+		Support:   strings.Fields(headers.Get("support")),
+		Ip:        location.Hostname(),
+		Port:      port,
+		Name:      headers.Get("name"),
+		Power:     headers.Get("power") == "on",
+		Bright:    atoi("bright"),
+		ColorMode: atoi("color_mode"),
+		CT:        atoi("ct"),
+		RGB:       atoi("rgb"),
+		Hue:       atoi("hue"),
+		Sat:       atoi("sat"),
+	}
+	if device.ID == "" {
+		return Device{}, errors.New("discovery message without device id")
+	}
+	return device, nil
+}
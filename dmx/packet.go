@@ -0,0 +1,191 @@
+// Package dmx drives bulbs from lighting consoles. Receiver decodes Art-Net (ArtDmx) and E1.31 (sACN)
+// packets and maps DMX channels onto bulbs patched in Patch, values are streamed through music mode,
+// so updates are not limited by command quota. Sender encodes synthetic packets for testing
+package dmx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Protocol is a DMX over IP protocol
+type Protocol int
+
+const (
+	ArtNet Protocol = iota // Art-Net 4, universes are numbered from 0 (15-bit port address)
+	E131                   // E1.31 (sACN), universes are numbered from 1
+)
+
+func (p Protocol) String() string {
+	if p == E131 {
+		return "sACN"
+	}
+	return "Art-Net"
+}
+
+// default ports of protocols
+const (
+	ArtNetPort = 6454
+	E131Port   = 5568
+)
+
+// Frame is a DMX universe update
+type Frame struct {
+	Protocol Protocol
+	Universe int
+	Sequence uint8  // 0 disables sequence checking of Art-Net
+	Data     []byte // channel values, channel 1 is Data[0]
+}
+
+var (
+	artNetID = []byte("Art-Net\x00")
+	acnID    = []byte("ASC-E1.17\x00\x00\x00")
+
+	// errIgnored is returned for valid packets which don't carry DMX data (polls, sync, other start codes)
+	errIgnored = errors.New("packet doesn't carry DMX data")
+)
+
+const (
+	artOpDmx     = 0x5000
+	artProtocol  = 14
+	e131Header   = 126 // length of E1.31 data packet without channel values
+	e131Priority = 100
+)
+
+// ParsePacket decodes Art-Net or E1.31 packet carrying DMX data
+func ParsePacket(packet []byte) (Frame, error) {
+	switch {
+	case bytes.HasPrefix(packet, artNetID):
+		return parseArtNet(packet)
+	case len(packet) >= 16 && bytes.Equal(packet[4:16], acnID):
+		return parseE131(packet)
+	}
+	return Frame{}, errors.New("unknown packet, expected Art-Net or E1.31")
+}
+
+func parseArtNet(packet []byte) (Frame, error) {
+	if len(packet) < 10 {
+		return Frame{}, errors.New("Art-Net packet too short")
+	}
+	if binary.LittleEndian.Uint16(packet[8:10]) != artOpDmx {
+		return Frame{}, errIgnored
+	}
+	if len(packet) < 18 {
+		return Frame{}, errors.New("ArtDmx packet too short")
+	}
+	length := int(binary.BigEndian.Uint16(packet[16:18]))
+	if length > 512 || len(packet) < 18+length {
+		return Frame{}, fmt.Errorf("invalid ArtDmx data length %d", length)
+	}
+	return Frame{
+		Protocol: ArtNet,
+		Universe: int(packet[15]&0x7f)<<8 | int(packet[14]),
+		Sequence: packet[12],
+		Data:     packet[18 : 18+length],
+	}, nil
+}
+
+func parseE131(packet []byte) (Frame, error) {
+	if len(packet) < 22 {
+		return Frame{}, errors.New("E1.31 packet too short")
+	}
+	if binary.BigEndian.Uint32(packet[18:22]) != 0x04 { // VECTOR_ROOT_E131_DATA, sync packets are ignored
+		return Frame{}, errIgnored
+	}
+	if len(packet) < e131Header {
+		return Frame{}, errors.New("E1.31 data packet too short")
+	}
+	if binary.BigEndian.Uint32(packet[40:44]) != 0x02 || packet[117] != 0x02 || packet[118] != 0xa1 {
+		return Frame{}, errors.New("invalid E1.31 framing or DMP layer")
+	}
+	if packet[112]&0xc0 != 0 || packet[125] != 0 {
+		// preview data, terminated stream and alternate start codes don't drive outputs
+		return Frame{}, errIgnored
+	}
+	count := int(binary.BigEndian.Uint16(packet[123:125])) - 1 // start code included
+	if count < 0 || count > 512 || len(packet) < e131Header+count {
+		return Frame{}, fmt.Errorf("invalid E1.31 property count %d", count+1)
+	}
+	return Frame{
+		Protocol: E131,
+		Universe: int(binary.BigEndian.Uint16(packet[113:115])),
+		Sequence: packet[111],
+		Data:     packet[e131Header : e131Header+count],
+	}, nil
+}
+
+// MarshalArtNet encodes frame as ArtDmx packet, data is padded to even length required by protocol
+func (f Frame) MarshalArtNet() []byte {
+	data := f.Data
+	if len(data) < 2 || len(data)%2 != 0 {
+		data = append(append([]byte{}, data...), 0)
+	}
+	packet := make([]byte, 18+len(data))
+	copy(packet, artNetID)
+	binary.LittleEndian.PutUint16(packet[8:10], artOpDmx)
+	binary.BigEndian.PutUint16(packet[10:12], artProtocol)
+	packet[12] = f.Sequence
+	packet[14] = byte(f.Universe)
+	packet[15] = byte(f.Universe>>8) & 0x7f
+	binary.BigEndian.PutUint16(packet[16:18], uint16(len(data)))
+	copy(packet[18:], data)
+	return packet
+}
+
+// MarshalE131 encodes frame as E1.31 data packet sent by given source (component ID and name)
+func (f Frame) MarshalE131(cid [16]byte, source string) []byte {
+	packet := make([]byte, e131Header+len(f.Data))
+	flagsLength := func(offset int) {
+		binary.BigEndian.PutUint16(packet[offset:offset+2], 0x7000|uint16(len(packet)-offset))
+	}
+
+	// root layer
+	binary.BigEndian.PutUint16(packet[0:2], 0x0010)
+	copy(packet[4:16], acnID)
+	flagsLength(16)
+	binary.BigEndian.PutUint32(packet[18:22], 0x04)
+	copy(packet[22:38], cid[:])
+
+	// framing layer
+	flagsLength(38)
+	binary.BigEndian.PutUint32(packet[40:44], 0x02)
+	copy(packet[44:107], source) // null terminated
+	packet[108] = e131Priority
+	packet[111] = f.Sequence
+	binary.BigEndian.PutUint16(packet[113:115], uint16(f.Universe))
+
+	// DMP layer
+	flagsLength(115)
+	packet[117] = 0x02
+	packet[118] = 0xa1
+	binary.BigEndian.PutUint16(packet[121:123], 1)
+	binary.BigEndian.PutUint16(packet[123:125], uint16(len(f.Data)+1))
+	copy(packet[e131Header:], f.Data)
+	return packet
+}
+
+// sequenceFilter drops late and duplicated packets, as recommended by E1.31:
+// packet is dropped when it's up to 20 sequence numbers behind the last accepted one
+type sequenceFilter map[universeKey]uint8
+
+type universeKey struct {
+	protocol Protocol
+	universe int
+}
+
+func (s sequenceFilter) accept(f Frame) bool {
+	if f.Protocol == ArtNet && f.Sequence == 0 {
+		return true
+	}
+	key := universeKey{f.Protocol, f.Universe}
+	last, ok := s[key]
+	if ok {
+		if diff := int8(f.Sequence - last); diff <= 0 && diff > -20 {
+			return false
+		}
+	}
+	s[key] = f.Sequence
+	return true
+}
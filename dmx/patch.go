@@ -0,0 +1,163 @@
+package dmx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+)
+
+// Mode is a channel layout of patched bulb
+type Mode string
+
+const (
+	ModeRGB         Mode = "rgb"  // red, green, blue, intensity is given by the brightest channel
+	ModeRGBDimmer   Mode = "rgbd" // red, green, blue, dimmer
+	ModeTemperature Mode = "ctd"  // color temperature (0: 1700 K - 255: 6500 K), dimmer
+)
+
+// Channels returns number of DMX channels used by mode, 0 for unknown modes
+func (m Mode) Channels() int {
+	switch m {
+	case ModeRGB:
+		return 3
+	case ModeRGBDimmer:
+		return 4
+	case ModeTemperature:
+		return 2
+	}
+	return 0
+}
+
+// Fixture patches a bulb onto DMX channels
+type Fixture struct {
+	Bulb     string `json:"bulb"`     // bulb name or device ID, see registry.Lookup
+	Universe int    `json:"universe"` // universe as numbered by protocol (Art-Net from 0, sACN from 1)
+	Address  int    `json:"address"`  // first channel, 1-512
+	Mode     Mode   `json:"mode"`
+}
+
+// Patch is a list of patched bulbs
+type Patch []Fixture
+
+// Validate checks addresses and modes, every bulb can be patched once
+func (p Patch) Validate() error {
+	bulbs := make(map[string]bool)
+	for _, f := range p {
+		if f.Bulb == "" {
+			return fmt.Errorf("fixture at %d.%d: bulb is required", f.Universe, f.Address)
+		}
+		if bulbs[f.Bulb] {
+			return fmt.Errorf("bulb \"%s\" is patched more than once", f.Bulb)
+		}
+		bulbs[f.Bulb] = true
+
+		channels := f.Mode.Channels()
+		if channels == 0 {
+			return fmt.Errorf("bulb \"%s\": unknown mode \"%s\", expected rgb, rgbd or ctd", f.Bulb, f.Mode)
+		}
+		if f.Universe < 0 || f.Universe > 63999 {
+			return fmt.Errorf("bulb \"%s\": universe expected in 0~63999 range, got %d", f.Bulb, f.Universe)
+		}
+		if f.Address < 1 || f.Address+channels-1 > 512 {
+			return fmt.Errorf("bulb \"%s\": %d channels starting at %d don't fit in universe", f.Bulb, channels, f.Address)
+		}
+	}
+	return nil
+}
+
+// Universes returns patched universes
+func (p Patch) Universes() []int {
+	var (
+		universes []int
+		seen      = make(map[int]bool)
+	)
+	for _, f := range p {
+		if !seen[f.Universe] {
+			seen[f.Universe] = true
+			universes = append(universes, f.Universe)
+		}
+	}
+	return universes
+}
+
+// LoadPatch reads patch from JSON file, example:
+//   [
+//     {"bulb": "stage/left", "universe": 1, "address": 1, "mode": "rgb"},
+//     {"bulb": "stage/right", "universe": 1, "address": 4, "mode": "rgbd"},
+//     {"bulb": "stage/back", "universe": 1, "address": 8, "mode": "ctd"}
+//   ]
+func LoadPatch(path string) (Patch, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return patch, nil
+}
+
+// Output is a bulb output decoded from DMX channels, zero brightness is a blackout
+type Output struct {
+	RGB         int // 0xRRGGBB, used when Temperature is zero
+	Temperature int // kelvins
+	Brightness  int // 0-100
+}
+
+// Output decodes fixture channels from universe data, false is returned when data doesn't contain
+// all channels of the fixture (consoles may send shortened universes)
+func (f Fixture) Output(data []byte) (Output, bool) {
+	first := f.Address - 1
+	if first < 0 || first+f.Mode.Channels() > len(data) {
+		return Output{}, false
+	}
+	ch := data[first : first+f.Mode.Channels()]
+
+	switch f.Mode {
+	case ModeRGB:
+		rgb, level := normalize(ch[0], ch[1], ch[2])
+		return Output{RGB: rgb, Brightness: percentage(level, 255)}, true
+	case ModeRGBDimmer:
+		rgb, level := normalize(ch[0], ch[1], ch[2])
+		return Output{RGB: rgb, Brightness: percentage(level*int(ch[3]), 255*255)}, true
+	case ModeTemperature:
+		temp := 1700 + int(math.Round(float64(ch[0])*(6500-1700)/255))
+		return Output{Temperature: temp, Brightness: percentage(int(ch[1]), 255)}, true
+	}
+	return Output{}, false
+}
+
+// normalize scales color to full intensity, as bulb brightness is set separately,
+// level of the brightest channel is returned
+func normalize(r, g, b byte) (rgb, level int) {
+	level = int(r)
+	if int(g) > level {
+		level = int(g)
+	}
+	if int(b) > level {
+		level = int(b)
+	}
+	if level == 0 {
+		return 0xffffff, 0
+	}
+	scale := func(c byte) int { return int(math.Round(float64(c) * 255 / float64(level))) }
+	return scale(r)<<16 | scale(g)<<8 | scale(b), level
+}
+
+// percentage converts value into brightness percentage, non-zero values are at least 1
+func percentage(value, max int) int {
+	if value <= 0 {
+		return 0
+	}
+	p := int(math.Round(float64(value) * 100 / float64(max)))
+	if p < 1 {
+		return 1
+	}
+	return p
+}
@@ -0,0 +1,267 @@
+package dmx
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// Receiver drives patched bulbs from received DMX frames. Every bulb is updated by its own worker, frames
+// received faster than update interval are coalesced, so only the latest values are sent. Music mode is
+// started on the first non-zero output, blackout stops music mode and turns the bulb off
+type Receiver struct {
+	registry *registry.Registry
+	fixtures map[int][]*output // keyed by universe
+	iface    string
+	interval time.Duration
+
+	sequencesMtx sync.Mutex
+	sequences    sequenceFilter
+
+	done    chan struct{}
+	workers sync.WaitGroup
+}
+
+// output is a patched bulb with its music mode session
+type output struct {
+	fixture Fixture
+	start   sync.Once
+
+	mtx     sync.Mutex
+	pending Output
+	valid   bool // pending value was received and wasn't rejected by the bulb
+	wake    chan struct{}
+
+	// owned by worker
+	music *yl.Music
+	last  Output
+	off   bool
+}
+
+// retryDelay is a delay after failed update of a bulb
+const retryDelay = 5 * time.Second
+
+// NewReceiver creates receiver of bulbs patched in valid patch (see Patch.Validate)
+func NewReceiver(reg *registry.Registry, patch Patch) *Receiver {
+	r := &Receiver{
+		registry:  reg,
+		fixtures:  make(map[int][]*output),
+		interval:  50 * time.Millisecond,
+		sequences: make(sequenceFilter),
+		done:      make(chan struct{}),
+	}
+	for _, f := range patch {
+		r.fixtures[f.Universe] = append(r.fixtures[f.Universe], &output{fixture: f, wake: make(chan struct{}, 1)})
+	}
+	return r
+}
+
+// SetMusicInterface sets network interface used for music mode connections, see registry.Registry.StartMusic
+func (r *Receiver) SetMusicInterface(iface string) {
+	r.iface = iface
+}
+
+// SetInterval sets minimum interval between updates of a single bulb, 50ms by default.
+// Bulbs become unresponsive when flooded with commands
+func (r *Receiver) SetInterval(interval time.Duration) {
+	r.interval = interval
+}
+
+// Serve receives packets from connection until connection is closed, packets of both protocols
+// are accepted and other packets are ignored
+func (r *Receiver) Serve(conn net.PacketConn) error {
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-r.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		frame, err := ParsePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+		r.Handle(frame)
+	}
+}
+
+// Handle applies received frame, late and duplicated frames are dropped
+func (r *Receiver) Handle(frame Frame) {
+	fixtures := r.fixtures[frame.Universe]
+	if len(fixtures) == 0 {
+		return
+	}
+
+	r.sequencesMtx.Lock()
+	accepted := r.sequences.accept(frame)
+	r.sequencesMtx.Unlock()
+	if !accepted {
+		return
+	}
+
+	for _, o := range fixtures {
+		value, ok := o.fixture.Output(frame.Data)
+		if !ok {
+			continue
+		}
+		o.start.Do(func() {
+			r.workers.Add(1)
+			go r.run(o)
+		})
+		o.update(value)
+	}
+}
+
+// Close stops workers and music mode of all bulbs, bulbs are left in their current state
+func (r *Receiver) Close() {
+	select {
+	case <-r.done:
+		return
+	default:
+		close(r.done)
+	}
+	r.workers.Wait()
+}
+
+// update queues value when it differs from the last queued one
+func (o *output) update(value Output) {
+	o.mtx.Lock()
+	if o.valid && o.pending == value {
+		o.mtx.Unlock()
+		return
+	}
+	o.pending, o.valid = value, true
+	o.mtx.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Receiver) run(o *output) {
+	defer r.workers.Done()
+	defer o.stopMusic()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-o.wake:
+		}
+
+		o.mtx.Lock()
+		value := o.pending
+		o.mtx.Unlock()
+
+		wait := r.interval
+		if err := r.apply(o, value); err != nil {
+			log.Printf("[dmx] bulb \"%s\": %v\n", o.fixture.Bulb, err)
+			o.stopMusic()
+
+			// value is sent again with the first frame after delay, retries would consume quota
+			o.mtx.Lock()
+			o.valid = false
+			o.mtx.Unlock()
+			wait = retryDelay
+		}
+
+		select {
+		case <-r.done:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// apply sends value to the bulb, only changed color and brightness are sent
+func (r *Receiver) apply(o *output, value Output) error {
+	if value.Brightness == 0 {
+		if o.off {
+			return nil
+		}
+		// bulb exits music mode when it's turned off
+		o.stopMusic()
+		yl.WaitQuota()
+		if err := r.registry.Execute(o.fixture.Bulb, func(b *yl.Bulb) error { return b.SetPower(false, 0) }); err != nil {
+			return err
+		}
+		o.off = true
+		return nil
+	}
+
+	if o.music == nil {
+		yl.WaitQuota()
+		music, err := r.registry.StartMusic(o.fixture.Bulb, r.iface)
+		if err != nil {
+			return err
+		}
+		o.music, o.last, o.off = music, Output{}, false
+	}
+
+	if value.RGB != o.last.RGB || value.Temperature != o.last.Temperature {
+		if value.Temperature != 0 {
+			o.music.SetTemperature(value.Temperature, 0)
+		} else {
+			o.music.SetRGB(value.RGB, 0)
+		}
+	}
+	if value.Brightness != o.last.Brightness {
+		o.music.SetBrightness(value.Brightness, 0)
+	}
+	o.last = value
+	return nil
+}
+
+func (o *output) stopMusic() {
+	if o.music == nil {
+		return
+	}
+	_ = o.music.Stop()
+	o.music = nil
+}
+
+// ListenArtNet opens Art-Net listener, ":6454" receives both broadcast and unicast packets
+func ListenArtNet(address string) (net.PacketConn, error) {
+	return net.ListenPacket("udp4", address)
+}
+
+// E131Address returns multicast address of E1.31 universe
+func E131Address(universe int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4(239, 255, byte(universe>>8), byte(universe)), Port: E131Port}
+}
+
+// ListenE131 opens multicast listeners of given universes, interface is chosen by system when name is empty.
+// Unicast packets are received as well. Listeners share the port, so the same packet may be received by
+// several of them, duplicates are dropped by Receiver
+func ListenE131(iface string, universes []int) ([]net.PacketConn, error) {
+	var netIface *net.Interface
+	if iface != "" {
+		var err error
+		if netIface, err = net.InterfaceByName(iface); err != nil {
+			return nil, err
+		}
+	}
+
+	var conns []net.PacketConn
+	for _, universe := range universes {
+		conn, err := net.ListenMulticastUDP("udp4", netIface, E131Address(universe))
+		if err != nil {
+			for _, c := range conns {
+				_ = c.Close()
+			}
+			return nil, fmt.Errorf("universe %d: %v", universe, err)
+		}
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
@@ -0,0 +1,131 @@
+package dmx
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+func TestPacketRoundTrip(t *testing.T) {
+	data := []byte{255, 128, 0, 64}
+	for _, frame := range []Frame{
+		{Protocol: ArtNet, Universe: 3, Sequence: 7, Data: data},
+		{Protocol: E131, Universe: 1, Sequence: 200, Data: data},
+	} {
+		packet := frame.MarshalArtNet()
+		if frame.Protocol == E131 {
+			packet = frame.MarshalE131([16]byte{1}, "test")
+		}
+		parsed, err := ParsePacket(packet)
+		if err != nil {
+			t.Fatalf("%v: %v", frame.Protocol, err)
+		}
+		if !reflect.DeepEqual(parsed, frame) {
+			t.Errorf("%v: expected %+v, got %+v", frame.Protocol, frame, parsed)
+		}
+	}
+}
+
+// waitCommands waits until device receives commands with given methods, music mode commands are
+// recognized by "music:" prefix
+func waitCommands(t *testing.T, device *yeelighttest.Device, expected ...string) {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var received []string
+		for _, command := range device.Commands() {
+			if command.Music {
+				received = append(received, "music:"+command.Method)
+			} else {
+				received = append(received, command.Method)
+			}
+		}
+		if reflect.DeepEqual(received, expected) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v, got %v", expected, received)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReceiverLoopback(t *testing.T) {
+	for _, protocol := range []Protocol{ArtNet, E131} {
+		t.Run(protocol.String(), func(t *testing.T) {
+			testReceiverLoopback(t, protocol)
+		})
+	}
+}
+
+func testReceiverLoopback(t *testing.T, protocol Protocol) {
+	reg := registry.New("")
+	defer reg.Close()
+	devices := make(map[string]*yeelighttest.Device)
+	for _, name := range []string{"left", "back"} {
+		device, err := yeelighttest.NewDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer device.Close()
+		devices[name] = device
+		if err := reg.Set(registry.Entry{ID: device.ID, Name: name, Ip: device.Ip, Port: device.Port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	patch := Patch{
+		{Bulb: "left", Universe: 1, Address: 1, Mode: ModeRGBDimmer},
+		{Bulb: "back", Universe: 1, Address: 5, Mode: ModeTemperature},
+	}
+	if err := patch.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	receiver := NewReceiver(reg, patch)
+	receiver.SetInterval(10 * time.Millisecond)
+	defer receiver.Close()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go receiver.Serve(conn)
+
+	sender, err := DialSender(conn.LocalAddr().String(), protocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	// red at half intensity and the warmest temperature at full brightness
+	if err := sender.Send(1, []byte{255, 0, 0, 128, 0, 255}); err != nil {
+		t.Fatal(err)
+	}
+	waitCommands(t, devices["left"], "set_power", "set_music", "music:set_rgb", "music:set_bright")
+	waitCommands(t, devices["back"], "set_power", "set_music", "music:set_ct_abx", "music:set_bright")
+
+	commands := devices["left"].Commands()
+	if rgb, bright := commands[2].Params[0], commands[3].Params[0]; rgb != float64(0xff0000) || bright != float64(50) {
+		t.Errorf("expected red at 50%%, got %v at %v%%", rgb, bright)
+	}
+	if temp := devices["back"].Commands()[2].Params[0]; temp != float64(1700) {
+		t.Errorf("expected 1700 K, got %v", temp)
+	}
+
+	// only changed values are sent, blackout turns the bulb off
+	devices["left"].Reset()
+	devices["back"].Reset()
+	if err := sender.Send(1, []byte{255, 0, 0, 255, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	waitCommands(t, devices["left"], "music:set_bright")
+	waitCommands(t, devices["back"], "set_power")
+	if devices["back"].Prop(yl.PROP_POWER) != "off" {
+		t.Error("bulb wasn't turned off by blackout")
+	}
+}
@@ -0,0 +1,68 @@
+package dmx
+
+import (
+	"crypto/rand"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// Sender sends DMX frames, it allows testing receivers without lighting console
+type Sender struct {
+	conn     net.Conn
+	protocol Protocol
+	cid      [16]byte
+	source   string
+
+	mtx       sync.Mutex
+	sequences map[int]uint8 // last sequence numbers keyed by universe
+}
+
+// NewSender creates sender of given protocol writing packets into connected UDP connection
+func NewSender(conn net.Conn, protocol Protocol) *Sender {
+	s := &Sender{conn: conn, protocol: protocol, source: "yeelight-go", sequences: make(map[int]uint8)}
+	_, _ = rand.Read(s.cid[:])
+	return s
+}
+
+// DialSender creates sender of packets sent to given address, default port of protocol is used
+// when address doesn't contain port ("127.0.0.1"). E1.31 multicast address of universe can be
+// obtained with E131Address
+func DialSender(address string, protocol Protocol) (*Sender, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		port := ArtNetPort
+		if protocol == E131 {
+			port = E131Port
+		}
+		address = net.JoinHostPort(address, strconv.Itoa(port))
+	}
+	conn, err := net.Dial("udp4", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewSender(conn, protocol), nil
+}
+
+// Send sends channel values of universe, channel 1 is data[0]
+func (s *Sender) Send(universe int, data []byte) error {
+	s.mtx.Lock()
+	sequence := s.sequences[universe] + 1
+	if sequence == 0 && s.protocol == ArtNet {
+		sequence = 1 // 0 disables sequence checking
+	}
+	s.sequences[universe] = sequence
+	s.mtx.Unlock()
+
+	frame := Frame{Protocol: s.protocol, Universe: universe, Sequence: sequence, Data: data}
+	packet := frame.MarshalArtNet()
+	if s.protocol == E131 {
+		packet = frame.MarshalE131(s.cid, s.source)
+	}
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+// Close closes connection
+func (s *Sender) Close() error {
+	return s.conn.Close()
+}
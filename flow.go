@@ -3,6 +3,9 @@ package yeelight
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type FlowState struct {
@@ -17,51 +20,102 @@ type FlowState struct {
 	Brightness int // brightness value (1-100), -1 when don't want to change brightness
 }
 
+// ColorStep creates color transition step, rgb range: 0x000000-0xFFFFFF, duration is rounded to the nearest
+// millisecond (minimum 50ms). brightness range: 1-100, CF_BRIGHTNESS_IGNORE may be passed for keeping current brightness
+func ColorStep(rgb int, d time.Duration, brightness int) (FlowState, error) {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return FlowState{}, err
+	}
+	return newFlowState(duration, CF_MODE_COLOR, rgb, brightness)
+}
+
+// TemperatureStep creates temperature transition step, temperature range: 1700-6500, see ColorStep
+func TemperatureStep(temp int, d time.Duration, brightness int) (FlowState, error) {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return FlowState{}, err
+	}
+	return newFlowState(duration, CF_MODE_TEMP, temp, brightness)
+}
+
+// SleepStep creates step which holds current state for given duration (minimum 50ms)
+func SleepStep(d time.Duration) (FlowState, error) {
+	duration, err := milliseconds(d)
+	if err != nil {
+		return FlowState{}, err
+	}
+	return newFlowState(duration, CF_MODE_SLEEP, 0, 0)
+}
+
 // NewFlowState creates transition step and panics on incorrect input variables
 // examples:
 //   yl.NewFlowState(50, yl.CF_MODE_COLOR, 0xff0000, 100)
 //   yl.NewFlowState(200, yl.CF_MODE_SLEEP, 0, 0)
 //   yl.NewFlowState(50, yl.CF_MODE_COLOR, 0x0000ff, 100)
 //   yl.NewFlowState(200, yl.CF_MODE_SLEEP, 0, 0)
-// TODO: prepare unfriendly interface for creating transition objects separately, according to supported modes
-//      for instance, current sleep command:
-//          NewFlowState(200, yl.CF_MODE_SLEEP, 0, 0)
-//      could be changed to:
-//          NewSleepState(200)
+// ColorStep, TemperatureStep and SleepStep are preferred, as they return an error instead of panicking
 func NewFlowState(duration int, mode CfMode, value, brightness int) FlowState {
+	state, err := newFlowState(duration, mode, value, brightness)
+	if err != nil {
+		panic(err.Error())
+	}
+	return state
+}
+
+// newFlowState validates given values and creates transition step
+func newFlowState(duration int, mode CfMode, value, brightness int) (FlowState, error) {
 	if duration < 50 {
-		panic("duration required to be >= 50")
+		return FlowState{}, errors.New("duration required to be >= 50")
 	}
 
-	valudateBrightness := func(brightness int) {
+	validateBrightness := func(brightness int) error {
+		// documentation says -1 is possible for skipping brightness manipulation,
+		// however, It just doesn't work on some devices (general error is returned with code 5000)
+		if brightness == CF_BRIGHTNESS_IGNORE {
+			return nil
+		}
 		if brightness < 1 || brightness > 100 {
-			// documentation says -1 is possible for skipping brightness manipulation,
-			// however, It just doesn't work, at least for my bulb (general error is returned with code 5000)
-			// according to this founding, I'll stick with standard 1-100 range validation
-			panic("brightness in 1-100 range or -1 (do not change brightness)")
+			return errors.New("brightness in 1-100 range or -1 (do not change brightness)")
 		}
+		return nil
 	}
 
 	switch mode {
 	case CF_MODE_COLOR:
 		if value < 0 || value > 0xffffff {
-			panic("value for color mode should be in 0-0xffffff range")
+			return FlowState{}, errors.New("value for color mode should be in 0-0xffffff range")
+		}
+		if err := validateBrightness(brightness); err != nil {
+			return FlowState{}, err
 		}
-		valudateBrightness(brightness)
 	case CF_MODE_TEMP:
 		if value < 1700 || value > 6500 {
-			panic("value for temperature mode should be in 1700-6500 range")
+			return FlowState{}, errors.New("value for temperature mode should be in 1700-6500 range")
+		}
+		if err := validateBrightness(brightness); err != nil {
+			return FlowState{}, err
 		}
-		valudateBrightness(brightness)
 	case CF_MODE_SLEEP:
 		// value and brightness are ignored in sleep mode
 		value = 0
 		brightness = 0
 	default:
-		panic("mode required to be 1 (color), 2 (temp), or 7 (sleep)")
+		return FlowState{}, errors.New("mode required to be 1 (color), 2 (temp), or 7 (sleep)")
 	}
 
-	return FlowState{duration, mode, value, brightness}
+	return FlowState{duration, mode, value, brightness}, nil
+}
+
+// Length returns transition (or sleep) duration of the step
+func (s FlowState) Length() time.Duration {
+	return time.Duration(s.Duration) * time.Millisecond
+}
+
+// validate checks if FlowState holds values acceptable by device
+func (s FlowState) validate() error {
+	_, err := newFlowState(s.Duration, s.Mode, s.Value, s.Brightness)
+	return err
 }
 
 type FlowExpression struct {
@@ -85,16 +139,234 @@ func (e *FlowExpression) encode() string {
 	return encodedExpression
 }
 
-func NewFlowExpression(states ...FlowState) (error, FlowExpression) {
+// Duration returns time of single expression cycle
+func (e *FlowExpression) Duration() time.Duration {
+	var total time.Duration
+	for _, state := range e.states {
+		total += state.Length()
+	}
+	return total
+}
+
+// IgnoresBrightness returns true when any of expression steps uses CF_BRIGHTNESS_IGNORE,
+// such expressions are started only on devices declared to support it (see Bulb.SetBrightnessIgnoreSupport)
+func (e *FlowExpression) IgnoresBrightness() bool {
+	for _, state := range e.states {
+		if state.Mode != CF_MODE_SLEEP && state.Brightness == CF_BRIGHTNESS_IGNORE {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxFlowStates is the maximum number of states in single expression, longer expressions are rejected,
+// as they exceed size of request accepted by device. Use count of StartColorFlow for repeating states
+const MaxFlowStates = 64
+
+// NewFlowExpression creates FlowExpression from given states, 1 to MaxFlowStates states are required
+func NewFlowExpression(states ...FlowState) (FlowExpression, error) {
 	if len(states) == 0 {
-		return errors.New("flowExpression should have at least one FlowState, please pass one"), FlowExpression{}
+		return FlowExpression{}, errors.New("flowExpression should have at least one FlowState, please pass one")
+	}
+	if len(states) > MaxFlowStates {
+		return FlowExpression{}, fmt.Errorf("flowExpression can have at most %d states, got %d", MaxFlowStates, len(states))
+	}
+
+	for i, state := range states {
+		if err := state.validate(); err != nil {
+			return FlowExpression{}, fmt.Errorf("state %d: %v", i, err)
+		}
 	}
 
-	var flowStates []FlowState
+	flowStates := make([]FlowState, len(states))
+	copy(flowStates, states)
+
+	return FlowExpression{states: flowStates}, nil
+}
 
-	for _, state := range states {
-		flowStates = append(flowStates, state)
+// FlowBuilder allows to prepare FlowExpression step by step, first encountered error is
+// remembered and returned by Build, so calls can be chained safely
+// example:
+//   expression, err := yl.NewFlowBuilder().Color(0xff0000, 500*time.Millisecond, 100).Sleep(200*time.Millisecond).Repeat(3).Build()
+type FlowBuilder struct {
+	states []FlowState
+	err    error
+}
+
+func NewFlowBuilder() *FlowBuilder {
+	return &FlowBuilder{}
+}
+
+func (b *FlowBuilder) add(state FlowState, err error) *FlowBuilder {
+	if b.err != nil {
+		return b
 	}
+	if err != nil {
+		b.err = fmt.Errorf("step %d: %v", len(b.states), err)
+		return b
+	}
+	b.states = append(b.states, state)
+	return b
+}
+
+// Color appends color transition step, see ColorStep
+func (b *FlowBuilder) Color(rgb int, d time.Duration, brightness int) *FlowBuilder {
+	return b.add(ColorStep(rgb, d, brightness))
+}
+
+// Temperature appends temperature transition step, see TemperatureStep
+func (b *FlowBuilder) Temperature(temp int, d time.Duration, brightness int) *FlowBuilder {
+	return b.add(TemperatureStep(temp, d, brightness))
+}
+
+// Sleep appends sleep step, see SleepStep
+func (b *FlowBuilder) Sleep(d time.Duration) *FlowBuilder {
+	return b.add(SleepStep(d))
+}
 
-	return nil, FlowExpression{states: states}
+// Step appends already prepared FlowState
+func (b *FlowBuilder) Step(state FlowState) *FlowBuilder {
+	return b.add(state, state.validate())
+}
+
+// Repeat repeats all steps added so far, so they occur given amount of times in total.
+// Repeated steps count towards MaxFlowStates, count of StartColorFlow repeats whole expression instead
+func (b *FlowBuilder) Repeat(times int) *FlowBuilder {
+	if b.err != nil {
+		return b
+	}
+	if times < 1 {
+		b.err = errors.New("repeat times required to be >= 1")
+		return b
+	}
+	if len(b.states)*times > MaxFlowStates {
+		b.err = fmt.Errorf("repeating %d steps %d times exceeds %d states", len(b.states), times, MaxFlowStates)
+		return b
+	}
+
+	steps := make([]FlowState, len(b.states))
+	copy(steps, b.states)
+	for i := 1; i < times; i++ {
+		b.states = append(b.states, steps...)
+	}
+	return b
+}
+
+// Build returns prepared FlowExpression or first error encountered while building
+func (b *FlowBuilder) Build() (FlowExpression, error) {
+	if b.err != nil {
+		return FlowExpression{}, b.err
+	}
+	return NewFlowExpression(b.states...)
+}
+
+// States returns a copy of expression steps
+func (e *FlowExpression) States() []FlowState {
+	states := make([]FlowState, len(e.states))
+	copy(states, e.states)
+	return states
+}
+
+// Equal reports whether both expressions contains the same steps
+func (e *FlowExpression) Equal(other FlowExpression) bool {
+	if len(e.states) != len(other.states) {
+		return false
+	}
+	for i := range e.states {
+		if e.states[i] != other.states[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns expression in the same form as it's sent to the device
+func (e FlowExpression) String() string {
+	return e.encode()
+}
+
+// MarshalText implements encoding.TextMarshaler, expression is encoded in device "flow_expression" form
+func (e FlowExpression) MarshalText() ([]byte, error) {
+	return []byte(e.encode()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseFlowExpression. Empty text is decoded
+// into empty expression, as it's produced by MarshalText of zero FlowExpression
+func (e *FlowExpression) UnmarshalText(text []byte) error {
+	if len(strings.TrimSpace(string(text))) == 0 {
+		*e = FlowExpression{}
+		return nil
+	}
+	expression, err := ParseFlowExpression(string(text))
+	if err != nil {
+		return err
+	}
+	*e = expression
+	return nil
+}
+
+// ParseFlowExpression decodes comma separated flow expression
+// ("duration,mode,value,brightness,duration,mode,value,brightness...") back into FlowExpression
+func ParseFlowExpression(s string) (FlowExpression, error) {
+	values, err := parseIntList(s)
+	if err != nil {
+		return FlowExpression{}, err
+	}
+	if len(values)%4 != 0 {
+		return FlowExpression{}, fmt.Errorf("flow expression requires 4 values per state, got %d values", len(values))
+	}
+
+	var states []FlowState
+	for i := 0; i < len(values); i += 4 {
+		states = append(states, FlowState{
+			Duration:   values[i],
+			Mode:       CfMode(values[i+1]),
+			Value:      values[i+2],
+			Brightness: values[i+3],
+		})
+	}
+	return NewFlowExpression(states...)
+}
+
+// ParseFlowParams decodes value of PROP_FLOW_PARAMS and PROP_BG_FLOW_PARAMS properties.
+// Device reports running flow with leading count and action values ("count,action,flow_expression"),
+// values are returned separately, so they can be passed directly to StartColorFlow
+func ParseFlowParams(s string) (int, CfAction, FlowExpression, error) {
+	values := strings.SplitN(s, ",", 3)
+	if len(values) != 3 {
+		return 0, 0, FlowExpression{}, errors.New("flow params requires count, action and flow expression")
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(values[0]))
+	if err != nil {
+		return 0, 0, FlowExpression{}, fmt.Errorf("invalid count: %v", err)
+	}
+	action, err := strconv.Atoi(strings.TrimSpace(values[1]))
+	if err != nil {
+		return 0, 0, FlowExpression{}, fmt.Errorf("invalid action: %v", err)
+	}
+
+	expression, err := ParseFlowExpression(values[2])
+	if err != nil {
+		return 0, 0, FlowExpression{}, err
+	}
+	return count, CfAction(action), expression, nil
+}
+
+// parseIntList parses comma separated list of integers
+func parseIntList(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("empty flow expression")
+	}
+
+	var values []int
+	for i, field := range strings.Split(s, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %v", i, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
 }
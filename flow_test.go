@@ -0,0 +1,242 @@
+package yeelight
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// recorder is a commander recording sent commands
+type recorder struct {
+	commands []partialCommand
+}
+
+func (r *recorder) executeCommand(c partialCommand) error {
+	r.commands = append(r.commands, c)
+	return nil
+}
+
+func (r *recorder) queryCommand(c partialCommand) ([]string, error) {
+	r.commands = append(r.commands, c)
+	return []string{"ok"}, nil
+}
+
+func TestFlowSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		step  func() (FlowState, error)
+		valid bool
+	}{
+		{"color", func() (FlowState, error) { return ColorStep(0xff0000, 500*time.Millisecond, 100) }, true},
+		{"color ignoring brightness", func() (FlowState, error) {
+			return ColorStep(0xff0000, 500*time.Millisecond, CF_BRIGHTNESS_IGNORE)
+		}, true},
+		{"color out of range", func() (FlowState, error) { return ColorStep(0x1000000, time.Second, 100) }, false},
+		{"brightness zero", func() (FlowState, error) { return ColorStep(0xff0000, time.Second, 0) }, false},
+		{"brightness too high", func() (FlowState, error) { return ColorStep(0xff0000, time.Second, 101) }, false},
+		{"duration too short", func() (FlowState, error) { return ColorStep(0xff0000, 49*time.Millisecond, 100) }, false},
+		{"temperature", func() (FlowState, error) { return TemperatureStep(2700, time.Second, 50) }, true},
+		{"temperature too low", func() (FlowState, error) { return TemperatureStep(1600, time.Second, 50) }, false},
+		{"temperature too high", func() (FlowState, error) { return TemperatureStep(6600, time.Second, 50) }, false},
+		{"sleep", func() (FlowState, error) { return SleepStep(200 * time.Millisecond) }, true},
+		{"sleep too short", func() (FlowState, error) { return SleepStep(10 * time.Millisecond) }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.step()
+			if (err == nil) != test.valid {
+				t.Errorf("expected valid %v, got %v", test.valid, err)
+			}
+		})
+	}
+
+	if state, _ := SleepStep(200 * time.Millisecond); state.Value != 0 || state.Brightness != 0 {
+		t.Errorf("expected sleep step without value and brightness, got %+v", state)
+	}
+	if _, err := NewFlowExpression(FlowState{Duration: 100, Mode: 3, Value: 0, Brightness: 100}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if _, err := NewFlowExpression(); err == nil {
+		t.Error("expected error for expression without states")
+	}
+}
+
+func TestFlowBuilder(t *testing.T) {
+	expression, err := NewFlowBuilder().
+		Color(0xff0000, 500*time.Millisecond, 100).
+		Sleep(200 * time.Millisecond).
+		Repeat(3).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if states := expression.States(); len(states) != 6 || states[4] != states[0] || states[5] != states[1] {
+		t.Errorf("unexpected states: %+v", states)
+	}
+	if expression.Duration() != 2100*time.Millisecond {
+		t.Errorf("expected duration 2.1s, got %v", expression.Duration())
+	}
+
+	// the first error is returned, following steps are skipped
+	_, err = NewFlowBuilder().
+		Color(0xff0000, 500*time.Millisecond, 100).
+		Temperature(1000, 500*time.Millisecond, 100).
+		Sleep(time.Millisecond).
+		Build()
+	if err == nil || err.Error() != "step 1: value for temperature mode should be in 1700-6500 range" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewFlowBuilder().Sleep(time.Second).Repeat(0).Build(); err == nil {
+		t.Error("expected error for zero repeats")
+	}
+}
+
+func TestBrightnessIgnoreSupport(t *testing.T) {
+	expression, err := NewFlowBuilder().
+		Color(0xff0000, 500*time.Millisecond, CF_BRIGHTNESS_IGNORE).
+		Sleep(200 * time.Millisecond).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expression.IgnoresBrightness() {
+		t.Fatal("expected expression ignoring brightness")
+	}
+	sleep, _ := SleepStep(200 * time.Millisecond)
+	if other, _ := NewFlowExpression(sleep); other.IgnoresBrightness() {
+		t.Error("sleep step reported as ignoring brightness")
+	}
+
+	r := &recorder{}
+	bulb := NewBulb("127.0.0.1")
+	bulb.commonCommands.commander = r
+	bulb.Bg.commonCommands.commander = r
+
+	if err := bulb.StartColorFlow(CF_COUNT_INF, CF_ACTION_RECOVER, expression); err == nil {
+		t.Error("expected error for device without declared support")
+	}
+	if err := bulb.Bg.StartColorFlow(CF_COUNT_INF, CF_ACTION_RECOVER, expression); err == nil {
+		t.Error("expected error for background light without declared support")
+	}
+	if len(r.commands) != 0 {
+		t.Fatalf("rejected flows were sent: %v", r.commands)
+	}
+
+	bulb.SetBrightnessIgnoreSupport(true)
+	if err := bulb.StartColorFlow(CF_COUNT_INF, CF_ACTION_RECOVER, expression); err != nil {
+		t.Fatal(err)
+	}
+	if err := bulb.Bg.StartColorFlow(CF_COUNT_INF, CF_ACTION_RECOVER, expression); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.commands) != 2 || r.commands[0].Params[2] != "500,1,16711680,-1,200,7,0,0" || r.commands[1].Method != "bg_start_cf" {
+		t.Errorf("unexpected commands: %v", r.commands)
+	}
+}
+
+func TestParseFlowExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		valid      bool
+	}{
+		{"single state", "500,1,16711680,100", true},
+		{"spaces", " 500, 1, 16711680, 100 , 200,7,0,0", true},
+		{"empty", "", false},
+		{"not a number", "500,1,red,100", false},
+		{"missing value", "500,1,16711680", false},
+		{"extra value", "500,1,16711680,100,200", false},
+		{"trailing comma", "500,1,16711680,100,", false},
+		{"invalid mode", "500,3,16711680,100", false},
+		{"invalid duration", "10,1,16711680,100", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseFlowExpression(test.expression)
+			if (err == nil) != test.valid {
+				t.Errorf("expected valid %v, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestFlowExpressionRoundTrip(t *testing.T) {
+	expression, err := NewFlowBuilder().
+		Color(0x00ff00, time.Second, 50).
+		Sleep(300*time.Millisecond).
+		Temperature(2700, 2*time.Second, CF_BRIGHTNESS_IGNORE).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseFlowExpression(expression.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(expression) {
+		t.Errorf("expected %s, got %s", expression, parsed)
+	}
+
+	// text marshaling is used by encoding/json, empty expression is encoded as empty string
+	type saved struct {
+		Flow  FlowExpression
+		Empty FlowExpression
+	}
+	data, err := json.Marshal(saved{Flow: expression})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded saved
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Flow.Equal(expression) || len(decoded.Empty.States()) != 0 {
+		t.Errorf("unexpected round trip of %s: %+v", data, decoded)
+	}
+	if err := decoded.Flow.UnmarshalText([]byte("500,1")); err == nil {
+		t.Error("expected error for malformed text")
+	}
+}
+
+func TestParseFlowParams(t *testing.T) {
+	count, action, expression, err := ParseFlowParams("4,1,500,1,16711680,100,200,7,0,0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 || action != CF_ACTION_STAY || expression.String() != "500,1,16711680,100,200,7,0,0" {
+		t.Errorf("unexpected params: %d, %d, %s", count, action, expression)
+	}
+
+	for _, params := range []string{"", "4,1", "x,1,500,1,16711680,100", "4,y,500,1,16711680,100", "4,1,500,1"} {
+		if _, _, _, err := ParseFlowParams(params); err == nil {
+			t.Errorf("expected error for \"%s\"", params)
+		}
+	}
+}
+
+func TestFlowStatesLimit(t *testing.T) {
+	builder := NewFlowBuilder().
+		Color(0xff0000, 100*time.Millisecond, 100).
+		Color(0x0000ff, 100*time.Millisecond, 100)
+	if _, err := builder.Repeat(MaxFlowStates / 2).Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewFlowBuilder().
+		Color(0xff0000, 100*time.Millisecond, 100).
+		Color(0x0000ff, 100*time.Millisecond, 100).
+		Repeat(MaxFlowStates/2 + 1).
+		Build()
+	if err == nil {
+		t.Error("expected error for too many repeated states")
+	}
+
+	states := make([]FlowState, MaxFlowStates+1)
+	for i := range states {
+		states[i], _ = SleepStep(100 * time.Millisecond)
+	}
+	if _, err := NewFlowExpression(states...); err == nil {
+		t.Error("expected error for too many states")
+	}
+}
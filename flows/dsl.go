@@ -0,0 +1,487 @@
+package flows
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// Flow text format, one statement per line, "#" at the beginning of line or "#" surrounded by whitespace
+// starts a comment (colors like "#ff8800" are not separated from their value):
+//
+//   # police lights
+//   repeat 0            # whole sequence repetitions, 0 means infinite loop (default)
+//   end recover         # action after flow stops: recover (default), stay or off
+//   color #ff0000 300ms 100
+//   sleep 100ms
+//   color #0000ff 300ms 100%
+//   color 3000K 1s keep # temperature step, "keep" leaves brightness unchanged
+//   color orange 1s 50  # CSS color names are accepted too
+//
+// "count N" may be used instead of "repeat" for setting raw state changes count.
+// The same flow in JSON format:
+//
+//   {
+//     "repeat": 0,
+//     "end": "recover",
+//     "steps": [
+//       {"color": "#ff0000", "duration": "300ms", "brightness": 100},
+//       {"sleep": "100ms"},
+//       {"color": "3000K", "duration": "1s", "brightness": "keep"}
+//     ]
+//   }
+
+// SyntaxError describes invalid statement in flow file
+type SyntaxError struct {
+	Line int // 1-based line number, 0 when unknown
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+var endActions = map[string]yl.CfAction{
+	"recover": yl.CF_ACTION_RECOVER,
+	"stay":    yl.CF_ACTION_STAY,
+	"off":     yl.CF_ACTION_POWEROFF,
+}
+
+func endActionName(action yl.CfAction) (string, error) {
+	for name, a := range endActions {
+		if a == action {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown action %d", action)
+}
+
+// parseStepColor parses color accepted by yl.ParseColor, temperature ("3000K") results in temperature step
+func parseStepColor(s string) (yl.CfMode, int, error) {
+	color, err := yl.ParseColor(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	if color.IsTemperature() {
+		return yl.CF_MODE_TEMP, color.Kelvin(), nil
+	}
+	return yl.CF_MODE_COLOR, color.RGB(), nil
+}
+
+func formatStepColor(state yl.FlowState) string {
+	if state.Mode == yl.CF_MODE_TEMP {
+		return fmt.Sprintf("%dK", state.Value)
+	}
+	return fmt.Sprintf("#%06x", state.Value)
+}
+
+// parseBrightness parses "80", "80%" or "keep"
+func parseBrightness(s string) (int, error) {
+	if s == "keep" {
+		return yl.CF_BRIGHTNESS_IGNORE, nil
+	}
+	brightness, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid brightness \"%s\"", s)
+	}
+	return brightness, nil
+}
+
+func formatBrightness(brightness int) string {
+	if brightness == yl.CF_BRIGHTNESS_IGNORE {
+		return "keep"
+	}
+	return strconv.Itoa(brightness)
+}
+
+// newStep creates validated FlowState from textual step values
+func newStep(color, duration, brightness string) (yl.FlowState, error) {
+	mode, value, err := parseStepColor(color)
+	if err != nil {
+		return yl.FlowState{}, err
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return yl.FlowState{}, err
+	}
+	b := 100
+	if brightness != "" {
+		b, err = parseBrightness(brightness)
+		if err != nil {
+			return yl.FlowState{}, err
+		}
+	}
+
+	if mode == yl.CF_MODE_TEMP {
+		return yl.TemperatureStep(value, d, b)
+	}
+	return yl.ColorStep(value, d, b)
+}
+
+func newSleepStep(duration string) (yl.FlowState, error) {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return yl.FlowState{}, err
+	}
+	return yl.SleepStep(d)
+}
+
+// flowHeader holds flow settings shared by text and JSON formats
+type flowHeader struct {
+	repeat, count int
+	countSet      bool
+	action        yl.CfAction
+}
+
+func (h flowHeader) build(states []yl.FlowState) (Flow, error) {
+	expression, err := yl.NewFlowExpression(states...)
+	if err != nil {
+		return Flow{}, err
+	}
+
+	count := h.repeat * len(states)
+	if h.countSet {
+		count = h.count
+	}
+	return Flow{count, h.action, expression}, nil
+}
+
+// ParseText reads flow in text format
+func ParseText(r io.Reader) (Flow, error) {
+	var (
+		header = flowHeader{action: yl.CF_ACTION_RECOVER}
+		states []yl.FlowState
+		line   int
+	)
+
+	fail := func(format string, a ...interface{}) (Flow, error) {
+		return Flow{}, &SyntaxError{line, fmt.Sprintf(format, a...)}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		keyword, args := strings.ToLower(fields[0]), fields[1:]
+		switch keyword {
+		case "repeat", "count":
+			if len(args) != 1 {
+				return fail("%s requires exactly one value", keyword)
+			}
+			value, err := strconv.Atoi(args[0])
+			if err != nil || value < 0 {
+				return fail("%s requires non-negative integer, got \"%s\"", keyword, args[0])
+			}
+			if keyword == "repeat" {
+				header.repeat = value
+			} else {
+				header.count, header.countSet = value, true
+			}
+		case "end":
+			if len(args) != 1 {
+				return fail("end requires exactly one value")
+			}
+			action, ok := endActions[strings.ToLower(args[0])]
+			if !ok {
+				return fail("unknown end action \"%s\", expected recover, stay or off", args[0])
+			}
+			header.action = action
+		case "color":
+			if len(args) < 2 || len(args) > 3 {
+				return fail("color requires color, duration and optional brightness")
+			}
+			var brightness string
+			if len(args) == 3 {
+				brightness = args[2]
+			}
+			state, err := newStep(args[0], args[1], brightness)
+			if err != nil {
+				return fail("%v", err)
+			}
+			states = append(states, state)
+		case "sleep":
+			if len(args) != 1 {
+				return fail("sleep requires exactly one duration")
+			}
+			state, err := newSleepStep(args[0])
+			if err != nil {
+				return fail("%v", err)
+			}
+			states = append(states, state)
+		default:
+			return fail("unknown statement \"%s\"", fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Flow{}, err
+	}
+
+	if len(states) == 0 {
+		return Flow{}, &SyntaxError{Msg: "flow requires at least one color or sleep step"}
+	}
+	return header.build(states)
+}
+
+// stripComment removes comment from given line, comment starts with "#" at the beginning of line
+// or with "#" separated by whitespace from both sides, so "#ff8800" is always a color
+func stripComment(line string) string {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return ""
+	}
+	for i := 1; i < len(line); i++ {
+		if line[i] != '#' || !isSpace(line[i-1]) {
+			continue
+		}
+		if i+1 == len(line) || isSpace(line[i+1]) {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// FormatText encodes flow in text format
+func FormatText(f Flow) ([]byte, error) {
+	var buf bytes.Buffer
+
+	states := f.Expression.States()
+	if len(states) == 0 {
+		return nil, errors.New("flow expression is empty")
+	}
+	action, err := endActionName(f.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Count%len(states) == 0 {
+		fmt.Fprintf(&buf, "repeat %d\n", f.Count/len(states))
+	} else {
+		fmt.Fprintf(&buf, "count %d\n", f.Count)
+	}
+	fmt.Fprintf(&buf, "end %s\n", action)
+
+	for _, state := range states {
+		if state.Mode == yl.CF_MODE_SLEEP {
+			fmt.Fprintf(&buf, "sleep %s\n", state.Length().String())
+			continue
+		}
+		fmt.Fprintf(&buf, "color %s %s %s\n",
+			formatStepColor(state), state.Length().String(), formatBrightness(state.Brightness))
+	}
+	return buf.Bytes(), nil
+}
+
+type jsonStep struct {
+	Color      string          `json:"color,omitempty"`
+	Sleep      string          `json:"sleep,omitempty"`
+	Duration   string          `json:"duration,omitempty"`
+	Brightness json.RawMessage `json:"brightness,omitempty"`
+}
+
+type jsonFlow struct {
+	Repeat *int       `json:"repeat,omitempty"`
+	Count  *int       `json:"count,omitempty"`
+	End    string     `json:"end,omitempty"`
+	Steps  []jsonStep `json:"steps"`
+}
+
+// ParseJSON reads flow in JSON format
+func ParseJSON(data []byte) (Flow, error) {
+	var doc jsonFlow
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		switch e := err.(type) {
+		case *json.SyntaxError:
+			return Flow{}, &SyntaxError{lineOf(data, e.Offset), e.Error()}
+		case *json.UnmarshalTypeError:
+			return Flow{}, &SyntaxError{lineOf(data, e.Offset), e.Error()}
+		}
+		return Flow{}, &SyntaxError{Msg: err.Error()}
+	}
+
+	header := flowHeader{action: yl.CF_ACTION_RECOVER}
+	if doc.Repeat != nil {
+		header.repeat = *doc.Repeat
+	}
+	if doc.Count != nil {
+		header.count, header.countSet = *doc.Count, true
+	}
+	if header.repeat < 0 || header.count < 0 {
+		return Flow{}, &SyntaxError{Msg: "repeat and count requires non-negative integer"}
+	}
+	if doc.End != "" {
+		action, ok := endActions[strings.ToLower(doc.End)]
+		if !ok {
+			return Flow{}, &SyntaxError{Msg: fmt.Sprintf("unknown end action \"%s\", expected recover, stay or off", doc.End)}
+		}
+		header.action = action
+	}
+
+	if len(doc.Steps) == 0 {
+		return Flow{}, &SyntaxError{Msg: "flow requires at least one color or sleep step"}
+	}
+
+	var (
+		states  []yl.FlowState
+		offsets = stepOffsets(data)
+	)
+	for i, step := range doc.Steps {
+		var (
+			state yl.FlowState
+			err   error
+		)
+		switch {
+		case step.Sleep != "" && step.Color == "":
+			state, err = newSleepStep(step.Sleep)
+		case step.Color != "" && step.Sleep == "":
+			var brightness string
+			if len(step.Brightness) > 0 {
+				brightness = strings.Trim(string(step.Brightness), "\"")
+			}
+			state, err = newStep(step.Color, step.Duration, brightness)
+		default:
+			err = errors.New("step requires either color or sleep")
+		}
+		if err != nil {
+			var line int
+			if i < len(offsets) {
+				line = lineOf(data, offsets[i])
+			}
+			return Flow{}, &SyntaxError{line, fmt.Sprintf("step %d: %v", i, err)}
+		}
+		states = append(states, state)
+	}
+	return header.build(states)
+}
+
+// lineOf returns line number of given byte offset
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte{'\n'}) + 1
+}
+
+// stepOffsets returns byte offsets of elements of top-level "steps" array, data is expected to be valid JSON
+func stepOffsets(data []byte) []int64 {
+	var (
+		offsets []int64
+		depth   int
+		key     string // the last string of top-level object, it's a key when followed by array
+		inSteps bool
+		next    bool // next value of steps array starts new element
+	)
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inSteps && depth == 2 && next && !strings.ContainsRune(" \t\r\n]", rune(c)) {
+			offsets = append(offsets, int64(i))
+			next = false
+		}
+
+		switch c {
+		case '"':
+			start := i + 1
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			if depth == 1 {
+				key = string(data[start:i])
+			}
+		case '{', '[':
+			depth++
+			// keys are matched case-insensitively by json.Unmarshal, the last "steps" key wins
+			if c == '[' && depth == 2 && strings.EqualFold(key, "steps") {
+				offsets, inSteps, next = nil, true, true
+			}
+		case '}', ']':
+			if depth == 2 {
+				inSteps = false
+			}
+			depth--
+		case ',':
+			if inSteps && depth == 2 {
+				next = true
+			}
+		}
+	}
+	return offsets
+}
+
+// FormatJSON encodes flow in JSON format
+func FormatJSON(f Flow) ([]byte, error) {
+	states := f.Expression.States()
+	if len(states) == 0 {
+		return nil, errors.New("flow expression is empty")
+	}
+	action, err := endActionName(f.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := jsonFlow{End: action}
+	if f.Count%len(states) == 0 {
+		repeat := f.Count / len(states)
+		doc.Repeat = &repeat
+	} else {
+		count := f.Count
+		doc.Count = &count
+	}
+
+	for _, state := range states {
+		if state.Mode == yl.CF_MODE_SLEEP {
+			doc.Steps = append(doc.Steps, jsonStep{Sleep: state.Length().String()})
+			continue
+		}
+		brightness, _ := json.Marshal(state.Brightness)
+		if state.Brightness == yl.CF_BRIGHTNESS_IGNORE {
+			brightness = []byte(`"keep"`)
+		}
+		doc.Steps = append(doc.Steps, jsonStep{
+			Color:      formatStepColor(state),
+			Duration:   state.Length().String(),
+			Brightness: brightness,
+		})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Load reads flow from file, JSON format is used for ".json" files, text format otherwise
+func Load(path string) (Flow, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Flow{}, err
+	}
+
+	var flow Flow
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		flow, err = ParseJSON(data)
+	} else {
+		flow, err = ParseText(bytes.NewReader(data))
+	}
+	if err != nil {
+		return Flow{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return flow, nil
+}
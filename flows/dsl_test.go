@@ -0,0 +1,146 @@
+package flows
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+func TestParseJSONErrorLine(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+	}{
+		{"syntax", "{\n  \"steps\": [\n    {\"sleep\": \"100ms\"},\n  ]\n}", 4},
+		{"type", "{\n  \"repeat\": 1,\n  \"steps\": [\n    {\"sleep\": 100}\n  ]\n}", 4},
+		{"step", "{\n  \"steps\": [\n    {\"sleep\": \"100ms\"},\n    {\"color\": \"#ff0000\",\n     \"duration\": \"10ms\"}\n  ]\n}", 4},
+		{"step escaped key", "{\"end\": \"stay\", \"x\\\"\": [1],\n\"Steps\": [{\"sleep\": \"1s\"},\n{}]}", 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseJSON([]byte(test.data))
+			syntaxErr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got %v", err)
+			}
+			if syntaxErr.Line != test.line {
+				t.Errorf("expected line %d, got %d (%v)", test.line, syntaxErr.Line, err)
+			}
+		})
+	}
+}
+
+func TestParseText(t *testing.T) {
+	text := `# police lights
+repeat 2            # whole sequence repetitions
+end stay
+color #ff0000 300ms 100
+	sleep 100ms # #00ff00 in comment isn't a color
+color #00f 300ms 50%
+color 3000K 1s keep #
+color orange 1s
+`
+	f, err := ParseText(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "300,1,16711680,100,100,7,0,0,300,1,255,50,1000,2,3000,-1,1000,1,16753920,100"
+	if f.Expression.String() != expected || f.Count != 10 || f.Action != yl.CF_ACTION_STAY {
+		t.Errorf("unexpected flow: count %d, action %d, %s", f.Count, f.Action, f.Expression)
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"# comment", ""},
+		{"  #ff0000 commented out step", ""},
+		{"color #ff0000 1s", "color #ff0000 1s"},
+		{"color #ff0000 1s # comment", "color #ff0000 1s "},
+		{"color #ff0000 1s\t#\tcomment #00ff00", "color #ff0000 1s\t"},
+		{"color #ff0000 1s #", "color #ff0000 1s "},
+		{"color red 1s #abcdef", "color red 1s #abcdef"},
+	}
+	for _, test := range tests {
+		if result := stripComment(test.line); result != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.line, test.expected, result)
+		}
+	}
+}
+
+func TestParseTextErrorLine(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		line int
+	}{
+		{"unknown statement", "repeat 1\n\nblink 1s\n", 3},
+		{"invalid repeat", "repeat -1\n", 1},
+		{"invalid end", "# comment\nend never\n", 2},
+		{"missing duration", "color #ff0000\n", 1},
+		{"invalid color", "sleep 1s\ncolor #ff00 1s\n", 2},
+		{"invalid brightness", "color red 1s 101\n", 1},
+		{"short duration", "color red 1s\nsleep 10ms\n", 2},
+		{"hex color after statement", "sleep 1s #ff0000\n", 1},
+		{"without steps", "repeat 1\n", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseText(strings.NewReader(test.text))
+			syntaxErr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got %v", err)
+			}
+			if syntaxErr.Line != test.line {
+				t.Errorf("expected line %d, got %d (%v)", test.line, syntaxErr.Line, err)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	expression, err := yl.NewFlowBuilder().
+		Color(0xff8800, 300*time.Millisecond, 100).
+		Sleep(100*time.Millisecond).
+		Temperature(2700, 1500*time.Millisecond, yl.CF_BRIGHTNESS_IGNORE).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []Flow{
+		{Count: 6, Action: yl.CF_ACTION_POWEROFF, Expression: expression},
+		{Count: 4, Action: yl.CF_ACTION_RECOVER, Expression: expression},
+		{Count: yl.CF_COUNT_INF, Action: yl.CF_ACTION_STAY, Expression: expression},
+	} {
+		text, err := FormatText(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseText(bytes.NewReader(text))
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if parsed.Count != f.Count || parsed.Action != f.Action || !parsed.Expression.Equal(f.Expression) {
+			t.Errorf("text round trip changed flow:\n%s", text)
+		}
+
+		data, err := FormatJSON(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err = ParseJSON(data)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if parsed.Count != f.Count || parsed.Action != f.Action || !parsed.Expression.Equal(f.Expression) {
+			t.Errorf("JSON round trip changed flow:\n%s", data)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package flows contains ready to use color flow presets built on yeelight.FlowExpression
+package flows
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// Flow bundles FlowExpression with remaining StartColorFlow parameters
+type Flow struct {
+	Count      int         // total number of visible state changes, yl.CF_COUNT_INF for infinite loop
+	Action     yl.CfAction // action taken after flow stops
+	Expression yl.FlowExpression
+}
+
+type flowStarter interface {
+	StartColorFlow(count int, action yl.CfAction, flowExpression yl.FlowExpression) error
+}
+
+// Start starts flow on given device, for instance *yl.Bulb or its background light
+func (f Flow) Start(device flowStarter) error {
+	return device.StartColorFlow(f.Count, f.Action, f.Expression)
+}
+
+func newFlow(count int, action yl.CfAction, builder *yl.FlowBuilder) (Flow, error) {
+	expression, err := builder.Build()
+	if err != nil {
+		return Flow{}, err
+	}
+	return Flow{count, action, expression}, nil
+}
+
+// Candle imitates flickering candle light with warm temperature and shifting brightness
+func Candle() (Flow, error) {
+	return newFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, yl.NewFlowBuilder().
+		Temperature(1700, 800*time.Millisecond, 50).
+		Temperature(1800, 400*time.Millisecond, 30).
+		Temperature(1700, 1200*time.Millisecond, 45).
+		Temperature(1750, 300*time.Millisecond, 25).
+		Temperature(1700, 600*time.Millisecond, 40).
+		Temperature(1850, 900*time.Millisecond, 50))
+}
+
+// Police flashes red and blue alternately
+func Police() (Flow, error) {
+	return newFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, yl.NewFlowBuilder().
+		Color(0xff0000, 300*time.Millisecond, 100).
+		Sleep(100*time.Millisecond).
+		Color(0x0000ff, 300*time.Millisecond, 100).
+		Sleep(100*time.Millisecond))
+}
+
+// Disco quickly jumps between saturated colors
+func Disco() (Flow, error) {
+	return newFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, yl.NewFlowBuilder().
+		Color(0xff0000, 200*time.Millisecond, 100).
+		Color(0xffff00, 200*time.Millisecond, 100).
+		Color(0x00ff00, 200*time.Millisecond, 100).
+		Color(0x00ffff, 200*time.Millisecond, 100).
+		Color(0x0000ff, 200*time.Millisecond, 100).
+		Color(0xff00ff, 200*time.Millisecond, 100))
+}
+
+// Sunrise slowly wakes device up from dim warm light to bright cold light within given amount of minutes.
+// Device stays in final state after flow is finished
+func Sunrise(minutes int) (Flow, error) {
+	if minutes < 1 {
+		return Flow{}, errors.New("minutes required to be >= 1")
+	}
+
+	step := time.Duration(minutes) * time.Minute / 3
+	return newFlow(4, yl.CF_ACTION_STAY, yl.NewFlowBuilder().
+		Temperature(1700, 50*time.Millisecond, 1).
+		Temperature(2100, step, 10).
+		Temperature(3200, step, 60).
+		Temperature(5000, step, 100))
+}
+
+// Sunset slowly dims device to warm light within given amount of minutes, device is turned off at the end
+func Sunset(minutes int) (Flow, error) {
+	if minutes < 1 {
+		return Flow{}, errors.New("minutes required to be >= 1")
+	}
+
+	step := time.Duration(minutes) * time.Minute / 3
+	return newFlow(3, yl.CF_ACTION_POWEROFF, yl.NewFlowBuilder().
+		Temperature(3200, step, 60).
+		Temperature(2100, step, 10).
+		Temperature(1700, step, 1))
+}
+
+// Pulse quickly fades given color in and out, period is time of one pulse (minimum 100ms)
+func Pulse(rgb int, period time.Duration) (Flow, error) {
+	return newFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, yl.NewFlowBuilder().
+		Color(rgb, period/2, 100).
+		Color(rgb, period/2, 1))
+}
+
+// Breathe slowly fades given color in and out with short holds on both ends,
+// period is time of one breath (minimum 500ms)
+func Breathe(rgb int, period time.Duration) (Flow, error) {
+	if period < 500*time.Millisecond {
+		return Flow{}, errors.New("period required to be >= 500ms")
+	}
+
+	transition := period * 2 / 5
+	hold := period / 10
+	return newFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, yl.NewFlowBuilder().
+		Color(rgb, transition, 100).
+		Sleep(hold).
+		Color(rgb, transition, 1).
+		Sleep(hold))
+}
+
+// Alarm rapidly flashes bright red
+func Alarm() (Flow, error) {
+	return newFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, yl.NewFlowBuilder().
+		Color(0xff0000, 50*time.Millisecond, 100).
+		Sleep(200*time.Millisecond).
+		Color(0xff0000, 50*time.Millisecond, 1).
+		Sleep(200*time.Millisecond))
+}
+
+// TemperatureCycle smoothly goes back and forth between given temperatures,
+// period is time of full cycle (minimum 100ms)
+func TemperatureCycle(from, to int, period time.Duration) (Flow, error) {
+	return newFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, yl.NewFlowBuilder().
+		Temperature(from, period/2, 100).
+		Temperature(to, period/2, 100))
+}
+
+// NotificationBlink blinks given color given amount of times and recovers previous device state,
+// it's the same flow as played by yl.BlinkPattern notification
+func NotificationBlink(rgb, times int) (Flow, error) {
+	count, expression, err := yl.BlinkPattern(rgb, times).Flow()
+	if err != nil {
+		return Flow{}, err
+	}
+	return Flow{count, yl.CF_ACTION_RECOVER, expression}, nil
+}
+
+// presets contains parameterless presets with default values, available by name
+var presets = map[string]func() (Flow, error){
+	"candle":  Candle,
+	"police":  Police,
+	"disco":   Disco,
+	"alarm":   Alarm,
+	"sunrise": func() (Flow, error) { return Sunrise(30) },
+	"sunset":  func() (Flow, error) { return Sunset(30) },
+	"pulse":   func() (Flow, error) { return Pulse(0xffffff, time.Second) },
+	"breathe": func() (Flow, error) { return Breathe(0x0000ff, 4*time.Second) },
+	"temperature_cycle": func() (Flow, error) {
+		return TemperatureCycle(2700, 6500, 10*time.Second)
+	},
+	"notification": func() (Flow, error) { return NotificationBlink(0xffffff, 3) },
+}
+
+// Preset returns preset with default parameters by name, see Names for available presets
+func Preset(name string) (Flow, error) {
+	preset, ok := presets[name]
+	if !ok {
+		return Flow{}, fmt.Errorf("preset \"%s\" not found", name)
+	}
+	return preset()
+}
+
+// Names returns sorted names of presets available by Preset function
+func Names() []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,88 @@
+package flows
+
+import (
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+func TestPresets(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			f, err := Preset(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			states := f.Expression.States()
+			if len(states) == 0 || len(states) > yl.MaxFlowStates {
+				t.Errorf("unexpected number of states: %d", len(states))
+			}
+			if _, err := yl.NewFlowExpression(states...); err != nil {
+				t.Errorf("invalid expression: %v", err)
+			}
+			if f.Count != yl.CF_COUNT_INF && f.Count%len(states) != 0 {
+				t.Errorf("count %d doesn't cover whole expression of %d states", f.Count, len(states))
+			}
+		})
+	}
+	if _, err := Preset("rainbow"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
+
+func TestParameters(t *testing.T) {
+	tests := []struct {
+		name  string
+		flow  func() (Flow, error)
+		valid bool
+	}{
+		{"sunrise", func() (Flow, error) { return Sunrise(1) }, true},
+		{"sunrise without minutes", func() (Flow, error) { return Sunrise(0) }, false},
+		{"sunset without minutes", func() (Flow, error) { return Sunset(0) }, false},
+		{"pulse", func() (Flow, error) { return Pulse(0xff0000, 100*time.Millisecond) }, true},
+		{"pulse too short", func() (Flow, error) { return Pulse(0xff0000, 90*time.Millisecond) }, false},
+		{"pulse invalid color", func() (Flow, error) { return Pulse(0x1000000, time.Second) }, false},
+		{"breathe too short", func() (Flow, error) { return Breathe(0xff0000, 400*time.Millisecond) }, false},
+		{"temperature cycle out of range", func() (Flow, error) { return TemperatureCycle(1000, 6500, time.Second) }, false},
+		{"blink without times", func() (Flow, error) { return NotificationBlink(0xff0000, 0) }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.flow(); (err == nil) != test.valid {
+				t.Errorf("expected valid %v, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestSunrise(t *testing.T) {
+	f, err := Sunrise(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := f.Expression.States()
+	last := states[len(states)-1]
+	if f.Count != len(states) || f.Action != yl.CF_ACTION_STAY || last.Value != 5000 || last.Brightness != 100 {
+		t.Errorf("expected single run ending at 5000K and full brightness, got %d: %v", f.Count, f.Expression)
+	}
+	if f.Expression.Duration() < 30*time.Minute || f.Expression.Duration() > 31*time.Minute {
+		t.Errorf("expected 30 minutes long flow, got %v", f.Expression.Duration())
+	}
+}
+
+func TestNotificationBlink(t *testing.T) {
+	f, err := NotificationBlink(0x00ff00, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// single blink is repeated by count, not by repeating states
+	if len(f.Expression.States()) != 4 || f.Count != 20 || f.Action != yl.CF_ACTION_RECOVER {
+		t.Errorf("unexpected flow: count %d, %v", f.Count, f.Expression)
+	}
+
+	// blinking for a long time doesn't exceed device limits
+	if _, err := NotificationBlink(0x00ff00, 100); err != nil {
+		t.Error(err)
+	}
+}
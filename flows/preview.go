@@ -0,0 +1,170 @@
+package flows
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// Sample describes simulated device output at given moment of flow
+type Sample struct {
+	At         time.Duration
+	RGB        int // 0xRRGGBB, temperature steps are converted to approximated color
+	Brightness int // 1-100, 0 when device is turned off
+}
+
+// Color returns sample color with applied brightness, useful for displaying previews
+func (s Sample) Color() color.RGBA {
+	scale := float64(s.Brightness) / 100
+	return color.RGBA{
+		R: uint8(math.Round(float64(s.RGB>>16&0xff) * scale)),
+		G: uint8(math.Round(float64(s.RGB>>8&0xff) * scale)),
+		B: uint8(math.Round(float64(s.RGB&0xff) * scale)),
+		A: 0xff,
+	}
+}
+
+// Simulate renders flow over time, returning device output sampled every step.
+// Flow starts from the state of its last step (as looping flows do), transitions are interpolated linearly,
+// like smooth transitions performed by device. Simulation stops after flow is finished or limit is reached,
+// limit is required for flows with infinite count. The last sample of finished flow is device output after
+// flow action: the initial output for CF_ACTION_RECOVER, turned off device for CF_ACTION_POWEROFF
+func Simulate(f Flow, step, limit time.Duration) ([]Sample, error) {
+	if step <= 0 {
+		return nil, errors.New("step required to be > 0")
+	}
+	states := f.Expression.States()
+	if len(states) == 0 {
+		return nil, errors.New("flow expression is empty")
+	}
+	if f.Count == yl.CF_COUNT_INF && limit <= 0 {
+		return nil, errors.New("limit is required for infinite flows")
+	}
+
+	// initial output is the final output of one full flow cycle
+	var initial Sample
+	initial.Brightness = 100
+	for _, state := range states {
+		initial = target(initial, state)
+	}
+
+	var (
+		samples []Sample
+		start   time.Duration // beginning of current transition
+		from    = initial
+		at      time.Duration
+		i       int
+	)
+	for {
+		if f.Count != yl.CF_COUNT_INF && i >= f.Count {
+			break
+		}
+		state := states[i%len(states)]
+		duration := time.Duration(state.Duration) * time.Millisecond
+		to := target(from, state)
+
+		for ; at < start+duration; at += step {
+			if limit > 0 && at > limit {
+				return samples, nil
+			}
+			progress := float64(at-start) / float64(duration)
+			samples = append(samples, interpolate(from, to, progress, at))
+		}
+
+		start += duration
+		from = to
+		i++
+	}
+
+	if limit <= 0 || at <= limit {
+		end := from
+		switch f.Action {
+		case yl.CF_ACTION_RECOVER:
+			end = initial
+		case yl.CF_ACTION_POWEROFF:
+			end.Brightness = 0
+		}
+		end.At = at
+		samples = append(samples, end)
+	}
+	return samples, nil
+}
+
+// target returns device output after given state transition is finished
+func target(from Sample, state yl.FlowState) Sample {
+	to := from
+	switch state.Mode {
+	case yl.CF_MODE_COLOR:
+		to.RGB = state.Value
+	case yl.CF_MODE_TEMP:
+		to.RGB = yl.KelvinToRGB(state.Value)
+	case yl.CF_MODE_SLEEP:
+		return to
+	}
+	if state.Brightness != yl.CF_BRIGHTNESS_IGNORE {
+		to.Brightness = state.Brightness
+	}
+	return to
+}
+
+func interpolate(from, to Sample, progress float64, at time.Duration) Sample {
+	lerp := func(a, b int) int {
+		return int(math.Round(float64(a) + float64(b-a)*progress))
+	}
+	return Sample{
+		At:         at,
+		RGB:        lerp(from.RGB>>16&0xff, to.RGB>>16&0xff)<<16 | lerp(from.RGB>>8&0xff, to.RGB>>8&0xff)<<8 | lerp(from.RGB&0xff, to.RGB&0xff),
+		Brightness: lerp(from.Brightness, to.Brightness),
+	}
+}
+
+// WriteGIF encodes samples as animated GIF of given size, frames are displayed in real time
+func WriteGIF(w io.Writer, samples []Sample, width, height int) error {
+	if len(samples) == 0 {
+		return errors.New("no samples to render")
+	}
+
+	var (
+		animation = &gif.GIF{}
+		shown     int // hundredths of second displayed so far
+	)
+	for i, sample := range samples {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{sample.Color()})
+
+		// delay is counted in hundredths of second, frames end at rounded sample times,
+		// so rounding errors don't accumulate. Zero delay is displayed differently by viewers
+		delay := 1
+		if i+1 < len(samples) {
+			end := int(math.Round(float64(samples[i+1].At-samples[0].At) / float64(10*time.Millisecond)))
+			if end-shown > 1 {
+				delay = end - shown
+			}
+		}
+		shown += delay
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, delay)
+	}
+	return gif.EncodeAll(w, animation)
+}
+
+// WritePNGStrip encodes samples as PNG image of horizontally placed cells, one cell per sample
+func WritePNGStrip(w io.Writer, samples []Sample, cellWidth, height int) error {
+	if len(samples) == 0 {
+		return errors.New("no samples to render")
+	}
+
+	strip := image.NewRGBA(image.Rect(0, 0, cellWidth*len(samples), height))
+	for i, sample := range samples {
+		cell := image.Rect(i*cellWidth, 0, (i+1)*cellWidth, height)
+		draw.Draw(strip, cell, &image.Uniform{C: sample.Color()}, image.Point{}, draw.Src)
+	}
+	return png.Encode(w, strip)
+}
@@ -0,0 +1,124 @@
+package flows
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"reflect"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+func TestWriteGIFDelays(t *testing.T) {
+	var samples []Sample
+	for i := 0; i < 100; i++ {
+		samples = append(samples, Sample{At: time.Duration(i) * 15 * time.Millisecond, RGB: 0xff0000, Brightness: 100})
+	}
+	samples = append(samples, Sample{At: 100*15*time.Millisecond + 4*time.Millisecond})
+
+	var buf bytes.Buffer
+	if err := WriteGIF(&buf, samples, 4, 4); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for i, delay := range decoded.Delay[:len(decoded.Delay)-1] {
+		if delay < 1 {
+			t.Fatalf("frame %d: zero delay", i)
+		}
+		total += delay
+	}
+	// 100 frames of 15ms, truncation would display 100 frames of 10ms
+	if total != 150 {
+		t.Errorf("expected frames displayed for 150 hundredths of second, got %d", total)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	expression, err := yl.NewFlowBuilder().
+		Color(0xff0000, 100*time.Millisecond, 100).
+		Sleep(100*time.Millisecond).
+		Color(0x0000ff, 100*time.Millisecond, 50).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := Simulate(Flow{Count: 3, Action: yl.CF_ACTION_STAY, Expression: expression}, 50*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flow starts from the output of its last step, every step boundary starts the next transition
+	expected := []Sample{
+		{0, 0x0000ff, 50},
+		{50 * time.Millisecond, 0x800080, 75},
+		{100 * time.Millisecond, 0xff0000, 100},
+		{150 * time.Millisecond, 0xff0000, 100},
+		{200 * time.Millisecond, 0xff0000, 100},
+		{250 * time.Millisecond, 0x800080, 75},
+		{300 * time.Millisecond, 0x0000ff, 50},
+	}
+	if !reflect.DeepEqual(samples, expected) {
+		t.Errorf("expected %v, got %v", expected, samples)
+	}
+}
+
+func TestSimulateActions(t *testing.T) {
+	expression, err := yl.NewFlowBuilder().
+		Color(0xff0000, 100*time.Millisecond, 100).
+		Temperature(1700, 100*time.Millisecond, yl.CF_BRIGHTNESS_IGNORE).
+		Color(0x00ff00, 100*time.Millisecond, 20).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		action yl.CfAction
+		end    Sample
+	}{
+		{yl.CF_ACTION_STAY, Sample{200 * time.Millisecond, yl.KelvinToRGB(1700), 100}},
+		{yl.CF_ACTION_RECOVER, Sample{200 * time.Millisecond, 0x00ff00, 20}},
+		{yl.CF_ACTION_POWEROFF, Sample{200 * time.Millisecond, yl.KelvinToRGB(1700), 0}},
+	}
+	for _, test := range tests {
+		// two of three states, temperature step keeps brightness of the first one
+		samples, err := Simulate(Flow{Count: 2, Action: test.action, Expression: expression}, 100*time.Millisecond, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) != 3 || samples[len(samples)-1] != test.end {
+			t.Errorf("action %d: expected %v at the end, got %v", test.action, test.end, samples)
+		}
+	}
+	if (Sample{RGB: 0xffffff}).Color() != (color.RGBA{A: 0xff}) {
+		t.Error("expected black color of turned off device")
+	}
+}
+
+func TestSimulateLimit(t *testing.T) {
+	f, err := Police()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Simulate(f, 50*time.Millisecond, 0); err == nil {
+		t.Error("expected error for infinite flow without limit")
+	}
+	if _, err := Simulate(f, 0, time.Second); err == nil {
+		t.Error("expected error for zero step")
+	}
+
+	samples, err := Simulate(f, 100*time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 11 || samples[len(samples)-1].At != time.Second {
+		t.Errorf("expected samples up to the limit, got %v", samples)
+	}
+}
@@ -0,0 +1,64 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// Error is a JSON body of unsuccessful response
+type Error struct {
+	Error      string `json:"error"`
+	DeviceCode *int   `json:"device_code,omitempty"` // error code reported by device
+}
+
+// requestError is an error caused by invalid request
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+func badRequest(msg string) error {
+	return &requestError{msg}
+}
+
+// status maps error into HTTP status code:
+//   400 Bad Request           invalid request body or values rejected by the library
+//   404 Not Found             unknown bulb or no bulbs matching selector
+//   422 Unprocessable Entity  command rejected by device (device error code is included)
+//   501 Not Implemented       method not supported by device
+//   502 Bad Gateway           device is unreachable or connection was lost
+func status(err error) int {
+	switch e := err.(type) {
+	case *requestError:
+		return http.StatusBadRequest
+	case *registry.NotFoundError:
+		return http.StatusNotFound
+	case *registry.UnreachableError:
+		return http.StatusBadGateway
+	case *yl.DeviceError:
+		if strings.Contains(e.Message, "not supported") {
+			return http.StatusNotImplemented
+		}
+		return http.StatusUnprocessableEntity
+	}
+	if registry.IsConnectionError(err) {
+		return http.StatusBadGateway
+	}
+	// remaining errors are returned by the library before command is sent (validation)
+	return http.StatusBadRequest
+}
+
+func errorBody(err error) Error {
+	body := Error{Error: err.Error()}
+	if e, ok := err.(*yl.DeviceError); ok {
+		code := e.Code
+		body.DeviceCode = &code
+	}
+	return body
+}
@@ -0,0 +1,278 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EventProps          = "props"           // bulb reported property changes
+	EventConnected      = "connected"       // connection to bulb was established
+	EventDisconnected   = "disconnected"    // connection to bulb was lost
+	EventDiscovered     = "discovered"      // new bulb was found by discovery
+	EventAddressChanged = "address_changed" // known bulb was found on a different address
+	EventLost           = "lost"            // known bulb didn't respond to discovery
+)
+
+// Event is a JSON message sent by /events and /ws endpoints
+type Event struct {
+	Type  string            `json:"type"`
+	Bulb  string            `json:"bulb"` // bulb name
+	ID    string            `json:"id"`   // device ID
+	Ip    string            `json:"ip,omitempty"`
+	Props map[string]string `json:"props,omitempty"` // changed properties, EventProps only
+	Error string            `json:"error,omitempty"`
+	Time  time.Time         `json:"time"`
+}
+
+// Filter selects events delivered to a client, empty lists match everything
+type Filter struct {
+	Bulbs []string `json:"bulbs"` // bulb names, device IDs or name patterns ("office/*")
+	Props []string `json:"props"` // properties included in EventProps, other properties are removed
+	Types []string `json:"types"` // event types
+}
+
+// filterFromQuery reads filter from "bulb", "prop" and "type" query parameters,
+// parameters may be repeated or contain comma separated values
+func filterFromQuery(r *http.Request) Filter {
+	values := func(key string) []string {
+		var list []string
+		for _, value := range r.URL.Query()[key] {
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					list = append(list, v)
+				}
+			}
+		}
+		return list
+	}
+	return Filter{Bulbs: values("bulb"), Props: values("prop"), Types: values("type")}
+}
+
+// apply returns event passed through filter, false is returned when event should be skipped
+func (f Filter) apply(e Event) (Event, bool) {
+	if len(f.Types) > 0 && !contains(f.Types, e.Type) {
+		return e, false
+	}
+
+	if len(f.Bulbs) > 0 {
+		matched := false
+		for _, pattern := range f.Bulbs {
+			ok, _ := path.Match(pattern, e.Bulb)
+			if ok || pattern == e.ID {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return e, false
+		}
+	}
+
+	if e.Type == EventProps && len(f.Props) > 0 {
+		props := make(map[string]string)
+		for k, v := range e.Props {
+			if contains(f.Props, k) {
+				props[k] = v
+			}
+		}
+		if len(props) == 0 {
+			return e, false
+		}
+		e.Props = props
+	}
+	return e, true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// eventClient is a single event stream receiver
+type eventClient struct {
+	events chan Event
+
+	mtx    sync.Mutex
+	filter Filter
+}
+
+func (c *eventClient) setFilter(filter Filter) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.filter = filter
+}
+
+// hub passes events to connected clients, events are dropped for clients which don't keep up
+type hub struct {
+	mtx     sync.Mutex
+	clients map[*eventClient]struct{}
+}
+
+func newHub() *hub {
+	return &hub{clients: make(map[*eventClient]struct{})}
+}
+
+func (h *hub) subscribe(filter Filter) *eventClient {
+	c := &eventClient{events: make(chan Event, 64), filter: filter}
+
+	h.mtx.Lock()
+	h.clients[c] = struct{}{}
+	h.mtx.Unlock()
+	return c
+}
+
+func (h *hub) unsubscribe(c *eventClient) {
+	h.mtx.Lock()
+	delete(h.clients, c)
+	h.mtx.Unlock()
+}
+
+func (h *hub) publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	for c := range h.clients {
+		c.mtx.Lock()
+		filtered, ok := c.filter.apply(e)
+		c.mtx.Unlock()
+		if !ok {
+			continue
+		}
+
+		select {
+		case c.events <- filtered:
+		default:
+		}
+	}
+}
+
+// Publish passes custom event to connected event stream clients
+func (s *Server) Publish(e Event) {
+	s.hub.publish(e)
+}
+
+// Subscribe returns channel receiving events matching filter, the same events are streamed by /events.
+// Returned function cancels subscription, channel is never closed
+func (s *Server) Subscribe(filter Filter) (<-chan Event, func()) {
+	client := s.hub.subscribe(filter)
+	return client.events, func() { s.hub.unsubscribe(client) }
+}
+
+// heartbeatInterval is an interval of keep-alive messages sent to idle event streams
+const heartbeatInterval = 15 * time.Second
+
+// handleEvents streams events as Server-Sent Events, see Filter for supported query parameters
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, Error{Error: "streaming is not supported"})
+		return
+	}
+
+	client := s.hub.subscribe(filterFromQuery(r))
+	defer s.hub.unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	var seq int
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case e := <-client.events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			seq++
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", seq, e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// handleWebSocket streams events as WebSocket text messages. Filter may be given with query parameters
+// and changed later by sending Filter as JSON message
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	client := s.hub.subscribe(filterFromQuery(r))
+	defer s.hub.unsubscribe(client)
+
+	// reading messages sent by client: filter changes and control frames
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var filter Filter
+			if err := json.Unmarshal(message, &filter); err != nil {
+				_ = conn.WriteMessage(mustMarshal(Error{Error: fmt.Sprintf("invalid filter: %v", err)}))
+				continue
+			}
+			client.setFilter(filter)
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		case e := <-client.events:
+			if err := conn.WriteMessage(mustMarshal(e)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// sseEvent is an event read from Server-Sent Events stream
+type sseEvent struct {
+	id, event string
+	data      Event
+}
+
+// readSSE reads next event from stream, comments are skipped
+func readSSE(t *testing.T, reader *bufio.Reader) sseEvent {
+	var e sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if e.event != "" {
+				return e
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.data); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			t.Fatalf("unexpected line %q", line)
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	server := newTestServer(t, "desk", "shelf")
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?bulb=desk&prop=power")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	server.Config.Handler.(*Server).Monitor()
+
+	// events of other bulbs are filtered out
+	e := readSSE(t, reader)
+	if e.id != "1" || e.event != EventConnected || e.data.Bulb != "desk" || e.data.ID != server.devices["desk"].ID {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	// device notification is streamed with not requested properties removed
+	server.devices["shelf"].SetProp(yl.PROP_POWER, "on")
+	server.devices["desk"].SetProp(yl.PROP_BRIGHT, "10")
+	server.devices["desk"].SetProp(yl.PROP_POWER, "on")
+	e = readSSE(t, reader)
+	if e.id != "2" || e.event != EventProps || e.data.Bulb != "desk" || len(e.data.Props) != 1 || e.data.Props["power"] != "on" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	server.drop(t, "desk")
+	if e = readSSE(t, reader); e.event != EventDisconnected || e.data.Bulb != "desk" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+}
+
+func TestEventStreamMethod(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	if code, body := request(t, server, http.MethodPost, "/events", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d: %s", code, body)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	e := Event{Type: EventProps, Bulb: "office/desk", ID: "0x1", Props: map[string]string{"power": "on", "bright": "10"}, Time: time.Now()}
+	tests := []struct {
+		name   string
+		filter Filter
+		pass   bool
+		props  int
+	}{
+		{"empty", Filter{}, true, 2},
+		{"type", Filter{Types: []string{EventConnected}}, false, 0},
+		{"bulb name", Filter{Bulbs: []string{"office/desk"}}, true, 2},
+		{"bulb pattern", Filter{Bulbs: []string{"office/*"}}, true, 2},
+		{"device ID", Filter{Bulbs: []string{"0x1"}}, true, 2},
+		{"other bulb", Filter{Bulbs: []string{"kitchen"}}, false, 0},
+		{"props", Filter{Props: []string{"power"}}, true, 1},
+		{"other props", Filter{Props: []string{"ct"}}, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filtered, ok := test.filter.apply(e)
+			if ok != test.pass {
+				t.Fatalf("expected pass %v, got %v", test.pass, ok)
+			}
+			if ok && len(filtered.Props) != test.props {
+				t.Errorf("expected %d props, got %v", test.props, filtered.Props)
+			}
+		})
+	}
+	if len(e.Props) != 2 {
+		t.Errorf("original event was modified: %v", e.Props)
+	}
+}
@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// Monitor keeps connections to all registered bulbs and publishes their notifications
+// and connection state changes as events. It should be called again after registry was changed,
+// bulbs which are already monitored are skipped and monitors of removed bulbs are stopped
+func (s *Server) Monitor() {
+	s.monitorsMtx.Lock()
+	defer s.monitorsMtx.Unlock()
+
+	registered := make(map[string]bool)
+	for _, entry := range s.registry.Entries() {
+		registered[entry.ID] = true
+		if _, ok := s.monitors[entry.ID]; ok {
+			continue
+		}
+		stop := make(chan struct{})
+		s.monitors[entry.ID] = stop
+		go s.monitor(entry.ID, stop)
+	}
+
+	for id, stop := range s.monitors {
+		if !registered[id] {
+			close(stop)
+			delete(s.monitors, id)
+		}
+	}
+}
+
+// monitor keeps connection to a single bulb until it's stopped or bulb is removed from registry
+func (s *Server) monitor(id string, stop chan struct{}) {
+	var backoff registry.Backoff
+
+	for {
+		entry, err := s.registry.Lookup(id)
+		if err != nil {
+			return
+		}
+
+		bulb, err := s.registry.Bulb(id)
+		if err != nil {
+			if !backoff.Wait(stop) {
+				return
+			}
+			continue
+		}
+		backoff.Reset()
+
+		s.hub.publish(Event{Type: EventConnected, Bulb: entry.Name, ID: entry.ID, Ip: entry.Ip})
+		if !s.forward(entry, bulb, stop) {
+			return
+		}
+		s.hub.publish(Event{Type: EventDisconnected, Bulb: entry.Name, ID: entry.ID, Ip: entry.Ip})
+
+		// connection could be already replaced after address change, dropping it doesn't hurt
+		_ = s.registry.Disconnect(id)
+		if !backoff.Wait(stop) {
+			return
+		}
+	}
+}
+
+// forward publishes bulb notifications until connection is closed, false is returned when monitor was stopped
+func (s *Server) forward(entry registry.Entry, bulb *yl.Bulb, stop chan struct{}) bool {
+	subscription := bulb.Subscribe()
+	defer subscription.Close()
+
+	for {
+		select {
+		case <-stop:
+			return false
+		case notification, ok := <-subscription.C:
+			if !ok {
+				return true
+			}
+			if notification.Method != "props" {
+				continue
+			}
+			// entry is read again, bulb could be renamed in the meantime
+			if current, err := s.registry.Lookup(entry.ID); err == nil {
+				entry = current
+			}
+			s.hub.publish(Event{Type: EventProps, Bulb: entry.Name, ID: entry.ID, Ip: entry.Ip, Props: notification.Params})
+		}
+	}
+}
+
+// Discover runs discovery, updates registry and publishes discovery events: new bulbs, changed addresses
+// and registered bulbs which didn't respond (reported once, until bulb responds again).
+// Monitored bulbs are updated, see Monitor. Changed registry entries are returned
+func (s *Server) Discover(timeout time.Duration) ([]registry.Entry, error) {
+	devices, err := yl.Discover(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, entry := range s.registry.Entries() {
+		known[entry.ID] = true
+	}
+
+	changed := s.registry.Update(devices)
+	for _, entry := range changed {
+		event := Event{Type: EventDiscovered, Bulb: entry.Name, ID: entry.ID, Ip: entry.Ip}
+		if known[entry.ID] {
+			event.Type = EventAddressChanged
+		}
+		s.hub.publish(event)
+	}
+
+	found := make(map[string]bool)
+	for _, device := range devices {
+		found[device.ID] = true
+	}
+
+	s.monitorsMtx.Lock()
+	for _, entry := range s.registry.Entries() {
+		switch {
+		case found[entry.ID]:
+			delete(s.lost, entry.ID)
+		case !s.lost[entry.ID]:
+			s.lost[entry.ID] = true
+			s.hub.publish(Event{Type: EventLost, Bulb: entry.Name, ID: entry.ID, Ip: entry.Ip})
+		}
+	}
+	s.monitorsMtx.Unlock()
+
+	s.Monitor()
+	return changed, nil
+}
@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+)
+
+// Duration is transition duration, JSON value is either number of milliseconds or duration string ("1.5s")
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var ms float64
+	if err := json.Unmarshal(data, &ms); err == nil {
+		*d = Duration(ms * float64(time.Millisecond))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration is required to be a number of milliseconds or a string")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// PowerRequest is a body of PUT .../power
+type PowerRequest struct {
+	On       bool     `json:"on"`
+	Duration Duration `json:"duration"`
+}
+
+// ColorRequest is a body of PUT .../color, color is required unless only brightness is changed
+type ColorRequest struct {
+	Color      string   `json:"color"`      // "#ff8800", "orange", "3000K", see yeelight.ParseColor
+	Brightness int      `json:"brightness"` // 1-100, 0 keeps current brightness
+	Duration   Duration `json:"duration"`
+}
+
+// BrightnessRequest is a body of PUT .../brightness
+type BrightnessRequest struct {
+	Brightness int      `json:"brightness"`
+	Duration   Duration `json:"duration"`
+}
+
+// FlowRequest is a body of PUT .../flow, flow is given with one of preset, text (flow file format)
+// or expression (comma separated flow tuples)
+type FlowRequest struct {
+	Preset     string `json:"preset"`
+	Text       string `json:"text"`
+	Expression string `json:"expression"`
+	Count      int    `json:"count"`  // used with expression, 0 for infinite flow
+	Action     string `json:"action"` // used with expression: recover (default), stay or off
+}
+
+// SceneRequest is a body of PUT .../scene
+type SceneRequest struct {
+	Type        string      `json:"type"` // color, hsv, ct, flow or delayoff
+	Color       string      `json:"color"`
+	Hue         int         `json:"hue"`
+	Saturation  int         `json:"saturation"`
+	Temperature int         `json:"temperature"`
+	Brightness  int         `json:"brightness"`
+	Minutes     int         `json:"minutes"`
+	Flow        FlowRequest `json:"flow"`
+}
+
+// TimerRequest is a body of PUT .../timer
+type TimerRequest struct {
+	Minutes int `json:"minutes"`
+}
+
+// NameRequest is a body of PUT .../name
+type NameRequest struct {
+	Name string `json:"name"`
+}
+
+// light is a set of commands supported by both main and background light
+type light interface {
+	SetPower(on bool, d time.Duration) error
+	Toggle() error
+	SetColor(color yl.Color, d time.Duration) error
+	SetBrightness(brightness int, d time.Duration) error
+	StartColorFlow(count int, action yl.CfAction, flowExpression yl.FlowExpression) error
+	StopColorFlow() error
+	SetScene(scene yl.Scene) error
+}
+
+// command is a single action executed on light
+type command func(l light) error
+
+func (r PowerRequest) command() (command, error) {
+	return func(l light) error { return l.SetPower(r.On, time.Duration(r.Duration)) }, nil
+}
+
+func (r ColorRequest) command() (command, error) {
+	if r.Color == "" && r.Brightness == 0 {
+		return nil, badRequest("color or brightness is required")
+	}
+
+	var color yl.Color
+	if r.Color != "" {
+		var err error
+		if color, err = yl.ParseColor(r.Color); err != nil {
+			return nil, badRequest(err.Error())
+		}
+	}
+
+	return func(l light) error {
+		if r.Color != "" {
+			if err := l.SetColor(color, time.Duration(r.Duration)); err != nil {
+				return err
+			}
+		}
+		if r.Brightness != 0 {
+			return l.SetBrightness(r.Brightness, time.Duration(r.Duration))
+		}
+		return nil
+	}, nil
+}
+
+func (r BrightnessRequest) command() (command, error) {
+	return func(l light) error { return l.SetBrightness(r.Brightness, time.Duration(r.Duration)) }, nil
+}
+
+// flow parses flow given in request
+func (r FlowRequest) flow() (flows.Flow, error) {
+	given := 0
+	for _, s := range []string{r.Preset, r.Text, r.Expression} {
+		if s != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return flows.Flow{}, badRequest("exactly one of preset, text or expression is required")
+	}
+
+	switch {
+	case r.Preset != "":
+		flow, err := flows.Preset(r.Preset)
+		if err != nil {
+			return flows.Flow{}, badRequest(err.Error())
+		}
+		return flow, nil
+	case r.Text != "":
+		flow, err := flows.ParseText(strings.NewReader(r.Text))
+		if err != nil {
+			return flows.Flow{}, badRequest(err.Error())
+		}
+		return flow, nil
+	}
+
+	expression, err := yl.ParseFlowExpression(r.Expression)
+	if err != nil {
+		return flows.Flow{}, badRequest(err.Error())
+	}
+	action, ok := map[string]yl.CfAction{
+		"": yl.CF_ACTION_RECOVER, "recover": yl.CF_ACTION_RECOVER, "stay": yl.CF_ACTION_STAY, "off": yl.CF_ACTION_POWEROFF,
+	}[r.Action]
+	if !ok {
+		return flows.Flow{}, badRequest(fmt.Sprintf("unknown action \"%s\", expected recover, stay or off", r.Action))
+	}
+	return flows.Flow{Count: r.Count, Action: action, Expression: expression}, nil
+}
+
+func (r FlowRequest) command() (command, error) {
+	flow, err := r.flow()
+	if err != nil {
+		return nil, err
+	}
+	return func(l light) error { return l.StartColorFlow(flow.Count, flow.Action, flow.Expression) }, nil
+}
+
+func (r SceneRequest) command() (command, error) {
+	var scene yl.Scene
+
+	switch r.Type {
+	case "color":
+		color, err := yl.ParseColor(r.Color)
+		if err != nil {
+			return nil, badRequest(err.Error())
+		}
+		if color.IsTemperature() {
+			scene = yl.NewTemperatureScene(color.Kelvin(), r.Brightness)
+		} else {
+			scene = yl.NewColorScene(color.RGB(), r.Brightness)
+		}
+	case "hsv":
+		scene = yl.NewHSVScene(r.Hue, r.Saturation, r.Brightness)
+	case "ct":
+		scene = yl.NewTemperatureScene(r.Temperature, r.Brightness)
+	case "flow":
+		flow, err := r.Flow.flow()
+		if err != nil {
+			return nil, err
+		}
+		scene = yl.NewColorFlowScene(flow.Count, flow.Action, flow.Expression)
+	case "delayoff":
+		scene = yl.NewAutoDelayOffScene(r.Brightness, r.Minutes)
+	default:
+		return nil, badRequest(fmt.Sprintf("unknown scene type \"%s\", expected color, hsv, ct, flow or delayoff", r.Type))
+	}
+
+	return func(l light) error { return l.SetScene(scene) }, nil
+}
@@ -0,0 +1,413 @@
+// Package gateway exposes bulbs registered in registry over HTTP, as REST API with JSON bodies:
+//
+//   GET    /bulbs                        registered bulbs
+//   GET    /bulbs/{name}                 single bulb
+//   GET    /bulbs/{name}/state           current state (see yeelight.Snapshot)
+//   PUT    /bulbs/{name}/power           PowerRequest
+//   POST   /bulbs/{name}/toggle
+//   PUT    /bulbs/{name}/color           ColorRequest
+//   PUT    /bulbs/{name}/brightness      BrightnessRequest
+//   PUT    /bulbs/{name}/flow            FlowRequest
+//   DELETE /bulbs/{name}/flow            stops running flow
+//   PUT    /bulbs/{name}/scene           SceneRequest
+//   GET    /bulbs/{name}/timer           remaining minutes of power off timer
+//   PUT    /bulbs/{name}/timer           TimerRequest
+//   DELETE /bulbs/{name}/timer
+//   PUT    /bulbs/{name}/name            NameRequest
+//
+// Name is a bulb name or device ID, names containing "/" ("office/desk") are allowed.
+// Light commands (power, toggle, color, brightness, flow, scene) control background light
+// when "light=bg" query parameter is given.
+// The same commands are available for groups under /groups/{selector}/..., where selector
+// is any selector supported by registry.Select ("all", "room:kitchen", "tag:ceiling", "office/*").
+// Group responses report result of every bulb, 207 Multi-Status is returned when any bulb failed.
+//
+// Live events (see Event) are streamed by /events as Server-Sent Events and by /ws as WebSocket messages.
+// Streams are filtered with "bulb", "prop" and "type" query parameters, for instance
+// /events?bulb=office/*&prop=power,bright, WebSocket clients can change filter by sending Filter message.
+// Bulb events are published for bulbs watched by Monitor, discovery events are published by Discover
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// Server is a HTTP handler controlling bulbs from registry. Connections to bulbs are kept open
+// between requests, broken connections are reopened
+type Server struct {
+	registry *registry.Registry
+	mux      *http.ServeMux
+	hub      *hub
+
+	monitors    map[string]chan struct{} // stop channels of bulb monitors, keyed by device ID
+	lost        map[string]bool          // bulbs which didn't respond to last discovery
+	monitorsMtx sync.Mutex
+
+	concurrency int
+}
+
+// New creates server controlling bulbs from given registry
+func New(reg *registry.Registry) *Server {
+	s := &Server{
+		registry:    reg,
+		mux:         http.NewServeMux(),
+		hub:         newHub(),
+		monitors:    make(map[string]chan struct{}),
+		lost:        make(map[string]bool),
+		concurrency: 4,
+	}
+	s.mux.HandleFunc("/bulbs", s.handleBulbs)
+	s.mux.HandleFunc("/bulbs/", s.handleBulb)
+	s.mux.HandleFunc("/groups/", s.handleGroup)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/ws", s.handleWebSocket)
+	return s
+}
+
+// Registry returns registry of controlled bulbs
+func (s *Server) Registry() *registry.Registry {
+	return s.registry
+}
+
+// SetConcurrency sets maximum number of bulbs controlled in parallel by group requests
+func (s *Server) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s.concurrency = concurrency
+}
+
+// Handle registers additional handler, it allows extending API with other endpoints
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// bulbCommand is a command executed on a single bulb
+type bulbCommand struct {
+	run        func(b *yl.Bulb) error
+	idempotent bool // command can be safely repeated after connection was lost
+}
+
+// actions available for single bulbs and groups
+var (
+	lightActions = map[string]bool{"power": true, "toggle": true, "color": true, "brightness": true, "flow": true, "scene": true, "timer": true}
+	bulbActions  = map[string]bool{"state": true, "name": true}
+)
+
+// splitAction splits path into name and action, path without known action is a name only
+func splitAction(path string, group bool) (name, action string) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return path, ""
+	}
+	name, action = path[:i], path[i+1:]
+	if lightActions[action] || !group && bulbActions[action] {
+		return name, action
+	}
+	return path, ""
+}
+
+func (s *Server) handleBulbs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.registry.Entries())
+}
+
+func (s *Server) handleBulb(w http.ResponseWriter, r *http.Request) {
+	name, action := splitAction(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/bulbs/"), "/"), false)
+	if name == "" {
+		writeError(w, &registry.NotFoundError{Name: name})
+		return
+	}
+
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		entry, err := s.registry.Lookup(name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, entry)
+	case "state":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		var snapshot yl.Snapshot
+		err := s.execute(name, bulbCommand{idempotent: true, run: func(b *yl.Bulb) (err error) {
+			snapshot, err = b.Snapshot()
+			return err
+		}})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, snapshot)
+	case "timer":
+		if r.Method != http.MethodGet {
+			s.handleCommand(w, r, name, action, false)
+			return
+		}
+		var minutes int
+		err := s.execute(name, bulbCommand{idempotent: true, run: func(b *yl.Bulb) error {
+			values, err := b.Prop(yl.PROP_DELAYOFF)
+			if err != nil {
+				return err
+			}
+			minutes, _ = strconv.Atoi(fmt.Sprintf("%v", values[string(yl.PROP_DELAYOFF)]))
+			return nil
+		}})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, TimerRequest{Minutes: minutes})
+	default:
+		s.handleCommand(w, r, name, action, false)
+	}
+}
+
+func (s *Server) handleGroup(w http.ResponseWriter, r *http.Request) {
+	selector, action := splitAction(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/groups/"), "/"), true)
+
+	if action == "" {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		entries, err := s.registry.Select(selector)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+		return
+	}
+	s.handleCommand(w, r, selector, action, true)
+}
+
+// handleCommand parses command request and executes it on a bulb or a group
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request, name, action string, group bool) {
+	cmd, err := s.parseCommand(w, r, action)
+	if err != nil {
+		if err != errMethodNotAllowed {
+			writeError(w, err)
+		}
+		return
+	}
+
+	if !group {
+		if err := s.execute(name, cmd); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	results, err := s.executeGroup(name, cmd)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	code := http.StatusOK
+	for _, result := range results {
+		if result.Status != http.StatusOK {
+			code = http.StatusMultiStatus
+		}
+	}
+	writeJSON(w, code, GroupResponse{Results: results})
+}
+
+var errMethodNotAllowed = badRequest("method not allowed")
+
+// parseCommand reads command from request, errMethodNotAllowed is returned when response was already written
+func (s *Server) parseCommand(w http.ResponseWriter, r *http.Request, action string) (bulbCommand, error) {
+	allow := map[string][]string{
+		"power": {http.MethodPut}, "toggle": {http.MethodPost}, "color": {http.MethodPut},
+		"brightness": {http.MethodPut}, "flow": {http.MethodPut, http.MethodDelete}, "scene": {http.MethodPut},
+		"timer": {http.MethodPut, http.MethodDelete}, "name": {http.MethodPut},
+	}[action]
+	allowed := false
+	for _, method := range allow {
+		allowed = allowed || r.Method == method
+	}
+	if !allowed {
+		methodNotAllowed(w, allow...)
+		return bulbCommand{}, errMethodNotAllowed
+	}
+
+	var selectLight func(b *yl.Bulb) light
+	switch r.URL.Query().Get("light") {
+	case "", "main":
+		selectLight = func(b *yl.Bulb) light { return b }
+	case "bg", "background":
+		selectLight = func(b *yl.Bulb) light { return &b.Bg }
+	default:
+		return bulbCommand{}, badRequest("light is required to be \"main\" or \"bg\"")
+	}
+	onLight := func(cmd command, err error) (bulbCommand, error) {
+		if err != nil {
+			return bulbCommand{}, err
+		}
+		return bulbCommand{idempotent: true, run: func(b *yl.Bulb) error { return cmd(selectLight(b)) }}, nil
+	}
+
+	switch {
+	case action == "toggle":
+		return bulbCommand{run: func(b *yl.Bulb) error { return selectLight(b).Toggle() }}, nil
+	case action == "flow" && r.Method == http.MethodDelete:
+		return onLight(func(l light) error { return l.StopColorFlow() }, nil)
+	case action == "timer" && r.Method == http.MethodDelete:
+		return bulbCommand{idempotent: true, run: func(b *yl.Bulb) error { return b.CronDel(yl.CRON_TYPE_POWER_OFF) }}, nil
+	}
+
+	switch action {
+	case "power":
+		var req PowerRequest
+		if err := readJSON(r, &req); err != nil {
+			return bulbCommand{}, err
+		}
+		return onLight(req.command())
+	case "color":
+		var req ColorRequest
+		if err := readJSON(r, &req); err != nil {
+			return bulbCommand{}, err
+		}
+		return onLight(req.command())
+	case "brightness":
+		var req BrightnessRequest
+		if err := readJSON(r, &req); err != nil {
+			return bulbCommand{}, err
+		}
+		return onLight(req.command())
+	case "flow":
+		var req FlowRequest
+		if err := readJSON(r, &req); err != nil {
+			return bulbCommand{}, err
+		}
+		return onLight(req.command())
+	case "scene":
+		var req SceneRequest
+		if err := readJSON(r, &req); err != nil {
+			return bulbCommand{}, err
+		}
+		return onLight(req.command())
+	case "timer":
+		var req TimerRequest
+		if err := readJSON(r, &req); err != nil {
+			return bulbCommand{}, err
+		}
+		return bulbCommand{idempotent: true, run: func(b *yl.Bulb) error { return b.CronAdd(yl.CRON_TYPE_POWER_OFF, req.Minutes) }}, nil
+	case "name":
+		var req NameRequest
+		if err := readJSON(r, &req); err != nil {
+			return bulbCommand{}, err
+		}
+		return bulbCommand{idempotent: true, run: func(b *yl.Bulb) error { return b.SetName(req.Name) }}, nil
+	}
+	return bulbCommand{}, badRequest(fmt.Sprintf("unknown action \"%s\"", action))
+}
+
+// execute runs command on bulb with given name, see registry.Execute
+func (s *Server) execute(name string, cmd bulbCommand) error {
+	if cmd.idempotent {
+		return s.registry.Execute(name, cmd.run)
+	}
+	return s.registry.ExecuteOnce(name, cmd.run)
+}
+
+// GroupResponse is a body of group command response
+type GroupResponse struct {
+	Results map[string]GroupResult `json:"results"` // keyed by bulb name
+}
+
+// GroupResult is a result of group command for a single bulb
+type GroupResult struct {
+	Status int `json:"status"` // HTTP status code which would be returned for single bulb
+	*Error
+}
+
+// executeGroup runs command on every bulb matching selector as yeelight.Group, results are keyed by bulb name
+func (s *Server) executeGroup(selector string, cmd bulbCommand) (map[string]GroupResult, error) {
+	entries, err := s.registry.Select(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(entries)) // keyed by bulb address
+	for _, entry := range entries {
+		names[entry.Address()] = entry.Name
+	}
+
+	// unreachable bulbs are reported as failed, the rest of the group is still controlled
+	group, err := s.registry.GroupOf(entries)
+	failed := yl.GroupError{}
+	if unreachable, ok := err.(yl.GroupError); ok {
+		failed = unreachable
+	}
+	if len(group.Bulbs()) > 0 {
+		group.SetConcurrency(s.concurrency)
+		// bulb is already connected, execute reuses connection and reconnects when it was lost
+		err := group.Each(func(b *yl.Bulb) error { return s.execute(names[b.Address()], cmd) })
+		if errs, ok := err.(yl.GroupError); ok {
+			for address, err := range errs {
+				failed[address] = err
+			}
+		}
+	}
+
+	results := make(map[string]GroupResult, len(entries))
+	for _, entry := range entries {
+		result := GroupResult{Status: http.StatusOK}
+		if err, ok := failed[entry.Address()]; ok {
+			body := errorBody(err)
+			result = GroupResult{Status: status(err), Error: &body}
+		}
+		results[entry.Name] = result
+	}
+	return results, nil
+}
+
+// readJSON decodes request body, unknown fields are rejected
+func readJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest(fmt.Sprintf("invalid request body: %v", err))
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, status(err), errorBody(err))
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, Error{Error: "method not allowed"})
+}
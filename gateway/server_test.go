@@ -0,0 +1,243 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+// testServer is a gateway controlling fake devices
+type testServer struct {
+	*httptest.Server
+	registry *registry.Registry
+	devices  map[string]*yeelighttest.Device
+}
+
+func (s *testServer) Close() {
+	s.Server.Close()
+	s.registry.Close()
+	for _, device := range s.devices {
+		device.Close()
+	}
+}
+
+// drop drops connection of the device and waits until it's noticed by the bulb
+func (s *testServer) drop(t *testing.T, name string) {
+	bulb, err := s.registry.Bulb(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscription := bulb.Subscribe()
+	s.devices[name].DropConnections()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("connection wasn't closed")
+		}
+	}
+}
+
+// newTestServer starts gateway controlling fake devices registered under given names
+func newTestServer(t *testing.T, names ...string) *testServer {
+	reg := registry.New("")
+	devices := make(map[string]*yeelighttest.Device)
+	for _, name := range names {
+		device, err := yeelighttest.NewDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		devices[name] = device
+		entry := registry.Entry{ID: device.ID, Name: name, Room: "office", Ip: device.Ip, Port: device.Port}
+		if err := reg.Set(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &testServer{Server: httptest.NewServer(New(reg)), registry: reg, devices: devices}
+}
+
+func request(t *testing.T, server *testServer, method, path, body string) (int, []byte) {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var data json.RawMessage
+	_ = json.NewDecoder(resp.Body).Decode(&data)
+	return resp.StatusCode, data
+}
+
+// methods returns methods of commands received by device
+func methods(device *yeelighttest.Device) []string {
+	var methods []string
+	for _, command := range device.Commands() {
+		methods = append(methods, command.Method)
+	}
+	return methods
+}
+
+func TestBulbCommand(t *testing.T) {
+	server := newTestServer(t, "desk")
+	defer server.Close()
+	devices := server.devices
+
+	code, body := request(t, server, http.MethodPut, "/bulbs/desk/power", `{"on": true, "duration": "500ms"}`)
+	if code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", code, body)
+	}
+	commands := devices["desk"].Commands()
+	if len(commands) != 1 || commands[0].Method != "set_power" || commands[0].Params[0] != "on" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+	if devices["desk"].Prop(yl.PROP_POWER) != "on" {
+		t.Error("device wasn't powered on")
+	}
+}
+
+func TestBulbErrors(t *testing.T) {
+	server := newTestServer(t, "desk")
+	defer server.Close()
+	devices := server.devices
+	devices["desk"].Fail("set_power", &yl.DeviceError{Code: -5000, Message: "general error"})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{"unknown bulb", http.MethodPut, "/bulbs/kitchen/power", `{"on": true}`, http.StatusNotFound},
+		{"invalid body", http.MethodPut, "/bulbs/desk/color", `{"colour": "red"}`, http.StatusBadRequest},
+		{"invalid color", http.MethodPut, "/bulbs/desk/color", `{"color": "nope"}`, http.StatusBadRequest},
+		{"method not allowed", http.MethodGet, "/bulbs/desk/toggle", ``, http.StatusMethodNotAllowed},
+		{"device error", http.MethodPut, "/bulbs/desk/power", `{"on": true}`, http.StatusUnprocessableEntity},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code, body := request(t, server, test.method, test.path, test.body); code != test.code {
+				t.Errorf("expected %d, got %d: %s", test.code, code, body)
+			}
+		})
+	}
+
+	_, body := request(t, server, http.MethodPut, "/bulbs/desk/power", `{"on": true}`)
+	var e Error
+	if err := json.Unmarshal(body, &e); err != nil || e.DeviceCode == nil || *e.DeviceCode != -5000 {
+		t.Errorf("expected device code in %s", body)
+	}
+}
+
+func TestBulbState(t *testing.T) {
+	server := newTestServer(t, "desk")
+	defer server.Close()
+	devices := server.devices
+	devices["desk"].SetProp(yl.PROP_POWER, "on")
+	devices["desk"].SetProp(yl.PROP_BRIGHT, "42")
+
+	code, body := request(t, server, http.MethodGet, "/bulbs/desk/state", "")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", code, body)
+	}
+	var snapshot yl.Snapshot
+	if err := json.Unmarshal(body, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if !snapshot.Main.Power || snapshot.Main.Brightness != 42 {
+		t.Errorf("unexpected snapshot: %s", body)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	server := newTestServer(t, "desk")
+	defer server.Close()
+	device := server.devices["desk"]
+
+	if code, body := request(t, server, http.MethodPut, "/bulbs/desk/power", `{"on": true}`); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", code, body)
+	}
+
+	// idempotent command is repeated on a new connection
+	server.drop(t, "desk")
+	device.Reset()
+	if code, body := request(t, server, http.MethodPut, "/bulbs/desk/power", `{"on": false}`); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", code, body)
+	}
+	if m := methods(device); len(m) != 1 || m[0] != "set_power" {
+		t.Errorf("expected set_power on new connection, got %v", m)
+	}
+
+	// toggle isn't repeated, so it cannot be executed twice
+	server.drop(t, "desk")
+	device.Reset()
+	if code, body := request(t, server, http.MethodPost, "/bulbs/desk/toggle", ""); code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d: %s", code, body)
+	}
+	if m := methods(device); len(m) != 0 {
+		t.Errorf("expected no commands, got %v", m)
+	}
+
+	// next request connects again
+	if code, body := request(t, server, http.MethodPost, "/bulbs/desk/toggle", ""); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", code, body)
+	}
+	if m := methods(device); len(m) != 1 || m[0] != "toggle" {
+		t.Errorf("expected toggle, got %v", m)
+	}
+}
+
+func TestGroupCommand(t *testing.T) {
+	server := newTestServer(t, "desk", "shelf", "lamp")
+	defer server.Close()
+	devices := server.devices
+	devices["lamp"].Close()
+	devices["shelf"].Fail("set_power", &yl.DeviceError{Code: -5000, Message: "general error"})
+
+	code, body := request(t, server, http.MethodPut, "/groups/room:office/power", `{"on": true}`)
+	if code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d: %s", code, body)
+	}
+	var resp GroupResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{
+		"desk":  http.StatusOK,
+		"shelf": http.StatusUnprocessableEntity,
+		"lamp":  http.StatusBadGateway,
+	}
+	if len(resp.Results) != len(expected) {
+		t.Fatalf("unexpected results: %s", body)
+	}
+	for name, status := range expected {
+		if resp.Results[name].Status != status {
+			t.Errorf("%s: expected %d, got %d", name, status, resp.Results[name].Status)
+		}
+	}
+	if devices["desk"].Prop(yl.PROP_POWER) != "on" {
+		t.Error("reachable bulb wasn't powered on")
+	}
+
+	code, body = request(t, server, http.MethodPut, "/groups/desk/power", `{"on": false}`)
+	if code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", code, body)
+	}
+}
@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsClient is a minimal WebSocket client sending masked frames
+type wsClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocket performs opening handshake with given key
+func dialWebSocket(t *testing.T, server *testServer, path, key string) (*wsClient, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := "GET " + path + " HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return &wsClient{conn: conn, reader: reader}, resp
+}
+
+func (c *wsClient) writeFrame(t *testing.T, fin bool, opcode byte, payload []byte) {
+	header := opcode
+	if fin {
+		header |= 0x80
+	}
+	frame := []byte{header}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	default:
+		frame = append(frame, 0x80|126, byte(length>>8), byte(length))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFrame reads unmasked server frame
+func (c *wsClient) readFrame(t *testing.T) (opcode byte, payload []byte) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	if header[0]&0x80 == 0 || header[1]&0x80 != 0 {
+		t.Fatalf("expected final unmasked frame, got header %x", header)
+	}
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			t.Fatal(err)
+		}
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0f, payload
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	// sample key from RFC 6455
+	client, resp := dialWebSocket(t, server, "/ws", "dGhlIHNhbXBsZSBub25jZQ==")
+	defer client.conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("unexpected accept key %s", accept)
+	}
+	if !headerContains(resp.Header, "Upgrade", "websocket") || !headerContains(resp.Header, "Connection", "upgrade") {
+		t.Errorf("unexpected headers: %v", resp.Header)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+	}{
+		{"plain request", map[string]string{}, http.StatusBadRequest},
+		{"old version", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8", "Sec-WebSocket-Key": "x"}, http.StatusUpgradeRequired},
+		{"missing key", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13"}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != test.code {
+				t.Errorf("expected %d, got %d", test.code, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestWebSocketFrames(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	gateway := server.Config.Handler.(*Server)
+
+	client, resp := dialWebSocket(t, server, "/ws?type=custom", "dGhlIHNhbXBsZSBub25jZQ==")
+	defer client.conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+
+	// ping is answered with pong carrying the same payload
+	client.writeFrame(t, true, opPing, []byte("hello"))
+	if opcode, payload := client.readFrame(t); opcode != opPong || string(payload) != "hello" {
+		t.Fatalf("expected pong, got %x %q", opcode, payload)
+	}
+
+	// event matching filter given in query is sent as text message
+	gateway.Publish(Event{Type: EventProps, Bulb: "desk"})
+	gateway.Publish(Event{Type: "custom", Bulb: "desk"})
+	opcode, payload := client.readFrame(t)
+	var e Event
+	if err := json.Unmarshal(payload, &e); opcode != opText || err != nil || e.Type != "custom" {
+		t.Fatalf("unexpected message %x %s", opcode, payload)
+	}
+
+	// fragmented filter message, long enough to use extended payload length
+	filter := `{"types": ["props"], "bulbs": ["desk"]` + strings.Repeat(" ", 200) + `}`
+	client.writeFrame(t, false, opText, []byte(filter[:100]))
+	client.writeFrame(t, true, opContinuation, []byte(filter[100:]))
+	client.writeFrame(t, true, opPing, nil)
+	if opcode, _ := client.readFrame(t); opcode != opPong {
+		t.Fatalf("expected pong, got %x", opcode)
+	}
+	gateway.Publish(Event{Type: "custom", Bulb: "desk"})
+	gateway.Publish(Event{Type: EventProps, Bulb: "desk", Props: map[string]string{"power": "on"}})
+	opcode, payload = client.readFrame(t)
+	if err := json.Unmarshal(payload, &e); opcode != opText || err != nil || e.Type != EventProps {
+		t.Fatalf("unexpected message %x %s", opcode, payload)
+	}
+
+	// invalid filter is reported
+	client.writeFrame(t, true, opText, []byte("nope"))
+	opcode, payload = client.readFrame(t)
+	var reported Error
+	if err := json.Unmarshal(payload, &reported); opcode != opText || err != nil || !strings.HasPrefix(reported.Error, "invalid filter") {
+		t.Fatalf("unexpected message %x %s", opcode, payload)
+	}
+
+	// closing handshake, close frame is echoed
+	client.writeFrame(t, true, opClose, []byte{0x03, 0xe8})
+	if opcode, payload := client.readFrame(t); opcode != opClose || string(payload) != "\x03\xe8" {
+		t.Fatalf("expected close frame, got %x %q", opcode, payload)
+	}
+	if _, err := client.reader.ReadByte(); err != io.EOF {
+		t.Errorf("expected closed connection, got %v", err)
+	}
+}
+
+func TestWebSocketInvalidFrames(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		err   string
+	}{
+		{"unmasked", []byte{0x81, 0x02, 'h', 'i'}, "websocket: unmasked client frame"},
+		{"too large", []byte{0x81, 0xff, 0, 0, 0, 0, 0, 2, 0, 0}, errMessageTooLarge.Error()},
+		{"unknown opcode", []byte{0x83, 0x80, 0, 0, 0, 0}, "websocket: unknown opcode"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer client.Close()
+			conn := &wsConn{conn: server, reader: bufio.NewReader(server)}
+
+			go client.Write(test.frame)
+			if _, err := conn.ReadMessage(); err == nil || err.Error() != test.err {
+				t.Errorf("expected %q, got %v", test.err, err)
+			}
+		})
+	}
+}
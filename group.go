@@ -0,0 +1,242 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GroupError holds errors returned by failed bulbs of a Group, keyed by bulb address (ip:port)
+type GroupError map[string]error
+
+func (e GroupError) Error() string {
+	var addresses []string
+	for address := range e {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	var messages []string
+	for _, address := range addresses {
+		messages = append(messages, fmt.Sprintf("[%s] %v", address, e[address]))
+	}
+	return fmt.Sprintf("%d of group bulbs failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+// quota limits commands sent to all devices: 144 commands per minute.
+// It's not enforced for commands sent by Bulb itself, only Group.Each and WaitQuota callers take tokens
+type quota struct {
+	mtx      sync.Mutex
+	tokens   float64
+	capacity float64
+	interval time.Duration // time required for restoring one token
+	last     time.Time
+}
+
+func newQuota(perMinute int) *quota {
+	return &quota{
+		tokens:   float64(perMinute),
+		capacity: float64(perMinute),
+		interval: time.Minute / time.Duration(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until command can be sent without exceeding quota
+func (q *quota) wait() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	now := time.Now()
+	q.tokens += float64(now.Sub(q.last)) / float64(q.interval)
+	if q.tokens > q.capacity {
+		q.tokens = q.capacity
+	}
+	q.last = now
+
+	q.tokens--
+	if q.tokens < 0 {
+		// waiting while holding the lock keeps waiting callers in order
+		time.Sleep(time.Duration(-q.tokens * float64(q.interval)))
+	}
+}
+
+// globalQuota is shared by all groups
+var globalQuota = newQuota(144)
+
+// WaitQuota takes one token of global quota shared with groups, blocking until command can be sent.
+// It's meant for code sending commands to many devices without Group, as Bulb commands don't take tokens
+func WaitQuota() {
+	globalQuota.wait()
+}
+
+// Group sends the same command to many bulbs in parallel, failure of one bulb doesn't stop the others.
+// Commands are limited by global quota (144 commands per minute for all devices, shared with WaitQuota callers),
+// every command returns nil or GroupError with errors of failed bulbs
+type Group struct {
+	bulbs       []*Bulb
+	concurrency int
+}
+
+// NewGroup creates Group of given bulbs, bulbs are expected to be connected. Default concurrency is 4
+func NewGroup(bulbs ...*Bulb) *Group {
+	return &Group{bulbs: bulbs, concurrency: 4}
+}
+
+// SetConcurrency sets maximum amount of bulbs processed at the same time
+func (g *Group) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	g.concurrency = concurrency
+}
+
+// Bulbs returns group members
+func (g *Group) Bulbs() []*Bulb {
+	bulbs := make([]*Bulb, len(g.bulbs))
+	copy(bulbs, g.bulbs)
+	return bulbs
+}
+
+// Add adds bulbs to the group
+func (g *Group) Add(bulbs ...*Bulb) {
+	g.bulbs = append(g.bulbs, bulbs...)
+}
+
+// Each runs given function for every group bulb in parallel, one quota token is taken for every call
+func (g *Group) Each(fn func(bulb *Bulb) error) error {
+	if len(g.bulbs) == 0 {
+		return errors.New("group is empty")
+	}
+
+	var (
+		failed    = GroupError{}
+		failedMtx sync.Mutex
+		jobs      sync.WaitGroup
+		slots     = make(chan struct{}, g.concurrency)
+	)
+
+	for _, bulb := range g.bulbs {
+		jobs.Add(1)
+		slots <- struct{}{}
+
+		go func(bulb *Bulb) {
+			defer func() {
+				<-slots
+				jobs.Done()
+			}()
+
+			globalQuota.wait()
+			if err := fn(bulb); err != nil {
+				failedMtx.Lock()
+				failed[bulb.Address()] = err
+				failedMtx.Unlock()
+			}
+		}(bulb)
+	}
+	jobs.Wait()
+
+	if len(failed) == 0 {
+		return nil
+	}
+	return failed
+}
+
+func (g *Group) PowerOn(duration int) error {
+	return g.Each(func(b *Bulb) error { return b.PowerOn(duration) })
+}
+
+func (g *Group) PowerOnWithMode(duration int, mode Mode) error {
+	return g.Each(func(b *Bulb) error { return b.PowerOnWithMode(duration, mode) })
+}
+
+func (g *Group) PowerOff(duration int) error {
+	return g.Each(func(b *Bulb) error { return b.PowerOff(duration) })
+}
+
+func (g *Group) SetPower(on bool, d time.Duration) error {
+	return g.Each(func(b *Bulb) error { return b.SetPower(on, d) })
+}
+
+func (g *Group) Toggle() error {
+	return g.Each(func(b *Bulb) error { return b.Toggle() })
+}
+
+func (g *Group) Temperature(temp, duration int) error {
+	return g.Each(func(b *Bulb) error { return b.Temperature(temp, duration) })
+}
+
+func (g *Group) SetTemperature(temp int, d time.Duration) error {
+	return g.Each(func(b *Bulb) error { return b.SetTemperature(temp, d) })
+}
+
+func (g *Group) RGB(rgb, duration int) error {
+	return g.Each(func(b *Bulb) error { return b.RGB(rgb, duration) })
+}
+
+func (g *Group) SetRGB(rgb int, d time.Duration) error {
+	return g.Each(func(b *Bulb) error { return b.SetRGB(rgb, d) })
+}
+
+func (g *Group) HSV(hue, saturation, duration int) error {
+	return g.Each(func(b *Bulb) error { return b.HSV(hue, saturation, duration) })
+}
+
+func (g *Group) SetHSV(hue, saturation int, d time.Duration) error {
+	return g.Each(func(b *Bulb) error { return b.SetHSV(hue, saturation, d) })
+}
+
+func (g *Group) SetColor(color Color, d time.Duration) error {
+	return g.Each(func(b *Bulb) error { return b.SetColor(color, d) })
+}
+
+func (g *Group) Brightness(brightness, duration int) error {
+	return g.Each(func(b *Bulb) error { return b.Brightness(brightness, duration) })
+}
+
+func (g *Group) SetBrightness(brightness int, d time.Duration) error {
+	return g.Each(func(b *Bulb) error { return b.SetBrightness(brightness, d) })
+}
+
+func (g *Group) StartColorFlow(count int, action CfAction, flowExpression FlowExpression) error {
+	return g.Each(func(b *Bulb) error { return b.StartColorFlow(count, action, flowExpression) })
+}
+
+func (g *Group) StopColorFlow() error {
+	return g.Each(func(b *Bulb) error { return b.StopColorFlow() })
+}
+
+func (g *Group) SetScene(scene Scene) error {
+	return g.Each(func(b *Bulb) error { return b.SetScene(scene) })
+}
+
+func (g *Group) SetDefault() error {
+	return g.Each(func(b *Bulb) error { return b.SetDefault() })
+}
+
+func (g *Group) CronAdd(jobType CronType, minutes int) error {
+	return g.Each(func(b *Bulb) error { return b.CronAdd(jobType, minutes) })
+}
+
+func (g *Group) CronDel(jobType CronType) error {
+	return g.Each(func(b *Bulb) error { return b.CronDel(jobType) })
+}
+
+func (g *Group) SetAdjust(action Action, prop AdjustProp) error {
+	return g.Each(func(b *Bulb) error { return b.SetAdjust(action, prop) })
+}
+
+func (g *Group) AdjustBright(percentage, duration int) error {
+	return g.Each(func(b *Bulb) error { return b.AdjustBright(percentage, duration) })
+}
+
+func (g *Group) AdjustTemperature(percentage, duration int) error {
+	return g.Each(func(b *Bulb) error { return b.AdjustTemperature(percentage, duration) })
+}
+
+func (g *Group) AdjustColor(percentage, duration int) error {
+	return g.Each(func(b *Bulb) error { return b.AdjustColor(percentage, duration) })
+}
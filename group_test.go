@@ -0,0 +1,40 @@
+package yeelight_test
+
+import (
+	"testing"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+func TestGroupErrorKeyedByAddress(t *testing.T) {
+	group := yl.NewGroup()
+	for i := 0; i < 2; i++ {
+		device, err := yeelighttest.NewDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer device.Close()
+		device.Fail("toggle", &yl.DeviceError{Code: -1, Message: "general error"})
+
+		bulb, err := device.Bulb()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer bulb.Disconnect()
+		group.Add(bulb)
+	}
+
+	failed, ok := group.Toggle().(yl.GroupError)
+	if !ok {
+		t.Fatalf("expected GroupError, got %v", failed)
+	}
+	if len(failed) != 2 {
+		t.Fatalf("expected 2 failed bulbs, got %v", failed)
+	}
+	for _, bulb := range group.Bulbs() {
+		if _, ok := failed[bulb.Address()]; !ok {
+			t.Errorf("missing error of %s", bulb.Address())
+		}
+	}
+}
@@ -0,0 +1,350 @@
+// Package hue emulates Philips Hue bridge (local REST API v1), so bulbs from registry can be controlled
+// by apps and voice assistants speaking Hue API:
+//
+//	POST /api                          pairing, link button is always considered pressed
+//	GET  /api/config                   public bridge config
+//	GET  /api/{user}                   full datastore
+//	GET  /api/{user}/config
+//	GET  /api/{user}/lights            all lights
+//	GET  /api/{user}/lights/{id}
+//	PUT  /api/{user}/lights/{id}/state on, bri, hue, sat, ct, xy, bri_inc, transitiontime, alert, effect
+//	GET  /description.xml              UPnP device description, see ServeSSDP
+//
+// Any user name is accepted. Light states are translated into set_power, set_bright, set_hsv,
+// set_ct_abx and set_rgb commands. Light IDs are assigned in order of device IDs and stay the same
+// while bridge is running, apps should identify lights by "uniqueid" derived from device ID.
+// Most apps expect the bridge on port 80
+package hue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// emulated bridge versions, apps refuse bridges with outdated software
+const (
+	apiVersion = "1.48.0"
+	swVersion  = "1948086000"
+	modelID    = "BSB002"
+)
+
+// Bridge is a HTTP handler emulating Hue bridge for bulbs from registry
+type Bridge struct {
+	registry *registry.Registry
+	name     string
+	host     string // advertised address, "192.168.0.10:80"
+	mac      net.HardwareAddr
+
+	idsMtx sync.Mutex
+	ids    map[string]string // light IDs keyed by device ID
+	lastID int
+}
+
+// New creates bridge advertised on given address ("192.168.0.10:80"), address used by apps is required,
+// first IPv4 address of local interfaces is used when host is missing (":80")
+func New(reg *registry.Registry, address string) (*Bridge, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" || host == "0.0.0.0" {
+		if host, err = localIPv4(); err != nil {
+			return nil, err
+		}
+	}
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("advertised host \"%s\" is not IPv4 address", host)
+	}
+
+	return &Bridge{
+		registry: reg,
+		name:     "Yeelight",
+		host:     net.JoinHostPort(host, port),
+		mac:      hardwareAddr(ip),
+		ids:      make(map[string]string),
+	}, nil
+}
+
+// SetName sets bridge name presented to apps, "Yeelight" by default
+func (b *Bridge) SetName(name string) {
+	b.name = name
+}
+
+// ID returns bridge ID, derived from MAC address of interface with advertised address
+func (b *Bridge) ID() string {
+	mac := strings.ToUpper(hex.EncodeToString(b.mac))
+	return mac[:6] + "FFFE" + mac[6:]
+}
+
+// localIPv4 returns first IPv4 address of up and non-loopback interface
+func localIPv4() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				return ipNet.IP.String(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no IPv4 address found, advertised address is required")
+}
+
+// hardwareAddr returns MAC address of interface with given address, locally administered
+// address derived from IP is returned when interface is not found (for instance loopback)
+func hardwareAddr(ip net.IP) net.HardwareAddr {
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if len(iface.HardwareAddr) != 6 {
+			continue
+		}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.HardwareAddr
+			}
+		}
+	}
+	return net.HardwareAddr{0x02, 0x00, ip[0], ip[1], ip[2], ip[3]}
+}
+
+// apiError is an error entry of Hue API response
+type apiError struct {
+	Type        int    `json:"type"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+}
+
+// Hue API error types
+const (
+	errorInvalidJSON     = 2
+	errorNotAvailable    = 3
+	errorMethod          = 4
+	errorParameter       = 6
+	errorInvalidValue    = 7
+	errorDeviceOff       = 201
+	errorInternal        = 901
+	errorMissingArgument = 5
+)
+
+func errorResponse(kind int, address, description string) []interface{} {
+	return []interface{}{map[string]apiError{"error": {kind, address, description}}}
+}
+
+// writeJSON writes response, Hue API reports errors in body with 200 status
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/description.xml" {
+		b.serveDescription(w, r)
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	if path != "api" && !strings.HasPrefix(path, "api/") {
+		http.NotFound(w, r)
+		return
+	}
+	segments := strings.Split(path, "/")[1:]
+	address := "/" + strings.Join(segments, "/")
+
+	methodError := func() {
+		writeJSON(w, errorResponse(errorMethod, address,
+			fmt.Sprintf("method, %s, not available for resource, %s", r.Method, address)))
+	}
+
+	switch {
+	case len(segments) == 0:
+		if r.Method != http.MethodPost {
+			methodError()
+			return
+		}
+		b.createUser(w, r)
+	case len(segments) == 1 && segments[0] == "config":
+		b.serveConfig(w, false)
+	case len(segments) == 1:
+		if r.Method != http.MethodGet {
+			methodError()
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"lights": b.lights(), "groups": struct{}{}, "config": b.config(true),
+			"schedules": struct{}{}, "scenes": struct{}{}, "rules": struct{}{},
+			"sensors": struct{}{}, "resourcelinks": struct{}{},
+		})
+	case len(segments) == 2 && segments[1] == "config":
+		b.serveConfig(w, true)
+	case len(segments) == 2 && segments[1] == "lights":
+		if r.Method != http.MethodGet {
+			methodError()
+			return
+		}
+		writeJSON(w, b.lights())
+	case len(segments) == 2:
+		if r.Method != http.MethodGet {
+			methodError()
+			return
+		}
+		// remaining resources are not emulated
+		writeJSON(w, struct{}{})
+	case len(segments) >= 3 && segments[1] == "lights":
+		entry, ok := b.entry(segments[2])
+		if !ok {
+			resource := "/lights/" + segments[2]
+			writeJSON(w, errorResponse(errorNotAvailable, resource, fmt.Sprintf("resource, %s, not available", resource)))
+			return
+		}
+		switch {
+		case len(segments) == 3 && r.Method == http.MethodGet:
+			writeJSON(w, b.light(entry))
+		case len(segments) == 4 && segments[3] == "state" && r.Method == http.MethodPut:
+			b.setState(w, r, entry, segments[2])
+		default:
+			methodError()
+		}
+	default:
+		writeJSON(w, errorResponse(errorNotAvailable, address, fmt.Sprintf("resource, %s, not available", address)))
+	}
+}
+
+// createUser accepts every pairing request, as if link button was pressed
+func (b *Bridge) createUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DeviceType        string `json:"devicetype"`
+		GenerateClientKey bool   `json:"generateclientkey"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeJSON(w, errorResponse(errorInvalidJSON, "", "body contains invalid json"))
+		return
+	}
+	if req.DeviceType == "" {
+		writeJSON(w, errorResponse(errorMissingArgument, "/", "invalid/missing parameters in body"))
+		return
+	}
+
+	success := map[string]string{"username": randomHex(16)}
+	if req.GenerateClientKey {
+		success["clientkey"] = strings.ToUpper(randomHex(16))
+	}
+	writeJSON(w, []interface{}{map[string]interface{}{"success": success}})
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func (b *Bridge) serveConfig(w http.ResponseWriter, full bool) {
+	writeJSON(w, b.config(full))
+}
+
+// config returns bridge config, public config contains only fields available without pairing
+func (b *Bridge) config(full bool) map[string]interface{} {
+	host, _, _ := net.SplitHostPort(b.host)
+	config := map[string]interface{}{
+		"name":             b.name,
+		"datastoreversion": "98",
+		"swversion":        swVersion,
+		"apiversion":       apiVersion,
+		"mac":              b.mac.String(),
+		"bridgeid":         b.ID(),
+		"factorynew":       false,
+		"replacesbridgeid": nil,
+		"modelid":          modelID,
+		"starterkitid":     "",
+	}
+	if full {
+		now := time.Now()
+		config["ipaddress"] = host
+		config["netmask"] = "255.255.255.0"
+		config["gateway"] = host
+		config["dhcp"] = true
+		config["linkbutton"] = true
+		config["portalservices"] = false
+		config["zigbeechannel"] = 25
+		config["UTC"] = now.UTC().Format("2006-01-02T15:04:05")
+		config["localtime"] = now.Format("2006-01-02T15:04:05")
+		config["timezone"] = "UTC"
+		config["whitelist"] = struct{}{}
+		config["swupdate2"] = map[string]interface{}{"state": "noupdates"}
+	}
+	return config
+}
+
+// lightID returns light ID of registered device, IDs are assigned on first use
+func (b *Bridge) lightID(deviceID string) string {
+	b.idsMtx.Lock()
+	defer b.idsMtx.Unlock()
+
+	if id, ok := b.ids[deviceID]; ok {
+		return id
+	}
+	b.lastID++
+	id := strconv.Itoa(b.lastID)
+	b.ids[deviceID] = id
+	return id
+}
+
+// entries returns registered entries keyed by light ID
+func (b *Bridge) entries() map[string]registry.Entry {
+	// entries are sorted by device ID, so IDs don't depend on order of requests
+	entries := b.registry.Entries()
+	byID := make(map[string]registry.Entry, len(entries))
+	for _, entry := range sortByDeviceID(entries) {
+		byID[b.lightID(entry.ID)] = entry
+	}
+	return byID
+}
+
+// entry returns registered entry by light ID
+func (b *Bridge) entry(id string) (registry.Entry, bool) {
+	entry, ok := b.entries()[id]
+	return entry, ok
+}
+
+// lights returns all lights keyed by light ID, lights are read in parallel as unreachable bulbs
+// delay the response
+func (b *Bridge) lights() map[string]light {
+	var (
+		lights    = make(map[string]light)
+		lightsMtx sync.Mutex
+		jobs      sync.WaitGroup
+	)
+	for id, entry := range b.entries() {
+		jobs.Add(1)
+		go func(id string, entry registry.Entry) {
+			defer jobs.Done()
+			l := b.light(entry)
+
+			lightsMtx.Lock()
+			lights[id] = l
+			lightsMtx.Unlock()
+		}(id, entry)
+	}
+	jobs.Wait()
+	return lights
+}
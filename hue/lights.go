@@ -0,0 +1,506 @@
+package hue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// light is a light resource of Hue API
+type light struct {
+	State            lightState        `json:"state"`
+	SwUpdate         map[string]string `json:"swupdate"`
+	Type             string            `json:"type"`
+	Name             string            `json:"name"`
+	ModelID          string            `json:"modelid"`
+	ManufacturerName string            `json:"manufacturername"`
+	ProductName      string            `json:"productname"`
+	UniqueID         string            `json:"uniqueid"`
+	SwVersion        string            `json:"swversion"`
+}
+
+type lightState struct {
+	On        bool       `json:"on"`
+	Bri       int        `json:"bri"`
+	Hue       int        `json:"hue"`
+	Sat       int        `json:"sat"`
+	Effect    string     `json:"effect"`
+	XY        [2]float64 `json:"xy"`
+	CT        int        `json:"ct"`
+	Alert     string     `json:"alert"`
+	ColorMode string     `json:"colormode"`
+	Mode      string     `json:"mode"`
+	Reachable bool       `json:"reachable"`
+}
+
+// color temperature range of Hue API, in mireds
+const (
+	minMireds = 153
+	maxMireds = 500
+)
+
+func sortByDeviceID(entries []registry.Entry) []registry.Entry {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+// uniqueID returns Hue light unique ID (MAC address and endpoint) derived from device ID
+func uniqueID(deviceID string) string {
+	id, err := strconv.ParseUint(deviceID, 0, 64)
+	if err != nil {
+		var sum uint64
+		for _, c := range deviceID {
+			sum = sum*31 + uint64(c)
+		}
+		id = sum
+	}
+	return fmt.Sprintf("00:17:88:01:%02x:%02x:%02x:%02x-0b", byte(id>>24), byte(id>>16), byte(id>>8), byte(id))
+}
+
+// bulb returns connected bulb with enabled state cache, so polling apps don't consume quota
+func (b *Bridge) bulb(entry registry.Entry) (*yl.Bulb, error) {
+	bulb, err := b.registry.Bulb(entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := bulb.State(); err == nil {
+		return bulb, nil
+	}
+	if err := bulb.EnableStateCache(time.Minute); err != nil {
+		_ = b.registry.Disconnect(entry.ID)
+		return nil, err
+	}
+	return bulb, nil
+}
+
+// light returns light resource of registered bulb, unreachable bulb is reported as turned off
+func (b *Bridge) light(entry registry.Entry) light {
+	l := light{
+		State:            lightState{Alert: "none", Effect: "none", ColorMode: "ct", CT: 366, Mode: "homeautomation"},
+		SwUpdate:         map[string]string{"state": "noupdates", "lastinstall": ""},
+		Type:             "Extended color light",
+		Name:             entry.Name,
+		ModelID:          "LCT015",
+		ManufacturerName: "Signify Netherlands B.V.", // some apps accept only lights of genuine manufacturer
+		ProductName:      "Hue color lamp",
+		UniqueID:         uniqueID(entry.ID),
+		SwVersion:        "1.46.13_r26312",
+	}
+
+	bulb, err := b.bulb(entry)
+	if err != nil {
+		return l
+	}
+	state, err := bulb.State()
+	if err != nil {
+		return l
+	}
+	l.State = newLightState(state)
+	return l
+}
+
+// newLightState converts cached bulb state into Hue light state
+func newLightState(s yl.State) lightState {
+	state := lightState{
+		On:        s.Get(yl.PROP_POWER) == "on",
+		Alert:     "none",
+		Effect:    "none",
+		Mode:      "homeautomation",
+		Reachable: true,
+	}
+	if bright, err := s.Int(yl.PROP_BRIGHT); err == nil {
+		state.Bri = toBri(bright)
+	}
+
+	var color yl.Color
+	switch s.Get(yl.PROP_COLOR_MODE) {
+	case "2":
+		ct, _ := s.Int(yl.PROP_CT)
+		color = yl.ColorFromKelvin(ct)
+		state.ColorMode = "ct"
+	case "3":
+		hue, _ := s.Int(yl.PROP_HUE)
+		sat, _ := s.Int(yl.PROP_SAT)
+		color = yl.ColorFromHSV(float64(hue), float64(sat), 100)
+		state.ColorMode = "hs"
+	default:
+		rgb, _ := s.Int(yl.PROP_RGB)
+		color = yl.ColorFromRGB(rgb)
+		state.ColorMode = "xy"
+	}
+
+	hue, sat, _ := color.HSV()
+	state.Hue = int(math.Round(hue * 65535 / 360))
+	state.Sat = int(math.Round(sat * 254 / 100))
+	x, y := color.XY()
+	state.XY = [2]float64{math.Round(x*10000) / 10000, math.Round(y*10000) / 10000}
+	if kelvin := color.Kelvin(); kelvin > 0 {
+		state.CT = clamp(int(math.Round(1e6/float64(kelvin))), minMireds, maxMireds)
+	}
+	if s.Get(yl.PROP_FLOWING) == "1" {
+		state.Effect = "colorloop"
+	}
+	return state
+}
+
+// toBri converts brightness percentage into Hue brightness (1-254)
+func toBri(bright int) int {
+	return clamp(int(math.Round(float64(bright)*254/100)), 1, 254)
+}
+
+// fromBri converts Hue brightness into brightness percentage
+func fromBri(bri int) int {
+	return clamp(int(math.Round(float64(bri)*100/254)), 1, 100)
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// degrees converts Hue API hue (0-65535) into degrees (0-359), both ends of the range are red
+func degrees(hue int) int {
+	return int(math.Round(float64(hue)*360/65535)) % 360
+}
+
+// stateRequest is a body of light state PUT, nil fields are not requested
+type stateRequest struct {
+	On             *bool
+	Bri            *int
+	BriInc         *int
+	Hue            *int
+	Sat            *int
+	CT             *int
+	XY             *[2]float64
+	TransitionTime *int // multiple of 100 ms
+	Alert          *string
+	Effect         *string
+
+	order []string // parameters in order of appearance, responses are returned in the same order
+}
+
+// parseStateRequest decodes request body, Hue API errors are returned for invalid parameters
+func parseStateRequest(body []byte, address string) (stateRequest, []interface{}) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return stateRequest{}, errorResponse(errorInvalidJSON, "", "body contains invalid json")
+	}
+
+	var (
+		req    stateRequest
+		errors []interface{}
+	)
+	targets := map[string]interface{}{
+		"on": &req.On, "bri": &req.Bri, "bri_inc": &req.BriInc, "hue": &req.Hue, "sat": &req.Sat,
+		"ct": &req.CT, "xy": &req.XY, "transitiontime": &req.TransitionTime, "alert": &req.Alert, "effect": &req.Effect,
+	}
+	for _, key := range keysInOrder(body) {
+		target, ok := targets[key]
+		if !ok {
+			errors = append(errors, errorResponse(errorParameter, address+"/"+key,
+				fmt.Sprintf("parameter, %s, not available", key))...)
+			continue
+		}
+		if err := json.Unmarshal(raw[key], target); err != nil || !validValue(key, target) {
+			errors = append(errors, errorResponse(errorInvalidValue, address+"/"+key,
+				fmt.Sprintf("invalid value, %s, for parameter, %s", raw[key], key))...)
+			continue
+		}
+		if key != "transitiontime" {
+			req.order = append(req.order, key)
+		}
+	}
+	return req, errors
+}
+
+// keysInOrder returns keys of JSON object in order of appearance
+func keysInOrder(body []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if _, err := decoder.Token(); err != nil {
+		return nil
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		key, _ := token.(string)
+		keys = append(keys, key)
+
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+// validValue checks ranges of decoded parameter
+func validValue(key string, target interface{}) bool {
+	in := func(v **int, min, max int) bool { return *v != nil && **v >= min && **v <= max }
+	switch key {
+	case "bri":
+		return in(target.(**int), 1, 254)
+	case "bri_inc":
+		return in(target.(**int), -254, 254)
+	case "hue":
+		return in(target.(**int), 0, 65535)
+	case "sat":
+		return in(target.(**int), 0, 254)
+	case "ct":
+		return in(target.(**int), minMireds, maxMireds)
+	case "transitiontime":
+		return in(target.(**int), 0, 65535)
+	case "xy":
+		xy := *target.(**[2]float64)
+		return xy != nil && xy[0] >= 0 && xy[0] <= 1 && xy[1] >= 0 && xy[1] <= 1
+	case "alert":
+		alert := *target.(**string)
+		return alert != nil && (*alert == "none" || *alert == "select" || *alert == "lselect")
+	case "effect":
+		effect := *target.(**string)
+		return effect != nil && (*effect == "none" || *effect == "colorloop")
+	case "on":
+		return *target.(**bool) != nil
+	}
+	return false
+}
+
+// transition returns transition duration, Hue default is 400 ms
+func (r stateRequest) transition() time.Duration {
+	if r.TransitionTime == nil {
+		return 400 * time.Millisecond
+	}
+	if *r.TransitionTime == 0 {
+		return 0
+	}
+	return time.Duration(*r.TransitionTime) * 100 * time.Millisecond
+}
+
+// setState executes light state PUT and responds with result of every parameter
+func (b *Bridge) setState(w http.ResponseWriter, r *http.Request, entry registry.Entry, id string) {
+	address := "/lights/" + id + "/state"
+	body := new(bytes.Buffer)
+	if _, err := body.ReadFrom(http.MaxBytesReader(nil, r.Body, 1<<16)); err != nil {
+		writeJSON(w, errorResponse(errorInvalidJSON, "", "body contains invalid json"))
+		return
+	}
+	req, response := parseStateRequest(body.Bytes(), address)
+	if len(req.order) == 0 {
+		if len(response) == 0 {
+			response = errorResponse(errorMissingArgument, address, "invalid/missing parameters in body")
+		}
+		writeJSON(w, response)
+		return
+	}
+
+	bulb, err := b.bulb(entry)
+	if err != nil {
+		for _, key := range req.order {
+			response = append(response, errorResponse(errorDeviceOff, address+"/"+key,
+				fmt.Sprintf("parameter, %s, is not modifiable. Device is unreachable.", key))...)
+		}
+		writeJSON(w, response)
+		return
+	}
+	yl.WaitQuota()
+
+	results := req.execute(bulb)
+	for _, key := range req.order {
+		result := results[key]
+		if result.err == yl.ErrConnectionClosed {
+			// reconnected by the next request
+			_ = b.registry.Disconnect(entry.ID)
+		}
+		switch {
+		case result.err == errDeviceOff:
+			response = append(response, errorResponse(errorDeviceOff, address+"/"+key,
+				fmt.Sprintf("parameter, %s, is not modifiable. Device is set to off.", key))...)
+		case result.err != nil:
+			response = append(response, errorResponse(errorInternal, address+"/"+key,
+				fmt.Sprintf("Internal error, %v", result.err))...)
+		default:
+			response = append(response, map[string]interface{}{
+				"success": map[string]interface{}{address + "/" + key: result.value},
+			})
+		}
+	}
+	writeJSON(w, response)
+}
+
+// result is a result of a single parameter
+type result struct {
+	value interface{}
+	err   error
+}
+
+var errDeviceOff = fmt.Errorf("device is set to off")
+
+// controller is implemented by *yl.Bulb
+type controller interface {
+	State() (yl.State, error)
+	SetPower(on bool, d time.Duration) error
+	SetBrightness(brightness int, d time.Duration) error
+	SetHSV(hue, saturation int, d time.Duration) error
+	SetTemperature(temp int, d time.Duration) error
+	SetColor(color yl.Color, d time.Duration) error
+	StartColorFlow(count int, action yl.CfAction, flowExpression yl.FlowExpression) error
+	StopColorFlow() error
+}
+
+// execute sends commands to the bulb, results are keyed by parameter name. Color is set by xy, ct or hue
+// with sat (in order of precedence, as by Hue bridge). Turned off light accepts power commands only
+func (r stateRequest) execute(bulb controller) map[string]result {
+	var (
+		d       = r.transition()
+		results = make(map[string]result)
+		state   yl.State
+	)
+	if cached, err := bulb.State(); err == nil {
+		state = cached
+	}
+	on := state.Get(yl.PROP_POWER) == "on"
+
+	if r.On != nil {
+		err := bulb.SetPower(*r.On, d)
+		results["on"] = result{*r.On, err}
+		if err == nil {
+			on = *r.On
+		}
+	}
+	skip := func(keys ...string) {
+		for _, key := range keys {
+			if _, ok := results[key]; !ok {
+				results[key] = result{err: errDeviceOff}
+			}
+		}
+	}
+	if !on {
+		skip(r.order...)
+		return results
+	}
+
+	switch {
+	case r.XY != nil:
+		err := bulb.SetColor(yl.ColorFromXY(r.XY[0], r.XY[1]), d)
+		results["xy"] = result{*r.XY, err}
+	case r.CT != nil:
+		err := bulb.SetTemperature(clamp(int(math.Round(1e6/float64(*r.CT))), 1700, 6500), d)
+		results["ct"] = result{*r.CT, err}
+	case r.Hue != nil || r.Sat != nil:
+		current := newLightState(state)
+		hue, sat := current.Hue, current.Sat
+		if r.Hue != nil {
+			hue = *r.Hue
+		}
+		if r.Sat != nil {
+			sat = *r.Sat
+		}
+		err := bulb.SetHSV(degrees(hue), int(math.Round(float64(sat)*100/254)), d)
+		if r.Hue != nil {
+			results["hue"] = result{*r.Hue, err}
+		}
+		if r.Sat != nil {
+			results["sat"] = result{*r.Sat, err}
+		}
+	}
+	// color parameters overridden by higher precedence are reported as accepted
+	for _, key := range []string{"ct", "hue", "sat"} {
+		if _, ok := results[key]; !ok {
+			switch key {
+			case "ct":
+				if r.CT != nil {
+					results[key] = result{value: *r.CT}
+				}
+			case "hue":
+				if r.Hue != nil {
+					results[key] = result{value: *r.Hue}
+				}
+			case "sat":
+				if r.Sat != nil {
+					results[key] = result{value: *r.Sat}
+				}
+			}
+		}
+	}
+
+	if r.Bri != nil || r.BriInc != nil {
+		bri := toBri(100)
+		if current, err := state.Int(yl.PROP_BRIGHT); err == nil {
+			bri = toBri(current)
+		}
+		key := "bri_inc"
+		if r.Bri != nil {
+			bri, key = *r.Bri, "bri"
+		} else {
+			bri = clamp(bri+*r.BriInc, 1, 254)
+		}
+		err := bulb.SetBrightness(fromBri(bri), d)
+		results[key] = result{bri, err}
+		if r.Bri != nil && r.BriInc != nil {
+			results["bri_inc"] = result{value: *r.BriInc}
+		}
+	}
+
+	if r.Effect != nil {
+		var err error
+		if *r.Effect == "colorloop" {
+			err = colorLoop(bulb)
+		} else {
+			err = bulb.StopColorFlow()
+		}
+		results["effect"] = result{*r.Effect, err}
+	}
+	if r.Alert != nil {
+		var err error
+		if *r.Alert != "none" {
+			err = alert(bulb, *r.Alert == "lselect")
+		}
+		results["alert"] = result{*r.Alert, err}
+	}
+	return results
+}
+
+// colorLoop starts infinite flow cycling through hues at full saturation
+func colorLoop(bulb controller) error {
+	builder := yl.NewFlowBuilder()
+	for hue := 0; hue < 360; hue += 60 {
+		builder.Color(yl.ColorFromHSV(float64(hue), 100, 100).RGB(), 3000*time.Millisecond, 100)
+	}
+	expression, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	return bulb.StartColorFlow(yl.CF_COUNT_INF, yl.CF_ACTION_RECOVER, expression)
+}
+
+// alert breathes once ("select") or for 15 seconds ("lselect"), previous state is recovered
+func alert(bulb controller, long bool) error {
+	times := 1
+	if long {
+		times = 15
+	}
+	expression, err := yl.NewFlowBuilder().
+		Temperature(4000, 500*time.Millisecond, 100).
+		Temperature(4000, 500*time.Millisecond, 1).
+		Build()
+	if err != nil {
+		return err
+	}
+	// device counts every step as a state change, so count repeats single blink
+	return bulb.StartColorFlow(len(expression.States())*times, yl.CF_ACTION_RECOVER, expression)
+}
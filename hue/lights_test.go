@@ -0,0 +1,76 @@
+package hue
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+func TestDegrees(t *testing.T) {
+	tests := []struct {
+		hue      int
+		expected int
+	}{
+		{0, 0},
+		{65535, 0},
+		{65445, 0},
+		{65400, 359},
+		{21845, 120},
+		{43690, 240},
+	}
+	for _, test := range tests {
+		if result := degrees(test.hue); result != test.expected {
+			t.Errorf("degrees(%d): expected %d, got %d", test.hue, test.expected, result)
+		}
+	}
+
+	// conversion reverses the one used for reported state
+	for hue := 0; hue < 360; hue++ {
+		if result := degrees(int(math.Round(float64(hue) * 65535 / 360))); result != hue {
+			t.Errorf("hue %d converted back to %d", hue, result)
+		}
+	}
+}
+
+// flowRecorder is a controller recording started color flows
+type flowRecorder struct {
+	controller
+	count      int
+	action     yl.CfAction
+	expression yl.FlowExpression
+}
+
+func (r *flowRecorder) StartColorFlow(count int, action yl.CfAction, expression yl.FlowExpression) error {
+	r.count, r.action, r.expression = count, action, expression
+	return nil
+}
+
+func TestAlert(t *testing.T) {
+	tests := []struct {
+		long     bool
+		count    int
+		duration time.Duration
+	}{
+		{false, 2, time.Second},
+		{true, 30, 15 * time.Second},
+	}
+	for _, test := range tests {
+		r := &flowRecorder{}
+		if err := alert(r, test.long); err != nil {
+			t.Fatal(err)
+		}
+		if r.count != test.count || r.action != yl.CF_ACTION_RECOVER {
+			t.Errorf("long %v: expected finite flow of %d states, got count %d, action %d", test.long, test.count, r.count, r.action)
+		}
+		var duration time.Duration
+		states := r.expression.States()
+		for i := 0; i < r.count; i++ {
+			duration += time.Duration(states[i%len(states)].Duration) * time.Millisecond
+		}
+		if duration != test.duration {
+			t.Errorf("long %v: expected alert lasting %v, got %v", test.long, test.duration, duration)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package hue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SSDPAddress is a multicast address of SSDP (UPnP discovery) used by apps searching for bridges
+const SSDPAddress = "239.255.255.250:1900"
+
+// search targets answered by the bridge
+const (
+	searchAll        = "ssdp:all"
+	searchRootDevice = "upnp:rootdevice"
+	searchBasic      = "urn:schemas-upnp-org:device:basic:1"
+)
+
+// ListenSSDP opens multicast listener for ServeSSDP, interface is chosen by system when name is empty
+func ListenSSDP(iface string) (net.PacketConn, error) {
+	address, err := net.ResolveUDPAddr("udp4", SSDPAddress)
+	if err != nil {
+		return nil, err
+	}
+	var netIface *net.Interface
+	if iface != "" {
+		if netIface, err = net.InterfaceByName(iface); err != nil {
+			return nil, err
+		}
+	}
+	return net.ListenMulticastUDP("udp4", netIface, address)
+}
+
+// ServeSSDP answers M-SEARCH requests received on connection until connection is closed,
+// any packet connection may be used, so responder can be tested on loopback
+func (b *Bridge) ServeSSDP(conn net.PacketConn) error {
+	buf := make([]byte, 2048)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		target, ok := parseSearch(buf[:n])
+		if !ok || !b.answers(target) {
+			continue
+		}
+		// responses are sent directly to searching app
+		_, _ = conn.WriteTo(b.searchResponse(target), addr)
+	}
+}
+
+// parseSearch returns search target of M-SEARCH request
+func parseSearch(packet []byte) (string, bool) {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(packet)))
+	if err != nil || req.Method != "M-SEARCH" || req.Header.Get("Man") != `"ssdp:discover"` {
+		return "", false
+	}
+	return req.Header.Get("St"), true
+}
+
+func (b *Bridge) uuid() string {
+	return "2f402f80-da50-11e1-9b23-" + strings.ToLower(strings.Replace(b.mac.String(), ":", "", -1))
+}
+
+func (b *Bridge) answers(target string) bool {
+	switch target {
+	case searchAll, searchRootDevice, searchBasic, "uuid:" + b.uuid():
+		return true
+	}
+	return false
+}
+
+func (b *Bridge) searchResponse(target string) []byte {
+	usn := "uuid:" + b.uuid()
+	if target != usn {
+		if target == searchAll {
+			target = searchRootDevice
+		}
+		usn += "::" + target
+	}
+	return []byte(fmt.Sprintf("HTTP/1.1 200 OK\r\n"+
+		"HOST: %s\r\n"+
+		"EXT:\r\n"+
+		"CACHE-CONTROL: max-age=100\r\n"+
+		"LOCATION: http://%s/description.xml\r\n"+
+		"SERVER: Linux/3.14.0 UPnP/1.0 IpBridge/%s\r\n"+
+		"hue-bridgeid: %s\r\n"+
+		"ST: %s\r\n"+
+		"USN: %s\r\n"+
+		"DATE: %s\r\n"+
+		"\r\n",
+		SSDPAddress, b.host, apiVersion, b.ID(), target, usn, time.Now().UTC().Format(http.TimeFormat)))
+}
+
+// description is UPnP device description, apps verify model name before pairing
+type description struct {
+	XMLName     xml.Name `xml:"urn:schemas-upnp-org:device-1-0 root"`
+	SpecVersion struct {
+		Major int `xml:"major"`
+		Minor int `xml:"minor"`
+	} `xml:"specVersion"`
+	URLBase string `xml:"URLBase"`
+	Device  struct {
+		DeviceType       string `xml:"deviceType"`
+		FriendlyName     string `xml:"friendlyName"`
+		Manufacturer     string `xml:"manufacturer"`
+		ManufacturerURL  string `xml:"manufacturerURL"`
+		ModelDescription string `xml:"modelDescription"`
+		ModelName        string `xml:"modelName"`
+		ModelNumber      string `xml:"modelNumber"`
+		ModelURL         string `xml:"modelURL"`
+		SerialNumber     string `xml:"serialNumber"`
+		UDN              string `xml:"UDN"`
+		PresentationURL  string `xml:"presentationURL"`
+	} `xml:"device"`
+}
+
+func (b *Bridge) serveDescription(w http.ResponseWriter, r *http.Request) {
+	host, _, _ := net.SplitHostPort(b.host)
+
+	var d description
+	d.SpecVersion.Major, d.SpecVersion.Minor = 1, 0
+	d.URLBase = "http://" + b.host + "/"
+	d.Device.DeviceType = searchBasic
+	d.Device.FriendlyName = fmt.Sprintf("%s (%s)", b.name, host)
+	d.Device.Manufacturer = "Signify"
+	d.Device.ManufacturerURL = "http://www.philips-hue.com"
+	d.Device.ModelDescription = "Philips hue Personal Wireless Lighting"
+	d.Device.ModelName = "Philips hue bridge 2015"
+	d.Device.ModelNumber = modelID
+	d.Device.ModelURL = "http://www.philips-hue.com"
+	d.Device.SerialNumber = strings.ToLower(strings.Replace(b.mac.String(), ":", "", -1))
+	d.Device.UDN = "uuid:" + b.uuid()
+	d.Device.PresentationURL = "index.html"
+
+	w.Header().Set("Content-Type", "text/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	_ = encoder.Encode(d)
+}
@@ -0,0 +1,141 @@
+package hue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+func newTestBridge(t *testing.T) *Bridge {
+	bridge, err := New(registry.New(""), "192.168.0.10:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bridge
+}
+
+func TestServeSSDP(t *testing.T) {
+	bridge := newTestBridge(t)
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go bridge.ServeSSDP(conn)
+
+	client, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	search := func(target string) (*http.Response, error) {
+		request := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: 239.255.255.250:1900\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 1\r\n" +
+			"ST: " + target + "\r\n\r\n"
+		if _, err := client.WriteTo([]byte(request), conn.LocalAddr()); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 2048)
+		_ = client.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := client.ReadFrom(buf)
+		if err != nil {
+			return nil, err
+		}
+		return http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+	}
+
+	uuid := "uuid:" + bridge.uuid()
+	tests := []struct {
+		target string
+		st     string
+		usn    string
+	}{
+		{"ssdp:all", "upnp:rootdevice", uuid + "::upnp:rootdevice"},
+		{"upnp:rootdevice", "upnp:rootdevice", uuid + "::upnp:rootdevice"},
+		{"urn:schemas-upnp-org:device:basic:1", "urn:schemas-upnp-org:device:basic:1", uuid + "::urn:schemas-upnp-org:device:basic:1"},
+		{uuid, uuid, uuid},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			resp, err := search(test.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("expected 200, got %d", resp.StatusCode)
+			}
+			if location := resp.Header.Get("Location"); location != "http://192.168.0.10:80/description.xml" {
+				t.Errorf("unexpected location %s", location)
+			}
+			if st, usn := resp.Header.Get("St"), resp.Header.Get("Usn"); st != test.st || usn != test.usn {
+				t.Errorf("unexpected ST %s, USN %s", st, usn)
+			}
+			if id := resp.Header.Get("Hue-Bridgeid"); id != bridge.ID() {
+				t.Errorf("unexpected bridge ID %s", id)
+			}
+		})
+	}
+
+	// other devices are searched, bridge doesn't respond
+	if _, err := search("urn:schemas-upnp-org:device:MediaRenderer:1"); err == nil {
+		t.Error("unexpected response for other search target")
+	}
+}
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet string
+		target string
+		ok     bool
+	}{
+		{"search", "M-SEARCH * HTTP/1.1\r\nMAN: \"ssdp:discover\"\r\nST: ssdp:all\r\n\r\n", "ssdp:all", true},
+		{"notify", "NOTIFY * HTTP/1.1\r\nNT: upnp:rootdevice\r\n\r\n", "", false},
+		{"missing man", "M-SEARCH * HTTP/1.1\r\nST: ssdp:all\r\n\r\n", "", false},
+		{"garbage", "hello", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, ok := parseSearch([]byte(test.packet))
+			if target != test.target || ok != test.ok {
+				t.Errorf("expected %q %v, got %q %v", test.target, test.ok, target, ok)
+			}
+		})
+	}
+}
+
+func TestDescription(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.SetName("Living room")
+
+	w := httptest.NewRecorder()
+	bridge.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/description.xml", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/xml" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.HasPrefix(w.Body.String(), xml.Header) {
+		t.Error("missing XML header")
+	}
+
+	var d description
+	if err := xml.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.URLBase != "http://192.168.0.10:80/" || d.Device.FriendlyName != "Living room (192.168.0.10)" ||
+		d.Device.ModelName != "Philips hue bridge 2015" || d.Device.ModelNumber != modelID ||
+		d.Device.UDN != "uuid:"+bridge.uuid() || d.Device.SerialNumber != strings.ToLower(bridge.ID()[:6]+bridge.ID()[10:]) {
+		t.Errorf("unexpected description: %+v", d)
+	}
+}
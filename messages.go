@@ -1,8 +1,10 @@
 package yeelight
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 )
 
 type Notification struct {
@@ -13,11 +15,12 @@ type Notification struct {
 type Response interface {
 	id() int
 	ok() error
+	result() []string
 }
 
 type OKResponse struct {
-	ID     int      `json:"id"`
-	Result []string `json:"result"`
+	ID     int           `json:"id"`
+	Result []interface{} `json:"result"`
 }
 
 func (r *OKResponse) id() int {
@@ -28,6 +31,15 @@ func (r *OKResponse) ok() error {
 	return nil
 }
 
+// result returns response values as strings, non-string values are encoded to JSON
+func (r *OKResponse) result() []string {
+	var values []string
+	for _, value := range r.Result {
+		values = append(values, stringify(value))
+	}
+	return values
+}
+
 type ERRResponse struct {
 	ID    int                    `json:"id"`
 	Error map[string]interface{} `json:"error"`
@@ -37,6 +49,70 @@ func (r *ERRResponse) id() int {
 	return r.ID
 }
 
+// DeviceError is an error reported by device in response to the command
+type DeviceError struct {
+	Code    int
+	Message string
+}
+
+func (e *DeviceError) Error() string {
+	return fmt.Sprintf("device error %d: %s", e.Code, e.Message)
+}
+
 func (r *ERRResponse) ok() error {
-	return errors.New(fmt.Sprintf("%v", r.Error))
+	code, ok := r.Error["code"].(float64)
+	if !ok {
+		return errors.New(fmt.Sprintf("%v", r.Error["message"]))
+	}
+	return &DeviceError{Code: int(code), Message: fmt.Sprintf("%v", r.Error["message"])}
+}
+
+func (r *ERRResponse) result() []string {
+	return nil
+}
+
+// closedResponse is passed to requests waiting for response when connection is closed
+type closedResponse struct {
+	ID int
+}
+
+func (r *closedResponse) id() int {
+	return r.ID
+}
+
+func (r *closedResponse) ok() error {
+	return ErrConnectionClosed
+}
+
+func (r *closedResponse) result() []string {
+	return nil
+}
+
+// parseNotification decodes notification, values which are not strings are converted to strings
+func parseNotification(data []byte) (Notification, error) {
+	var raw struct {
+		Method string                 `json:"method"`
+		Params map[string]interface{} `json:"params"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Notification{}, err
+	}
+
+	notification := Notification{Method: raw.Method, Params: make(map[string]string)}
+	for key, value := range raw.Params {
+		notification.Params[key] = stringify(value)
+	}
+	return notification, nil
+}
+
+// stringify converts JSON decoded value into string, numbers are formatted without exponent
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
 }
@@ -0,0 +1,224 @@
+package midi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// note modes and controller actions of channels
+var (
+	noteModes         = map[string]bool{"": true, "hue": true, "color": true}
+	controllerActions = map[string]bool{"brightness": true, "hue": true, "saturation": true, "temperature": true}
+)
+
+// Channel maps events of MIDI channel onto target bulbs. Note on sets color and brightness from velocity,
+// when the last held note is released brightness drops to 1 (with release fade). Controllers set
+// brightness, hue, saturation or color temperature of target from controller value
+type Channel struct {
+	Target      string            `json:"target"`            // bulb name or selector, see registry.Select
+	Notes       string            `json:"notes,omitempty"`   // "hue": pitch class picks hue, "color": fixed color, empty ignores notes
+	Color       string            `json:"color,omitempty"`   // color of "color" mode, see yeelight.ParseColor, white by default
+	Release     int               `json:"release,omitempty"` // fade after note off in milliseconds, 0 or at least 30
+	Controllers map[string]string `json:"cc,omitempty"`      // controller number (0-127) to action: brightness, hue, saturation or temperature
+}
+
+// Mapping maps MIDI channels (1-16) onto bulbs
+type Mapping map[int]Channel
+
+// Validate checks channels, modes, colors and controller actions
+func (m Mapping) Validate() error {
+	for number, c := range m {
+		if number < 1 || number > 16 {
+			return fmt.Errorf("channel expected in 1~16 range, got %d", number)
+		}
+		if c.Target == "" {
+			return fmt.Errorf("channel %d: target is required", number)
+		}
+		if !noteModes[c.Notes] {
+			return fmt.Errorf("channel %d: unknown notes mode \"%s\"", number, c.Notes)
+		}
+		if c.Color != "" {
+			if _, err := yl.ParseColor(c.Color); err != nil {
+				return fmt.Errorf("channel %d: %v", number, err)
+			}
+		}
+		if c.Release < 0 || (c.Release > 0 && c.Release < 30) {
+			return fmt.Errorf("channel %d: release has to be 0 or at least 30ms, got %d", number, c.Release)
+		}
+		for controller, action := range c.Controllers {
+			n, err := strconv.Atoi(controller)
+			if err != nil || n < 0 || n > 127 {
+				return fmt.Errorf("channel %d: controller expected in 0~127 range, got \"%s\"", number, controller)
+			}
+			if !controllerActions[action] {
+				return fmt.Errorf("channel %d: unknown controller action \"%s\"", number, action)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadMapping reads mapping from JSON file, keys are channel numbers, example:
+//   {
+//     "1": {"target": "stage/*", "notes": "hue", "release": 300, "cc": {"7": "brightness", "1": "hue"}},
+//     "10": {"target": "office/desk", "notes": "color", "color": "white"}
+//   }
+func LoadMapping(path string) (Mapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Cue is a change of target bulbs at given time
+type Cue struct {
+	At         time.Duration
+	Target     string
+	Color      *yl.Color     // nil keeps color
+	Brightness int           // 0 keeps brightness
+	Fade       time.Duration // transition duration, 0 for sudden change
+}
+
+// String formats cue for logging
+func (c Cue) String() string {
+	s := fmt.Sprintf("%9.3fs %s", c.At.Seconds(), c.Target)
+	if c.Color != nil {
+		s += fmt.Sprintf(" color %s", c.Color)
+	}
+	if c.Brightness != 0 {
+		s += fmt.Sprintf(" brightness %d", c.Brightness)
+	}
+	if c.Fade != 0 {
+		s += fmt.Sprintf(" fade %v", c.Fade)
+	}
+	return s
+}
+
+// Mapper turns events into cues, state of channels (held notes, hue and saturation) is kept between events
+type Mapper struct {
+	mapping  Mapping
+	channels map[int]*channelState
+}
+
+type channelState struct {
+	hue, saturation float64
+	held            map[int]bool
+}
+
+// NewMapper creates mapper of valid mapping (see Mapping.Validate)
+func NewMapper(m Mapping) *Mapper {
+	return &Mapper{mapping: m, channels: make(map[int]*channelState)}
+}
+
+func (m *Mapper) state(channel int) *channelState {
+	s, ok := m.channels[channel]
+	if !ok {
+		s = &channelState{saturation: 100, held: make(map[int]bool)}
+		m.channels[channel] = s
+	}
+	return s
+}
+
+// Map returns cues of event, At of cues is set to Time of event. Events of unmapped channels
+// and events not bound to any action produce no cues
+func (m *Mapper) Map(e Event) []Cue {
+	c, ok := m.mapping[e.Channel]
+	if !ok {
+		return nil
+	}
+	s := m.state(e.Channel)
+	cue := Cue{At: e.Time, Target: c.Target}
+
+	switch e.Type {
+	case NoteOn:
+		if c.Notes == "" {
+			return nil
+		}
+		s.held[e.Key] = true
+		color := yl.ColorFromHSV(float64(e.Key%12)*30, s.saturation, 100)
+		if c.Notes == "color" {
+			color = yl.ColorFromRGB(0xffffff)
+			if c.Color != "" {
+				color, _ = yl.ParseColor(c.Color)
+			}
+		}
+		cue.Color, cue.Brightness = &color, scale(e.Value, 1, 100)
+	case NoteOff:
+		if c.Notes == "" || !s.held[e.Key] {
+			return nil
+		}
+		delete(s.held, e.Key)
+		if len(s.held) > 0 {
+			return nil
+		}
+		cue.Brightness, cue.Fade = 1, time.Duration(c.Release)*time.Millisecond
+	case ControlChange:
+		switch c.Controllers[strconv.Itoa(e.Key)] {
+		case "brightness":
+			cue.Brightness = scale(e.Value, 1, 100)
+		case "hue":
+			s.hue = float64(e.Value) * 359 / 127
+			color := yl.ColorFromHSV(s.hue, s.saturation, 100)
+			cue.Color = &color
+		case "saturation":
+			s.saturation = float64(e.Value) * 100 / 127
+			color := yl.ColorFromHSV(s.hue, s.saturation, 100)
+			cue.Color = &color
+		case "temperature":
+			color := yl.ColorFromKelvin(scale(e.Value, 1700, 6500))
+			cue.Color = &color
+		default:
+			return nil
+		}
+	default:
+		return nil
+	}
+	return []Cue{cue}
+}
+
+// scale maps 7-bit MIDI value onto min~max range
+func scale(value, min, max int) int {
+	return min + int(math.Round(float64(value)*float64(max-min)/127))
+}
+
+// Schedule returns cues of file ordered by time, tempo changes are applied (see File.Events)
+func Schedule(f *File, m Mapping) []Cue {
+	var (
+		mapper = NewMapper(m)
+		cues   []Cue
+	)
+	for _, e := range f.Events() {
+		cues = append(cues, mapper.Map(e)...)
+	}
+	return cues
+}
+
+// Targets returns distinct targets of cues in order of appearance
+func Targets(cues []Cue) []string {
+	var (
+		targets []string
+		seen    = make(map[string]bool)
+	)
+	for _, c := range cues {
+		if !seen[c.Target] {
+			seen[c.Target] = true
+			targets = append(targets, c.Target)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,88 @@
+package midi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping Mapping
+		valid   bool
+	}{
+		{"valid", Mapping{1: {Target: "desk", Notes: "color", Color: "red", Release: 30}}, true},
+		{"channel", Mapping{17: {Target: "desk"}}, false},
+		{"target", Mapping{1: {Notes: "hue"}}, false},
+		{"notes", Mapping{1: {Target: "desk", Notes: "pitch"}}, false},
+		{"color", Mapping{1: {Target: "desk", Notes: "color", Color: "nope"}}, false},
+		{"release", Mapping{1: {Target: "desk", Release: 10}}, false},
+		{"controller", Mapping{1: {Target: "desk", Controllers: map[string]string{"128": "hue"}}}, false},
+		{"action", Mapping{1: {Target: "desk", Controllers: map[string]string{"7": "volume"}}}, false},
+	}
+	for _, test := range tests {
+		if err := test.mapping.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got %v", test.name, test.valid, err)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	f, err := ReadFile("testdata/demo.mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadMapping("testdata/demo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cues := Schedule(f, m)
+	if len(cues) != 26 {
+		t.Fatalf("expected 26 cues, got %d", len(cues))
+	}
+	for i := 1; i < len(cues); i++ {
+		if cues[i].At < cues[i-1].At {
+			t.Fatalf("cues are not ordered by time at %d: %v", i, cues[i])
+		}
+	}
+	if targets := Targets(cues); !reflect.DeepEqual(targets, []string{"lead", "pad"}) {
+		t.Errorf("unexpected targets: %v", targets)
+	}
+
+	expected := []string{
+		"    0.000s lead color hsv(0, 100%, 100%) brightness 79",
+		"    0.000s pad color hsv(0, 100%, 100%)",
+		"    0.250s lead brightness 1 fade 200ms",
+		"    0.500s lead color hsv(120, 100%, 100%) brightness 79",
+	}
+	for i, s := range expected {
+		if cues[i].String() != s {
+			t.Errorf("cue %d: expected \"%s\", got \"%s\"", i, s, cues[i])
+		}
+	}
+	// the last cues are set by controllers of the pad channel
+	if last := cues[len(cues)-1]; last.At != 6*time.Second || last.Target != "pad" || last.Brightness != 100 {
+		t.Errorf("unexpected last cue: %v", last)
+	}
+}
+
+func TestMapperHeldNotes(t *testing.T) {
+	mapper := NewMapper(Mapping{1: {Target: "desk", Notes: "hue", Release: 100}})
+	events := []Event{
+		{Type: NoteOn, Channel: 1, Key: 60, Value: 127},
+		{Type: NoteOn, Channel: 1, Key: 64, Value: 127},
+		{Type: NoteOff, Channel: 1, Key: 60},
+		{Type: NoteOff, Channel: 1, Key: 64},
+		{Type: NoteOn, Channel: 2, Key: 60, Value: 127},
+	}
+	var cues []Cue
+	for _, e := range events {
+		cues = append(cues, mapper.Map(e)...)
+	}
+	// brightness drops only when the last held note is released, unmapped channels are ignored
+	if len(cues) != 3 || cues[2].Brightness != 1 || cues[2].Fade != 100*time.Millisecond {
+		t.Errorf("unexpected cues: %v", cues)
+	}
+}
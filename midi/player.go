@@ -0,0 +1,226 @@
+package midi
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// Player sends cues to bulbs through music mode. Every bulb is updated by its own worker, cues of a bulb
+// arriving faster than update interval are coalesced, so short notes are still visible for an interval
+// and dense controller sweeps don't flood bulbs
+type Player struct {
+	registry *registry.Registry
+	iface    string
+	interval time.Duration
+
+	outputsMtx sync.Mutex
+	outputs    map[string]*output // keyed by device ID
+
+	done    chan struct{}
+	workers sync.WaitGroup
+}
+
+// output is a music mode session of a single bulb with pending values
+type output struct {
+	entry registry.Entry
+
+	mtx    sync.Mutex
+	color  *yl.Color
+	bright int // 0 when not changed
+	fade   time.Duration
+	wake   chan struct{}
+
+	// owned by worker
+	music *yl.Music
+}
+
+// retryDelay is a delay after failed start of music mode
+const retryDelay = 5 * time.Second
+
+// NewPlayer creates player of bulbs from given registry
+func NewPlayer(reg *registry.Registry) *Player {
+	return &Player{
+		registry: reg,
+		interval: 50 * time.Millisecond,
+		outputs:  make(map[string]*output),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetMusicInterface sets network interface used for music mode connections, see registry.Registry.StartMusic
+func (p *Player) SetMusicInterface(iface string) {
+	p.iface = iface
+}
+
+// SetInterval sets minimum interval between updates of a single bulb, 50ms by default
+func (p *Player) SetInterval(interval time.Duration) {
+	p.interval = interval
+}
+
+// Prepare turns bulbs of targets on and starts their music mode sessions, so the first cues
+// aren't delayed. Bulbs which failed are logged, music mode is started again with their first cue
+func (p *Player) Prepare(targets []string) error {
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		entries, err := p.registry.Select(target)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if seen[entry.ID] {
+				continue
+			}
+			seen[entry.ID] = true
+			p.output(entry).update(nil, 0, 0)
+		}
+	}
+	return nil
+}
+
+// Play sends cues at their time relative to the call, cues have to be ordered by time.
+// False is returned when player was closed before all cues were sent
+func (p *Player) Play(cues []Cue) bool {
+	start := time.Now()
+	for _, c := range cues {
+		if wait := c.At - time.Since(start); wait > 0 {
+			select {
+			case <-p.done:
+				return false
+			case <-time.After(wait):
+			}
+		}
+		if err := p.Send(c); err != nil {
+			log.Printf("[midi] %s: %v\n", c.Target, err)
+		}
+	}
+	return true
+}
+
+// Send queues cue immediately, At of cue is ignored
+func (p *Player) Send(c Cue) error {
+	select {
+	case <-p.done:
+		return fmt.Errorf("player closed")
+	default:
+	}
+	entries, err := p.registry.Select(c.Target)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		p.output(entry).update(c.Color, c.Brightness, c.Fade)
+	}
+	return nil
+}
+
+// Close stops workers and music mode of all bulbs, bulbs are left in their current state
+func (p *Player) Close() {
+	select {
+	case <-p.done:
+		return
+	default:
+		close(p.done)
+	}
+	p.workers.Wait()
+}
+
+// Done returns channel closed when player is closed
+func (p *Player) Done() <-chan struct{} {
+	return p.done
+}
+
+// output returns output of bulb, worker is started on first use
+func (p *Player) output(entry registry.Entry) *output {
+	p.outputsMtx.Lock()
+	defer p.outputsMtx.Unlock()
+
+	o, ok := p.outputs[entry.ID]
+	if !ok {
+		o = &output{entry: entry, wake: make(chan struct{}, 1)}
+		p.outputs[entry.ID] = o
+		p.workers.Add(1)
+		go p.run(o)
+	}
+	return o
+}
+
+// update queues values, queued values not sent yet are replaced, fade of the latest cue is used
+func (o *output) update(color *yl.Color, bright int, fade time.Duration) {
+	o.mtx.Lock()
+	if color != nil {
+		o.color = color
+	}
+	if bright != 0 {
+		o.bright = bright
+	}
+	o.fade = fade
+	o.mtx.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Player) run(o *output) {
+	defer p.workers.Done()
+	defer o.stopMusic()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-o.wake:
+		}
+
+		o.mtx.Lock()
+		color, bright, fade := o.color, o.bright, o.fade
+		o.color, o.bright, o.fade = nil, 0, 0
+		o.mtx.Unlock()
+
+		wait := p.interval
+		if err := p.apply(o, color, bright, fade); err != nil {
+			log.Printf("[midi] bulb \"%s\": %v\n", o.entry.Name, err)
+			wait = retryDelay
+		}
+
+		select {
+		case <-p.done:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// apply sends values through music mode, music mode is started when needed
+func (p *Player) apply(o *output, color *yl.Color, bright int, fade time.Duration) error {
+	if o.music == nil {
+		yl.WaitQuota()
+		music, err := p.registry.StartMusic(o.entry.ID, p.iface)
+		if err != nil {
+			return err
+		}
+		o.music = music
+	}
+
+	if color != nil {
+		o.music.SetColor(*color, fade)
+	}
+	if bright != 0 {
+		o.music.SetBrightness(bright, fade)
+	}
+	return nil
+}
+
+func (o *output) stopMusic() {
+	if o.music == nil {
+		return
+	}
+	_ = o.music.Stop()
+	o.music = nil
+}
@@ -0,0 +1,92 @@
+package midi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+// waitCommands waits until device receives commands with given methods, music mode commands are
+// recognized by "music:" prefix
+func waitCommands(t *testing.T, device *yeelighttest.Device, expected ...string) []yeelighttest.Command {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var (
+			commands = device.Commands()
+			received []string
+		)
+		for _, command := range commands {
+			if command.Music {
+				received = append(received, "music:"+command.Method)
+			} else {
+				received = append(received, command.Method)
+			}
+		}
+		if reflect.DeepEqual(received, expected) {
+			return commands
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v, got %v", expected, received)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	f, err := ReadFile("testdata/demo.mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadMapping("testdata/demo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := registry.New("")
+	defer reg.Close()
+	devices := make(map[string]*yeelighttest.Device)
+	for _, name := range []string{"lead", "pad"} {
+		device, err := yeelighttest.NewDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer device.Close()
+		devices[name] = device
+		if err := reg.Set(registry.Entry{ID: device.ID, Name: name, Ip: device.Ip, Port: device.Port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	player := NewPlayer(reg)
+	player.SetInterval(10 * time.Millisecond)
+	defer player.Close()
+
+	// the first half second of the file
+	var cues []Cue
+	for _, c := range Schedule(f, m) {
+		if c.At <= 500*time.Millisecond {
+			cues = append(cues, c)
+		}
+	}
+	if err := player.Prepare(Targets(cues)); err != nil {
+		t.Fatal(err)
+	}
+	if !player.Play(cues) {
+		t.Fatal("player closed while playing")
+	}
+
+	commands := waitCommands(t, devices["lead"], "set_power", "set_music",
+		"music:set_hsv", "music:set_bright", "music:set_bright", "music:set_hsv", "music:set_bright")
+	if hue, bright := commands[5].Params[0], commands[6].Params[0]; hue != float64(120) || bright != float64(79) {
+		t.Errorf("expected hue 120 at 79%%, got %v at %v%%", hue, bright)
+	}
+	waitCommands(t, devices["pad"], "set_power", "set_music", "music:set_hsv", "music:set_hsv")
+
+	player.Close()
+	if err := player.Send(cues[0]); err == nil {
+		t.Error("cue sent to closed player")
+	}
+}
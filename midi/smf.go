@@ -0,0 +1,455 @@
+// Package midi drives bulbs from MIDI: Standard MIDI Files are parsed and scheduled with tempo changes,
+// raw MIDI streams (for instance /dev/snd/midiC1D0 on Linux) are read live. Note and controller events
+// are mapped onto bulbs by Mapping and played in real time through music mode by Player
+package midi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// EventType is a type of MIDI event
+type EventType int
+
+const (
+	NoteOff EventType = iota
+	NoteOn
+	PolyPressure
+	ControlChange
+	ProgramChange
+	ChannelPressure
+	PitchBend
+	SetTempo   // meta event, Tempo is set
+	EndOfTrack // meta event
+	Meta       // other meta events, Meta and Data are set
+	SysEx      // system exclusive, Data is set
+)
+
+func (t EventType) String() string {
+	names := []string{"note off", "note on", "poly pressure", "control change", "program change",
+		"channel pressure", "pitch bend", "set tempo", "end of track", "meta", "sysex"}
+	if t < 0 || int(t) >= len(names) {
+		return fmt.Sprintf("event %d", int(t))
+	}
+	return names[t]
+}
+
+// Event is a MIDI event, note on with zero velocity is reported as NoteOff
+type Event struct {
+	Tick    int64         // absolute time in ticks, files only
+	Time    time.Duration // absolute time computed with tempo changes, see File.Events
+	Type    EventType
+	Channel int    // 1-16, channel events only
+	Key     int    // note of note events and poly pressure, controller of control change, program of program change
+	Value   int    // velocity, controller value, pressure or pitch bend (0-16383, 8192 is center)
+	Tempo   int    // microseconds per quarter note, SetTempo only
+	Meta    byte   // meta event type
+	Data    []byte // payload of meta and sysex events
+}
+
+// File is a Standard MIDI File
+type File struct {
+	Format   int // 0: single track, 1: simultaneous tracks, 2: independent sequences
+	Division int // ticks per quarter note, negative values are SMPTE division (see SMPTE)
+	Tracks   [][]Event
+}
+
+// SMPTE returns frames per second and ticks per frame of SMPTE division, false for metrical division
+func (f *File) SMPTE() (fps, ticksPerFrame int, ok bool) {
+	if f.Division >= 0 {
+		return 0, 0, false
+	}
+	d := uint16(f.Division)
+	return int(-int8(d >> 8)), int(d & 0xff), true
+}
+
+// DefaultTempo is a tempo used until first tempo change, 120 BPM
+const DefaultTempo = 500000
+
+// ReadFile parses Standard MIDI File
+func ReadFile(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	f, err := Parse(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+// Parse parses Standard MIDI File, chunks of unknown type are skipped
+func Parse(r io.Reader) (*File, error) {
+	id, data, err := readChunk(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != "MThd" || len(data) < 6 {
+		return nil, errors.New("not a Standard MIDI File, MThd header expected")
+	}
+	f := &File{
+		Format:   int(binary.BigEndian.Uint16(data[0:2])),
+		Division: int(int16(binary.BigEndian.Uint16(data[4:6]))),
+	}
+	if f.Format > 2 {
+		return nil, fmt.Errorf("unsupported format %d", f.Format)
+	}
+	if f.Division == 0 {
+		return nil, errors.New("invalid division 0")
+	}
+	tracks := int(binary.BigEndian.Uint16(data[2:4]))
+
+	for len(f.Tracks) < tracks {
+		id, data, err := readChunk(r)
+		if err == io.EOF {
+			break // some files declare more tracks than they contain
+		}
+		if err != nil {
+			return nil, err
+		}
+		if id != "MTrk" {
+			continue
+		}
+		track, err := parseTrack(data)
+		if err != nil {
+			return nil, fmt.Errorf("track %d: %v", len(f.Tracks)+1, err)
+		}
+		f.Tracks = append(f.Tracks, track)
+	}
+	return f, nil
+}
+
+func readChunk(r io.Reader) (string, []byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", nil, errors.New("truncated chunk header")
+		}
+		return "", nil, err
+	}
+	length := binary.BigEndian.Uint32(header[4:8])
+	if length > 1<<28 {
+		return "", nil, fmt.Errorf("chunk too large (%d bytes)", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", nil, errors.New("truncated chunk")
+	}
+	return string(header[:4]), data, nil
+}
+
+// parseTrack decodes track events, running status is supported
+func parseTrack(data []byte) ([]Event, error) {
+	var (
+		events  []Event
+		tick    int64
+		running byte
+		r       = bytes.NewReader(data)
+	)
+	for r.Len() > 0 {
+		delta, err := readVLQ(r)
+		if err != nil {
+			return nil, err
+		}
+		tick += int64(delta)
+
+		status, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.New("truncated event")
+		}
+		var event Event
+		switch {
+		case status == 0xff:
+			event, err = readMeta(r)
+		case status == 0xf0 || status == 0xf7:
+			event, err = readSysEx(r)
+		case status < 0x80:
+			// running status, status byte is omitted
+			if running == 0 {
+				return nil, fmt.Errorf("data byte 0x%02x without status", status)
+			}
+			if err := r.UnreadByte(); err != nil {
+				return nil, err
+			}
+			event, err = readChannelEvent(r, running)
+		case status < 0xf0:
+			running = status
+			event, err = readChannelEvent(r, status)
+		default:
+			return nil, fmt.Errorf("unexpected status 0x%02x", status)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		event.Tick = tick
+		events = append(events, event)
+		if event.Type == EndOfTrack {
+			break
+		}
+	}
+	return events, nil
+}
+
+// readVLQ reads variable-length quantity
+func readVLQ(r io.ByteReader) (uint32, error) {
+	var value uint32
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, errors.New("truncated variable-length quantity")
+		}
+		value = value<<7 | uint32(b&0x7f)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, errors.New("variable-length quantity longer than 4 bytes")
+}
+
+func readPayload(r *bytes.Reader) ([]byte, error) {
+	length, err := readVLQ(r)
+	if err != nil {
+		return nil, err
+	}
+	if int(length) > r.Len() {
+		return nil, errors.New("truncated event payload")
+	}
+	data := make([]byte, length)
+	_, _ = r.Read(data)
+	return data, nil
+}
+
+func readMeta(r *bytes.Reader) (Event, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return Event{}, errors.New("truncated meta event")
+	}
+	data, err := readPayload(r)
+	if err != nil {
+		return Event{}, err
+	}
+
+	switch {
+	case kind == 0x51 && len(data) == 3:
+		tempo := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+		if tempo == 0 {
+			return Event{}, errors.New("invalid tempo 0")
+		}
+		return Event{Type: SetTempo, Tempo: tempo, Meta: kind, Data: data}, nil
+	case kind == 0x2f:
+		return Event{Type: EndOfTrack, Meta: kind}, nil
+	}
+	return Event{Type: Meta, Meta: kind, Data: data}, nil
+}
+
+func readSysEx(r *bytes.Reader) (Event, error) {
+	data, err := readPayload(r)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: SysEx, Data: data}, nil
+}
+
+// dataBytes returns number of data bytes of channel message
+func dataBytes(status byte) int {
+	switch status & 0xf0 {
+	case 0xc0, 0xd0:
+		return 1
+	}
+	return 2
+}
+
+func readChannelEvent(r io.ByteReader, status byte) (Event, error) {
+	data := make([]byte, dataBytes(status))
+	for i := range data {
+		b, err := r.ReadByte()
+		if err != nil {
+			return Event{}, errors.New("truncated channel event")
+		}
+		if b >= 0x80 {
+			return Event{}, fmt.Errorf("status byte 0x%02x in place of data byte", b)
+		}
+		data[i] = b
+	}
+	return channelEvent(status, data), nil
+}
+
+// channelEvent decodes channel message of given status and data bytes
+func channelEvent(status byte, data []byte) Event {
+	event := Event{Channel: int(status&0x0f) + 1, Key: int(data[0])}
+	switch status & 0xf0 {
+	case 0x80:
+		event.Type, event.Value = NoteOff, int(data[1])
+	case 0x90:
+		event.Type, event.Value = NoteOn, int(data[1])
+		if event.Value == 0 {
+			event.Type = NoteOff
+		}
+	case 0xa0:
+		event.Type, event.Value = PolyPressure, int(data[1])
+	case 0xb0:
+		event.Type, event.Value = ControlChange, int(data[1])
+	case 0xc0:
+		event.Type = ProgramChange
+	case 0xd0:
+		event.Type, event.Key, event.Value = ChannelPressure, 0, int(data[0])
+	case 0xe0:
+		event.Type, event.Key, event.Value = PitchBend, 0, int(data[1])<<7|int(data[0])
+	}
+	return event
+}
+
+// Events returns events of all tracks ordered by time, Time of events is computed with tempo changes
+// (tempo changes of all tracks are applied, as in format 1 files they're placed in the first track).
+// Tracks of format 2 files are independent sequences, they're played one after another
+func (f *File) Events() []Event {
+	var events []Event
+	if f.Format == 2 {
+		var offset int64
+		for _, track := range f.Tracks {
+			var last int64
+			for _, e := range track {
+				e.Tick += offset
+				events = append(events, e)
+				last = e.Tick
+			}
+			offset = last
+		}
+	} else {
+		for _, track := range f.Tracks {
+			events = append(events, track...)
+		}
+		// stable sort keeps order of simultaneous events of the same track
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Tick < events[j].Tick })
+	}
+
+	fps, ticksPerFrame, smpte := f.SMPTE()
+	var (
+		tempo    = DefaultTempo
+		lastTick int64
+		elapsed  time.Duration
+	)
+	for i := range events {
+		ticks := events[i].Tick - lastTick
+		if smpte {
+			elapsed += time.Duration(ticks) * time.Second / time.Duration(fps*ticksPerFrame)
+		} else {
+			elapsed += time.Duration(ticks) * time.Duration(tempo) * time.Microsecond / time.Duration(f.Division)
+		}
+		lastTick = events[i].Tick
+		events[i].Time = elapsed
+
+		if events[i].Type == SetTempo {
+			tempo = events[i].Tempo
+		}
+	}
+	return events
+}
+
+// Duration returns time of the last event
+func (f *File) Duration() time.Duration {
+	events := f.Events()
+	if len(events) == 0 {
+		return 0
+	}
+	return events[len(events)-1].Time
+}
+
+// WriteTo encodes file, running status is not used. Events are written in given order, end of track
+// is appended to tracks which don't end with it
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	header := make([]byte, 6)
+	binary.BigEndian.PutUint16(header[0:2], uint16(f.Format))
+	binary.BigEndian.PutUint16(header[2:4], uint16(len(f.Tracks)))
+	binary.BigEndian.PutUint16(header[4:6], uint16(int16(f.Division)))
+	writeChunk(&buf, "MThd", header)
+
+	for i, track := range f.Tracks {
+		var (
+			data bytes.Buffer
+			last int64
+		)
+		for _, e := range track {
+			if e.Tick < last {
+				return 0, fmt.Errorf("track %d: events are not ordered by tick", i+1)
+			}
+			writeVLQ(&data, uint32(e.Tick-last))
+			last = e.Tick
+			if err := writeEvent(&data, e); err != nil {
+				return 0, fmt.Errorf("track %d: %v", i+1, err)
+			}
+		}
+		if len(track) == 0 || track[len(track)-1].Type != EndOfTrack {
+			writeVLQ(&data, 0)
+			data.Write([]byte{0xff, 0x2f, 0x00})
+		}
+		writeChunk(&buf, "MTrk", data.Bytes())
+	}
+	return buf.WriteTo(w)
+}
+
+func writeChunk(buf *bytes.Buffer, id string, data []byte) {
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(data)))
+	buf.WriteString(id)
+	buf.Write(length)
+	buf.Write(data)
+}
+
+func writeVLQ(buf *bytes.Buffer, value uint32) {
+	bytes := []byte{byte(value & 0x7f)}
+	for value >>= 7; value > 0; value >>= 7 {
+		bytes = append([]byte{byte(value&0x7f) | 0x80}, bytes...)
+	}
+	buf.Write(bytes)
+}
+
+func writeEvent(buf *bytes.Buffer, e Event) error {
+	if e.Type <= PitchBend && (e.Channel < 1 || e.Channel > 16) {
+		return fmt.Errorf("channel expected in 1~16 range, got %d", e.Channel)
+	}
+	status := byte(e.Channel - 1)
+	switch e.Type {
+	case NoteOff:
+		buf.Write([]byte{0x80 | status, byte(e.Key), byte(e.Value)})
+	case NoteOn:
+		buf.Write([]byte{0x90 | status, byte(e.Key), byte(e.Value)})
+	case PolyPressure:
+		buf.Write([]byte{0xa0 | status, byte(e.Key), byte(e.Value)})
+	case ControlChange:
+		buf.Write([]byte{0xb0 | status, byte(e.Key), byte(e.Value)})
+	case ProgramChange:
+		buf.Write([]byte{0xc0 | status, byte(e.Key)})
+	case ChannelPressure:
+		buf.Write([]byte{0xd0 | status, byte(e.Value)})
+	case PitchBend:
+		buf.Write([]byte{0xe0 | status, byte(e.Value & 0x7f), byte(e.Value >> 7 & 0x7f)})
+	case SetTempo:
+		buf.Write([]byte{0xff, 0x51, 0x03, byte(e.Tempo >> 16), byte(e.Tempo >> 8), byte(e.Tempo)})
+	case EndOfTrack:
+		buf.Write([]byte{0xff, 0x2f, 0x00})
+	case Meta:
+		buf.Write([]byte{0xff, e.Meta})
+		writeVLQ(buf, uint32(len(e.Data)))
+		buf.Write(e.Data)
+	case SysEx:
+		buf.WriteByte(0xf0)
+		writeVLQ(buf, uint32(len(e.Data)))
+		buf.Write(e.Data)
+	default:
+		return fmt.Errorf("unknown event type %d", e.Type)
+	}
+	return nil
+}
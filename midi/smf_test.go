@@ -0,0 +1,82 @@
+package midi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadFile(t *testing.T) {
+	f, err := ReadFile("testdata/demo.mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != 1 || f.Division != 480 || len(f.Tracks) != 3 {
+		t.Fatalf("unexpected header: format %d, division %d, %d tracks", f.Format, f.Division, len(f.Tracks))
+	}
+	if _, _, ok := f.SMPTE(); ok {
+		t.Error("metrical division reported as SMPTE")
+	}
+	// 4 beats at 120 BPM, 4 beats at 60 BPM
+	if d := f.Duration(); d != 6*time.Second {
+		t.Errorf("expected duration 6s, got %v", d)
+	}
+
+	events := f.Events()
+	for i := 1; i < len(events); i++ {
+		if events[i].Time < events[i-1].Time {
+			t.Fatalf("events are not ordered by time at %d: %v after %v", i, events[i].Time, events[i-1].Time)
+		}
+	}
+	// tempo is halved at tick 1920, the next note off is 240 ticks later
+	for _, e := range events {
+		if e.Tick == 2160 && e.Type == NoteOff && e.Time != 2500*time.Millisecond {
+			t.Errorf("expected note off at 2.5s, got %v", e.Time)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	f, err := ReadFile("testdata/demo.mid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, f) {
+		t.Error("file changed after round trip")
+	}
+}
+
+func TestParseRunningStatus(t *testing.T) {
+	track := []byte{
+		0x00, 0x90, 0x3c, 0x64, // note on
+		0x60, 0x3c, 0x00, // running status, note on with zero velocity
+		0x00, 0xff, 0x2f, 0x00, // end of track
+	}
+	data := append([]byte{'M', 'T', 'h', 'd', 0, 0, 0, 6, 0, 0, 0, 1, 0xe7, 0x28, 'M', 'T', 'r', 'k', 0, 0, 0, byte(len(track))}, track...)
+
+	f, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fps, ticksPerFrame, ok := f.SMPTE(); !ok || fps != 25 || ticksPerFrame != 40 {
+		t.Errorf("expected SMPTE 25 fps, 40 ticks per frame, got %d, %d, %v", fps, ticksPerFrame, ok)
+	}
+	events := f.Events()
+	if len(events) != 3 || events[0].Type != NoteOn || events[1].Type != NoteOff || events[1].Key != 60 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	// 96 ticks at 1000 ticks per second
+	if events[1].Time != 96*time.Millisecond {
+		t.Errorf("expected note off at 96ms, got %v", events[1].Time)
+	}
+}
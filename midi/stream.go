@@ -0,0 +1,109 @@
+package midi
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Reader reads events from raw MIDI byte stream, as provided by MIDI devices (/dev/snd/midiC1D0,
+// /dev/midi1) and serial MIDI interfaces. Running status is supported, real-time messages
+// (clock, start, stop, active sensing) and system common messages are skipped
+type Reader struct {
+	r       *bufio.Reader
+	running byte
+}
+
+// NewReader creates reader of raw MIDI stream
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next reads next channel or system exclusive event, Tick and Time of events are not set
+func (r *Reader) Next() (Event, error) {
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return Event{}, err
+		}
+
+		switch {
+		case b >= 0xf8:
+			// real-time messages may appear anywhere, even between data bytes
+			continue
+		case b == 0xf0:
+			r.running = 0
+			data, err := r.readSysEx()
+			if err != nil {
+				return Event{}, err
+			}
+			return Event{Type: SysEx, Data: data}, nil
+		case b > 0xf0:
+			// system common messages cancel running status, their data bytes are skipped below
+			r.running = 0
+			continue
+		case b >= 0x80:
+			r.running = b
+		default:
+			if r.running == 0 {
+				continue // stray data byte
+			}
+			if err := r.r.UnreadByte(); err != nil {
+				return Event{}, err
+			}
+		}
+
+		data, err := r.readData(dataBytes(r.running))
+		if err != nil {
+			return Event{}, err
+		}
+		if data == nil {
+			continue // message interrupted by another status byte
+		}
+		return channelEvent(r.running, data), nil
+	}
+}
+
+// readData reads data bytes of channel message, nil is returned when status byte is found in place
+// of data byte, status byte is left for the next message
+func (r *Reader) readData(n int) ([]byte, error) {
+	data := make([]byte, 0, n)
+	for len(data) < n {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b >= 0xf8 {
+			continue
+		}
+		if b >= 0x80 {
+			return nil, r.r.UnreadByte()
+		}
+		data = append(data, b)
+	}
+	return data, nil
+}
+
+// readSysEx reads system exclusive message until end of exclusive
+func (r *Reader) readSysEx() ([]byte, error) {
+	var data []byte
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case b == 0xf7:
+			return data, nil
+		case b >= 0xf8:
+			continue
+		case b >= 0x80:
+			// unterminated message, status byte starts the next message
+			return data, r.r.UnreadByte()
+		}
+		if len(data) >= 1<<16 {
+			return nil, errors.New("system exclusive message too long")
+		}
+		data = append(data, b)
+	}
+}
@@ -0,0 +1,41 @@
+package midi
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	stream := []byte{
+		0xf8,             // clock
+		0x91, 0x3c, 0x64, // note on
+		0x3e, 0xfe, 0x50, // running status with active sensing between data bytes
+		0xf0, 0x7e, 0x01, 0xf7, // sysex cancels running status
+		0x3c,             // stray data byte
+		0xb1, 0x07, 0x7f, // control change
+		0xe1, 0x00, 0x40, // pitch bend center
+	}
+	expected := []Event{
+		{Type: NoteOn, Channel: 2, Key: 60, Value: 100},
+		{Type: NoteOn, Channel: 2, Key: 62, Value: 80},
+		{Type: SysEx, Data: []byte{0x7e, 0x01}},
+		{Type: ControlChange, Channel: 2, Key: 7, Value: 127},
+		{Type: PitchBend, Channel: 2, Value: 8192},
+	}
+
+	r := NewReader(bytes.NewReader(stream))
+	for i, e := range expected {
+		event, err := r.Next()
+		if err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+		if event.Type != e.Type || event.Channel != e.Channel || event.Key != e.Key || event.Value != e.Value ||
+			!bytes.Equal(event.Data, e.Data) {
+			t.Errorf("event %d: expected %+v, got %+v", i, e, event)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
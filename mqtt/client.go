@@ -0,0 +1,390 @@
+// Package mqtt is a minimal MQTT 3.1.1 client (QoS 0 publishing and subscriptions, retained messages,
+// last will, automatic reconnection) and in-process broker stand-in for testing code using it
+package mqtt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Handler processes received message, handlers of a single client are invoked sequentially
+type Handler func(topic string, payload []byte)
+
+// Client publishes and receives messages, it's implemented by network client (Dial)
+// and clients of in-process LocalBroker
+type Client interface {
+	Publish(topic string, payload []byte, retain bool) error
+	Subscribe(filter string, handler Handler) error
+	Close() error
+}
+
+// Message is a message published by client
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Options configures network client
+type Options struct {
+	ClientID string
+	Username string
+	Password string
+
+	KeepAlive      time.Duration // default: 30 seconds
+	ReconnectDelay time.Duration // delay between reconnection attempts, default: 5 seconds
+
+	// Will is published by broker when client disconnects unexpectedly
+	Will *Message
+	// OnConnect is invoked after every successful connection, subscriptions are already restored
+	OnConnect func()
+}
+
+// ErrNotConnected is returned when message is published while connection to broker is lost
+var ErrNotConnected = errors.New("mqtt: not connected")
+
+// NetClient is a client connected to MQTT broker over TCP, lost connection is reestablished
+type NetClient struct {
+	address string
+	options Options
+
+	mtx      sync.Mutex
+	conn     net.Conn
+	handlers map[string]Handler // keyed by topic filter
+	nextID   uint16
+	closed   bool
+
+	writeMtx sync.Mutex
+	messages chan incoming
+	done     chan struct{}
+}
+
+type incoming struct {
+	topic   string
+	payload []byte
+}
+
+// Dial connects to the broker at given address ("localhost:1883")
+func Dial(address string, options Options) (*NetClient, error) {
+	if options.KeepAlive <= 0 {
+		options.KeepAlive = 30 * time.Second
+	}
+	if options.ReconnectDelay <= 0 {
+		options.ReconnectDelay = 5 * time.Second
+	}
+	if options.ClientID == "" {
+		options.ClientID = fmt.Sprintf("yeelight-%d", time.Now().UnixNano()%1e9)
+	}
+
+	c := &NetClient{
+		address:  address,
+		options:  options,
+		handlers: make(map[string]Handler),
+		messages: make(chan incoming, 64),
+		done:     make(chan struct{}),
+	}
+
+	conn, lost, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	go c.dispatch()
+	go c.run(conn, lost)
+	return c, nil
+}
+
+// connect opens connection and performs CONNECT handshake, returned channel is closed when connection is lost
+func (c *NetClient) connect() (net.Conn, chan struct{}, error) {
+	conn, err := net.DialTimeout("tcp", c.address, 10*time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var flags byte = 0x02 // clean session
+	payload := appendString(nil, c.options.ClientID)
+	if will := c.options.Will; will != nil {
+		flags |= 0x04
+		if will.Retain {
+			flags |= 0x20
+		}
+		payload = appendString(payload, will.Topic)
+		payload = appendBytes(payload, will.Payload)
+	}
+	if c.options.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, c.options.Username)
+		if c.options.Password != "" {
+			flags |= 0x40
+			payload = appendString(payload, c.options.Password)
+		}
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags) // protocol level 4: MQTT 3.1.1
+	body = appendUint16(body, uint16(c.options.KeepAlive/time.Second))
+	body = append(body, payload...)
+
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write(packet{kind: packetConnect, body: body}.encode()); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+	ack, err := readPacket(reader)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if ack.kind != packetConnack || len(ack.body) != 2 {
+		conn.Close()
+		return nil, nil, errors.New("mqtt: unexpected response to CONNECT")
+	}
+	if code := ack.body[1]; code != 0 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("mqtt: connection refused (code %d: %s)", code, connackMessage(code))
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	c.mtx.Lock()
+	c.conn = conn
+	filters := make([]string, 0, len(c.handlers))
+	for filter := range c.handlers {
+		filters = append(filters, filter)
+	}
+	c.mtx.Unlock()
+
+	for _, filter := range filters {
+		if err := c.subscribe(filter); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	lost := make(chan struct{})
+	go c.readPackets(conn, reader, lost)
+
+	if c.options.OnConnect != nil {
+		go c.options.OnConnect()
+	}
+	return conn, lost, nil
+}
+
+func connackMessage(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return "unknown"
+}
+
+// run keeps connection alive and reconnects after connection was lost
+func (c *NetClient) run(conn net.Conn, lost chan struct{}) {
+	for {
+		c.keepAlive(conn, lost)
+
+		c.mtx.Lock()
+		closed := c.closed
+		c.conn = nil
+		c.mtx.Unlock()
+		if closed {
+			return
+		}
+		log.Printf("[mqtt] connection to %s lost, reconnecting...\n", c.address)
+
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(c.options.ReconnectDelay):
+			}
+
+			var err error
+			if conn, lost, err = c.connect(); err == nil {
+				break
+			}
+			log.Printf("[mqtt] reconnecting to %s failed: %v\n", c.address, err)
+		}
+	}
+}
+
+// keepAlive sends ping requests until connection is lost or client is closed
+func (c *NetClient) keepAlive(conn net.Conn, lost chan struct{}) {
+	ticker := time.NewTicker(c.options.KeepAlive / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lost:
+			return
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.write(conn, packet{kind: packetPingreq}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPackets reads packets until connection is closed
+func (c *NetClient) readPackets(conn net.Conn, reader *bufio.Reader, lost chan struct{}) {
+	defer close(lost)
+	defer conn.Close()
+
+	for {
+		// broker is required to respond to pings, silence means connection is broken
+		_ = conn.SetReadDeadline(time.Now().Add(c.options.KeepAlive * 3 / 2))
+		p, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+
+		switch p.kind {
+		case packetPublish:
+			topic, payload, qos, _, id, err := decodePublish(p)
+			if err != nil {
+				return
+			}
+			if qos == 1 {
+				_ = c.write(conn, packet{kind: packetPuback, body: appendUint16(nil, id)})
+			}
+			select {
+			case c.messages <- incoming{topic, payload}:
+			case <-c.done:
+				return
+			}
+		case packetSuback:
+			if len(p.body) >= 3 && p.body[2] == 0x80 {
+				log.Printf("[mqtt] subscription rejected by broker\n")
+			}
+		}
+	}
+}
+
+// dispatch passes received messages to handlers
+func (c *NetClient) dispatch() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case m := <-c.messages:
+			c.mtx.Lock()
+			var handlers []Handler
+			for filter, handler := range c.handlers {
+				if Match(filter, m.topic) {
+					handlers = append(handlers, handler)
+				}
+			}
+			c.mtx.Unlock()
+
+			for _, handler := range handlers {
+				handler(m.topic, m.payload)
+			}
+		}
+	}
+}
+
+func (c *NetClient) write(conn net.Conn, p packet) error {
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := conn.Write(p.encode())
+	if err != nil {
+		conn.Close()
+	}
+	return err
+}
+
+func (c *NetClient) connection() (net.Conn, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.closed {
+		return nil, errors.New("mqtt: client is closed")
+	}
+	if c.conn == nil {
+		return nil, ErrNotConnected
+	}
+	return c.conn, nil
+}
+
+// Publish publishes message with QoS 0, ErrNotConnected is returned while client is reconnecting
+func (c *NetClient) Publish(topic string, payload []byte, retain bool) error {
+	if err := validateTopic(topic); err != nil {
+		return err
+	}
+	conn, err := c.connection()
+	if err != nil {
+		return err
+	}
+	return c.write(conn, publishPacket(topic, payload, 0, retain, 0))
+}
+
+// Subscribe subscribes to topic filter, subscription is restored after reconnection
+func (c *NetClient) Subscribe(filter string, handler Handler) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	c.handlers[filter] = handler
+	c.mtx.Unlock()
+
+	err := c.subscribe(filter)
+	if err == ErrNotConnected {
+		return nil // subscription is sent after reconnection
+	}
+	return err
+}
+
+func (c *NetClient) subscribe(filter string) error {
+	conn, err := c.connection()
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+	id := c.nextID
+	c.mtx.Unlock()
+
+	body := appendUint16(nil, id)
+	body = appendString(body, filter)
+	body = append(body, 0) // QoS 0
+	return c.write(conn, packet{kind: packetSubscribe, flags: 0x02, body: body})
+}
+
+// Close disconnects from the broker, last will is not published
+func (c *NetClient) Close() error {
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		return nil
+	}
+	c.closed = true
+	conn := c.conn
+	c.mtx.Unlock()
+	close(c.done)
+
+	if conn == nil {
+		return nil
+	}
+	_ = c.write(conn, packet{kind: packetDisconnect})
+	return conn.Close()
+}
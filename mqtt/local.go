@@ -0,0 +1,186 @@
+package mqtt
+
+import (
+	"errors"
+	"sync"
+)
+
+// LocalBroker is an in-process stand-in of MQTT broker, it routes messages between its clients
+// and keeps retained messages. It's meant for testing code using Client without network broker
+type LocalBroker struct {
+	mtx      sync.Mutex
+	retained map[string][]byte
+	clients  map[*LocalClient]struct{}
+}
+
+// NewLocalBroker creates empty broker
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{
+		retained: make(map[string][]byte),
+		clients:  make(map[*LocalClient]struct{}),
+	}
+}
+
+// Client creates client connected to the broker
+func (b *LocalBroker) Client() *LocalClient {
+	c := &LocalClient{
+		broker:   b,
+		handlers: make(map[string]Handler),
+		queue:    make(chan incoming, 256),
+		done:     make(chan struct{}),
+	}
+
+	b.mtx.Lock()
+	b.clients[c] = struct{}{}
+	b.mtx.Unlock()
+
+	go c.dispatch()
+	return c
+}
+
+// Retained returns retained message of given topic
+func (b *LocalBroker) Retained(topic string) ([]byte, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+func (b *LocalBroker) publish(topic string, payload []byte, retain bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	payload = append([]byte(nil), payload...)
+	if retain {
+		// empty retained message removes retained message of the topic
+		if len(payload) == 0 {
+			delete(b.retained, topic)
+		} else {
+			b.retained[topic] = payload
+		}
+	}
+
+	for c := range b.clients {
+		c.mtx.Lock()
+		matched := false
+		for filter := range c.handlers {
+			if Match(filter, topic) {
+				matched = true
+				break
+			}
+		}
+		c.mtx.Unlock()
+
+		if matched {
+			c.enqueue(incoming{topic, payload})
+		}
+	}
+}
+
+// LocalClient is a client of LocalBroker
+type LocalClient struct {
+	broker *LocalBroker
+
+	mtx      sync.Mutex
+	handlers map[string]Handler
+	closed   bool
+
+	queue chan incoming
+	done  chan struct{}
+}
+
+func (c *LocalClient) enqueue(m incoming) {
+	select {
+	case c.queue <- m:
+	case <-c.done:
+	}
+}
+
+func (c *LocalClient) dispatch() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case m := <-c.queue:
+			c.mtx.Lock()
+			var handlers []Handler
+			for filter, handler := range c.handlers {
+				if Match(filter, m.topic) {
+					handlers = append(handlers, handler)
+				}
+			}
+			c.mtx.Unlock()
+
+			for _, handler := range handlers {
+				handler(m.topic, m.payload)
+			}
+		}
+	}
+}
+
+var errClientClosed = errors.New("mqtt: client is closed")
+
+// Publish passes message to subscribed clients of the broker
+func (c *LocalClient) Publish(topic string, payload []byte, retain bool) error {
+	if err := validateTopic(topic); err != nil {
+		return err
+	}
+	if c.isClosed() {
+		return errClientClosed
+	}
+	c.broker.publish(topic, payload, retain)
+	return nil
+}
+
+// Subscribe subscribes to topic filter, matching retained messages are delivered immediately
+func (c *LocalClient) Subscribe(filter string, handler Handler) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		return errClientClosed
+	}
+	c.handlers[filter] = handler
+	c.mtx.Unlock()
+
+	c.broker.mtx.Lock()
+	var retained []incoming
+	for topic, payload := range c.broker.retained {
+		if Match(filter, topic) {
+			retained = append(retained, incoming{topic, payload})
+		}
+	}
+	c.broker.mtx.Unlock()
+
+	for _, m := range retained {
+		c.enqueue(m)
+	}
+	return nil
+}
+
+func (c *LocalClient) isClosed() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.closed
+}
+
+// Close disconnects client from the broker
+func (c *LocalClient) Close() error {
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mtx.Unlock()
+
+	c.broker.mtx.Lock()
+	delete(c.broker.clients, c)
+	c.broker.mtx.Unlock()
+	close(c.done)
+	return nil
+}
@@ -0,0 +1,173 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// control packet types (MQTT 3.1.1)
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// maxPacketSize limits size of received packets
+const maxPacketSize = 1 << 20
+
+// packet is a raw control packet
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	// remaining length, variable length encoding
+	var length, multiplier int = 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errors.New("mqtt: malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if length > maxPacketSize {
+		return packet{}, fmt.Errorf("mqtt: packet too large (%d bytes)", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{kind: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+func (p packet) encode() []byte {
+	data := []byte{p.kind<<4 | p.flags}
+	length := len(p.body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		data = append(data, b)
+		if length == 0 {
+			break
+		}
+	}
+	return append(data, p.body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	return appendBytes(b, []byte(s))
+}
+
+func appendBytes(b []byte, data []byte) []byte {
+	b = append(b, byte(len(data)>>8), byte(len(data)))
+	return append(b, data...)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+// reader decodes packet body fields
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) uint16() uint16 {
+	if len(r.data) < 2 {
+		r.err = errors.New("mqtt: malformed packet")
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.data)
+	r.data = r.data[2:]
+	return v
+}
+
+func (r *reader) bytes() []byte {
+	n := int(r.uint16())
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < n {
+		r.err = errors.New("mqtt: malformed packet")
+		return nil
+	}
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *reader) string() string {
+	return string(r.bytes())
+}
+
+func (r *reader) byte() byte {
+	if len(r.data) < 1 {
+		r.err = errors.New("mqtt: malformed packet")
+		return 0
+	}
+	v := r.data[0]
+	r.data = r.data[1:]
+	return v
+}
+
+func (r *reader) rest() []byte {
+	v := r.data
+	r.data = nil
+	return v
+}
+
+// publishPacket encodes PUBLISH packet, packet id is required for QoS 1
+func publishPacket(topic string, payload []byte, qos byte, retain bool, id uint16) packet {
+	flags := qos << 1
+	if retain {
+		flags |= 0x01
+	}
+	body := appendString(nil, topic)
+	if qos > 0 {
+		body = appendUint16(body, id)
+	}
+	return packet{kind: packetPublish, flags: flags, body: append(body, payload...)}
+}
+
+// decodePublish decodes PUBLISH packet body
+func decodePublish(p packet) (topic string, payload []byte, qos byte, retain bool, id uint16, err error) {
+	r := &reader{data: p.body}
+	qos = p.flags >> 1 & 0x03
+	retain = p.flags&0x01 != 0
+	topic = r.string()
+	if qos > 0 {
+		id = r.uint16()
+	}
+	payload = r.rest()
+	return topic, payload, qos, retain, id, r.err
+}
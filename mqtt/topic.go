@@ -0,0 +1,58 @@
+package mqtt
+
+import (
+	"errors"
+	"strings"
+)
+
+// Match returns true when topic matches topic filter, "+" matches single level and "#" remaining levels
+func Match(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	// topics starting with "$" are not matched by wildcards on the first level
+	if strings.HasPrefix(topic, "$") && (filterLevels[0] == "+" || filterLevels[0] == "#") {
+		return false
+	}
+
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// validateFilter checks topic filter syntax
+func validateFilter(filter string) error {
+	if filter == "" {
+		return errors.New("mqtt: empty topic filter")
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return errors.New("mqtt: \"#\" is allowed only as the last level")
+		}
+		if strings.Contains(level, "+") && level != "+" {
+			return errors.New("mqtt: \"+\" has to occupy entire level")
+		}
+	}
+	return nil
+}
+
+// validateTopic checks topic name used for publishing
+func validateTopic(topic string) error {
+	if topic == "" {
+		return errors.New("mqtt: empty topic")
+	}
+	if strings.ContainsAny(topic, "+#") {
+		return errors.New("mqtt: wildcards are not allowed in topic name")
+	}
+	return nil
+}
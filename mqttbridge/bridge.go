@@ -0,0 +1,315 @@
+// Package mqttbridge connects bulbs registered in registry with MQTT broker, in the layout
+// expected by Home Assistant:
+//
+//   yeelight/<id>/state          retained bulb state (see State), published after every change
+//   yeelight/<id>/set            commands (see Command), in Home Assistant JSON light schema
+//   yeelight/<id>/availability   "online" while bulb is connected, "offline" otherwise
+//   yeelight/bridge/availability "online" while bridge is running, should be used as last will
+//
+// Home Assistant MQTT discovery configs are published (retained) to homeassistant/light/<id>/config,
+// so registered bulbs appear in Home Assistant without any configuration.
+// Topic prefixes can be changed with SetPrefix and SetDiscoveryPrefix
+package mqttbridge
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/mqtt"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// Bridge publishes state of registered bulbs and executes received commands
+type Bridge struct {
+	client   mqtt.Client
+	registry *registry.Registry
+
+	prefix          string
+	discoveryPrefix string
+
+	mtx      sync.Mutex
+	monitors map[string]*monitor // keyed by device ID
+	effects  map[string]string   // last started flow preset, keyed by device ID
+}
+
+// monitor keeps connection to a single bulb and executes its commands
+type monitor struct {
+	stop     chan struct{}
+	commands chan []byte
+}
+
+// New creates bridge between given client and registry, see Start
+func New(client mqtt.Client, reg *registry.Registry) *Bridge {
+	return &Bridge{
+		client:          client,
+		registry:        reg,
+		prefix:          "yeelight",
+		discoveryPrefix: "homeassistant",
+		monitors:        make(map[string]*monitor),
+		effects:         make(map[string]string),
+	}
+}
+
+// SetPrefix sets prefix of bulb topics, "yeelight" by default. It has to be called before Start
+func (b *Bridge) SetPrefix(prefix string) {
+	b.prefix = strings.TrimSuffix(prefix, "/")
+}
+
+// SetDiscoveryPrefix sets Home Assistant discovery prefix, "homeassistant" by default,
+// empty prefix disables publishing of discovery configs. It has to be called before Start
+func (b *Bridge) SetDiscoveryPrefix(prefix string) {
+	b.discoveryPrefix = strings.TrimSuffix(prefix, "/")
+}
+
+// AvailabilityTopic returns topic of bridge availability for given prefix, intended for client last will:
+//   mqtt.Options{Will: &mqtt.Message{Topic: mqttbridge.AvailabilityTopic("yeelight"), Payload: []byte("offline"), Retain: true}}
+func AvailabilityTopic(prefix string) string {
+	return strings.TrimSuffix(prefix, "/") + "/bridge/availability"
+}
+
+func (b *Bridge) availabilityTopic() string {
+	return AvailabilityTopic(b.prefix)
+}
+
+func (b *Bridge) topic(id, name string) string {
+	return b.prefix + "/" + id + "/" + name
+}
+
+// Start subscribes to command topics, announces bridge and its bulbs and starts monitoring bulbs
+func (b *Bridge) Start() error {
+	if err := b.client.Subscribe(b.prefix+"/+/set", b.handleCommand); err != nil {
+		return err
+	}
+	if err := b.Announce(); err != nil {
+		return err
+	}
+	b.Sync()
+	return nil
+}
+
+// Announce publishes bridge availability and discovery configs of registered bulbs.
+// It should be called after reconnection to broker, as Home Assistant could be restarted in the meantime
+func (b *Bridge) Announce() error {
+	if err := b.client.Publish(b.availabilityTopic(), []byte("online"), true); err != nil {
+		return err
+	}
+	if b.discoveryPrefix == "" {
+		return nil
+	}
+	for _, entry := range b.registry.Entries() {
+		config, err := b.discovery(entry)
+		if err != nil {
+			return err
+		}
+		if err := b.client.Publish(b.discoveryTopic(entry.ID), config, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bridge) discoveryTopic(id string) string {
+	return b.discoveryPrefix + "/light/" + strings.Replace(id, "/", "_", -1) + "/config"
+}
+
+// Sync starts monitors of bulbs added to registry and stops monitors of removed bulbs,
+// discovery configs of removed bulbs are deleted. It should be called after registry was changed
+func (b *Bridge) Sync() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	registered := make(map[string]bool)
+	for _, entry := range b.registry.Entries() {
+		registered[entry.ID] = true
+		if _, ok := b.monitors[entry.ID]; ok {
+			continue
+		}
+		m := &monitor{stop: make(chan struct{}), commands: make(chan []byte, 16)}
+		b.monitors[entry.ID] = m
+		go b.monitor(entry.ID, m)
+		go b.execute(entry.ID, m)
+	}
+
+	for id, m := range b.monitors {
+		if registered[id] {
+			continue
+		}
+		close(m.stop)
+		delete(b.monitors, id)
+		delete(b.effects, id)
+		if b.discoveryPrefix != "" {
+			// empty retained message removes retained discovery config
+			b.publish(b.discoveryTopic(id), nil)
+		}
+		b.publish(b.topic(id, "availability"), []byte("offline"))
+	}
+}
+
+// Close stops all monitors and marks bridge offline, client is not closed
+func (b *Bridge) Close() error {
+	b.mtx.Lock()
+	for id, m := range b.monitors {
+		close(m.stop)
+		delete(b.monitors, id)
+	}
+	b.mtx.Unlock()
+	return b.client.Publish(b.availabilityTopic(), []byte("offline"), true)
+}
+
+// publish publishes retained message, errors are only logged as bulb state is published again on next change
+func (b *Bridge) publish(topic string, payload []byte) {
+	if err := b.client.Publish(topic, payload, true); err != nil {
+		log.Printf("[mqttbridge] publishing to %s failed: %v\n", topic, err)
+	}
+}
+
+// monitor keeps connection to a single bulb and publishes its state until monitor is stopped
+func (b *Bridge) monitor(id string, m *monitor) {
+	var backoff registry.Backoff
+
+	for {
+		if _, err := b.registry.Lookup(id); err != nil {
+			return
+		}
+
+		bulb, err := b.connect(id)
+		if err != nil {
+			log.Printf("[mqttbridge] %v\n", err)
+			if !backoff.Wait(m.stop) {
+				return
+			}
+			continue
+		}
+		backoff.Reset()
+
+		b.publish(b.topic(id, "availability"), []byte("online"))
+		b.publishState(id, bulb)
+		stopped := !b.forward(id, bulb, m.stop)
+		b.publish(b.topic(id, "availability"), []byte("offline"))
+		if stopped {
+			return
+		}
+
+		_ = b.registry.Disconnect(id)
+		if !backoff.Wait(m.stop) {
+			return
+		}
+	}
+}
+
+// connect returns connected bulb with enabled state cache
+func (b *Bridge) connect(id string) (*yl.Bulb, error) {
+	bulb, err := b.registry.Bulb(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := bulb.State(); err == nil {
+		return bulb, nil
+	}
+	if err := bulb.EnableStateCache(time.Minute); err != nil {
+		_ = b.registry.Disconnect(id)
+		return nil, fmt.Errorf("reading state of %s failed: %v", id, err)
+	}
+	return bulb, nil
+}
+
+// forward publishes bulb state after every notification until connection is closed,
+// false is returned when monitor was stopped
+func (b *Bridge) forward(id string, bulb *yl.Bulb, stop chan struct{}) bool {
+	subscription := bulb.Subscribe()
+	defer subscription.Close()
+
+	for {
+		select {
+		case <-stop:
+			return false
+		case notification, ok := <-subscription.C:
+			if !ok {
+				return true
+			}
+			if notification.Method == "props" {
+				b.publishState(id, bulb)
+			}
+		}
+	}
+}
+
+// publishState publishes cached state of the bulb
+func (b *Bridge) publishState(id string, bulb *yl.Bulb) {
+	cached, err := bulb.State()
+	if err != nil {
+		return
+	}
+
+	b.mtx.Lock()
+	effect := b.effects[id]
+	b.mtx.Unlock()
+
+	b.publish(b.topic(id, "state"), formatState(newState(cached, effect)))
+}
+
+// handleCommand passes received command to monitor of addressed bulb
+func (b *Bridge) handleCommand(topic string, payload []byte) {
+	id := strings.TrimSuffix(strings.TrimPrefix(topic, b.prefix+"/"), "/set")
+
+	b.mtx.Lock()
+	m, ok := b.monitors[id]
+	b.mtx.Unlock()
+	if !ok {
+		log.Printf("[mqttbridge] command for unknown bulb %s ignored\n", id)
+		return
+	}
+
+	select {
+	case m.commands <- payload:
+	default:
+		log.Printf("[mqttbridge] command queue of %s is full, command dropped\n", id)
+	}
+}
+
+// execute executes commands of a single bulb in order of arrival until monitor is stopped
+func (b *Bridge) execute(id string, m *monitor) {
+	for {
+		select {
+		case <-m.stop:
+			return
+		case payload := <-m.commands:
+			if err := b.command(id, payload); err != nil {
+				log.Printf("[mqttbridge] command for %s failed: %v\n", id, err)
+			}
+		}
+	}
+}
+
+func (b *Bridge) command(id string, payload []byte) error {
+	command, err := parseCommand(payload)
+	if err != nil {
+		return err
+	}
+
+	// commands set absolute values, so they can be repeated after connection was found closed
+	err = b.registry.Execute(id, func(bulb *yl.Bulb) error {
+		yl.WaitQuota()
+		return command.execute(bulb)
+	})
+
+	if command.Effect != "" || command.Color != nil || command.ColorTemp != nil || command.State == "OFF" {
+		b.mtx.Lock()
+		if command.Effect != "" && !stopsEffect(command.Effect) && err == nil {
+			b.effects[id] = command.Effect
+		} else {
+			delete(b.effects, id)
+		}
+		b.mtx.Unlock()
+	}
+
+	// state is published even after failure, part of the command could be executed
+	if bulb, connectErr := b.connect(id); connectErr == nil {
+		b.publishState(id, bulb)
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package mqttbridge
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/mqtt"
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+// waitRetained waits until retained message of the topic satisfies given condition
+func waitRetained(t *testing.T, broker *mqtt.LocalBroker, topic string, ok func(payload []byte) bool) []byte {
+	for start := time.Now(); ; time.Sleep(5 * time.Millisecond) {
+		if payload, retained := broker.Retained(topic); retained && ok(payload) {
+			return payload
+		}
+		if time.Since(start) > 2*time.Second {
+			payload, _ := broker.Retained(topic)
+			t.Fatalf("%s: unexpected retained message %q", topic, payload)
+		}
+	}
+}
+
+func equals(expected string) func(payload []byte) bool {
+	return func(payload []byte) bool {
+		return string(payload) == expected
+	}
+}
+
+// state returns condition matching state payload
+func state(ok func(s State) bool) func(payload []byte) bool {
+	return func(payload []byte) bool {
+		var s State
+		return json.Unmarshal(payload, &s) == nil && ok(s)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	reg := registry.New("")
+	defer reg.Close()
+	if err := reg.Set(registry.Entry{ID: device.ID, Name: "desk", Room: "office", Ip: device.Ip, Port: device.Port}); err != nil {
+		t.Fatal(err)
+	}
+
+	broker := mqtt.NewLocalBroker()
+	bridge := New(broker.Client(), reg)
+	if err := bridge.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// discovery config and availability
+	waitRetained(t, broker, "yeelight/bridge/availability", equals("online"))
+	waitRetained(t, broker, "yeelight/"+device.ID+"/availability", equals("online"))
+	payload := waitRetained(t, broker, "homeassistant/light/"+device.ID+"/config", func([]byte) bool { return true })
+	var config discoveryConfig
+	if err := json.Unmarshal(payload, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "desk" || config.UniqueID != "yeelight_"+device.ID || config.Schema != "json" ||
+		config.CommandTopic != "yeelight/"+device.ID+"/set" || config.StateTopic != "yeelight/"+device.ID+"/state" ||
+		len(config.Availability) != 2 || config.Device.SuggestedArea != "office" {
+		t.Errorf("unexpected discovery config: %s", payload)
+	}
+
+	// initial state
+	waitRetained(t, broker, "yeelight/"+device.ID+"/state", state(func(s State) bool {
+		return s.State == "OFF" && s.ColorMode == "color_temp" && s.ColorTemp == 250
+	}))
+
+	// command received on command topic
+	client := broker.Client()
+	defer client.Close()
+	command := `{"state": "ON", "brightness": 30, "color": {"r": 255, "g": 128, "b": 0}}`
+	if err := client.Publish("yeelight/"+device.ID+"/set", []byte(command), false); err != nil {
+		t.Fatal(err)
+	}
+	waitRetained(t, broker, "yeelight/"+device.ID+"/state", state(func(s State) bool {
+		return s.State == "ON" && s.Brightness == 30 && s.ColorMode == "rgb" && *s.Color.R == 255 && *s.Color.G == 128
+	}))
+	if device.Prop(yl.PROP_RGB) != "16744448" || device.Prop(yl.PROP_BRIGHT) != "30" {
+		t.Errorf("command wasn't executed: %+v", device.Commands())
+	}
+
+	// change made by other client is published
+	device.SetProp(yl.PROP_BRIGHT, "75")
+	waitRetained(t, broker, "yeelight/"+device.ID+"/state", state(func(s State) bool {
+		return s.Brightness == 75
+	}))
+
+	// effect is reported while flow is running
+	if err := client.Publish("yeelight/"+device.ID+"/set", []byte(`{"effect": "candle"}`), false); err != nil {
+		t.Fatal(err)
+	}
+	waitRetained(t, broker, "yeelight/"+device.ID+"/state", state(func(s State) bool {
+		return s.Effect == "candle"
+	}))
+
+	// bulb removed from registry loses discovery config
+	reg.Remove(device.ID)
+	bridge.Sync()
+	if _, ok := broker.Retained("homeassistant/light/" + device.ID + "/config"); ok {
+		t.Error("discovery config of removed bulb wasn't deleted")
+	}
+	waitRetained(t, broker, "yeelight/"+device.ID+"/availability", equals("offline"))
+
+	if err := bridge.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitRetained(t, broker, "yeelight/bridge/availability", equals("offline"))
+}
+
+func TestBridgeReconnect(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	reg := registry.New("")
+	defer reg.Close()
+	if err := reg.Set(registry.Entry{ID: device.ID, Name: "desk", Ip: device.Ip, Port: device.Port}); err != nil {
+		t.Fatal(err)
+	}
+
+	broker := mqtt.NewLocalBroker()
+	bridge := New(broker.Client(), reg)
+	bridge.SetPrefix("lights/")
+	bridge.SetDiscoveryPrefix("")
+	if err := bridge.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+	waitRetained(t, broker, "lights/"+device.ID+"/availability", equals("online"))
+	if _, ok := broker.Retained("homeassistant/light/" + device.ID + "/config"); ok {
+		t.Error("discovery config published with disabled discovery")
+	}
+
+	// command sent after connection was lost is executed on a new connection
+	device.DropConnections()
+	client := broker.Client()
+	defer client.Close()
+	if err := client.Publish("lights/"+device.ID+"/set", []byte(`{"state": "ON"}`), false); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); device.Prop(yl.PROP_POWER) != "on"; time.Sleep(5 * time.Millisecond) {
+		if time.Since(start) > 3*time.Second {
+			t.Fatalf("command wasn't executed: %+v", device.Commands())
+		}
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		valid   bool
+	}{
+		{"state", `{"state": "ON"}`, true},
+		{"invalid state", `{"state": "on"}`, false},
+		{"negative transition", `{"transition": -1}`, false},
+		{"rgb", `{"color": {"r": 1, "g": 2, "b": 3}}`, true},
+		{"hs", `{"color": {"h": 120, "s": 50}}`, true},
+		{"incomplete color", `{"color": {"r": 1, "g": 2}}`, false},
+		{"preset", `{"effect": "candle"}`, true},
+		{"stop effect", `{"effect": "none"}`, true},
+		{"unknown effect", `{"effect": "nope"}`, false},
+		{"invalid json", `{`, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseCommand([]byte(test.payload)); (err == nil) != test.valid {
+				t.Errorf("expected valid %v, got %v", test.valid, err)
+			}
+		})
+	}
+
+	if kelvin(370) != 2703 || kelvin(100) != 6500 || kelvin(1000) != 1700 || kelvin(0) != 6500 {
+		t.Error("unexpected mireds conversion")
+	}
+}
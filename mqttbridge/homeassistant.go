@@ -0,0 +1,272 @@
+package mqttbridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// color temperature range supported by bulbs, in mireds
+const (
+	minMireds = 154 // 6500K
+	maxMireds = 588 // 1700K
+)
+
+// Color is a color of Home Assistant JSON light schema, RGB or hue/saturation fields are set
+type Color struct {
+	R *int     `json:"r,omitempty"`
+	G *int     `json:"g,omitempty"`
+	B *int     `json:"b,omitempty"`
+	H *float64 `json:"h,omitempty"`
+	S *float64 `json:"s,omitempty"`
+}
+
+// State is a bulb state published to state topic, in Home Assistant JSON light schema
+type State struct {
+	State      string `json:"state"`                // "ON" or "OFF"
+	Brightness int    `json:"brightness,omitempty"` // 1~100
+	ColorMode  string `json:"color_mode,omitempty"` // "rgb", "hs" or "color_temp"
+	ColorTemp  int    `json:"color_temp,omitempty"` // mireds
+	Color      *Color `json:"color,omitempty"`
+	Effect     string `json:"effect,omitempty"` // name of running flow preset
+}
+
+// Command is a command received on command topic, in Home Assistant JSON light schema
+type Command struct {
+	State      string   `json:"state,omitempty"`      // "ON" or "OFF"
+	Brightness *int     `json:"brightness,omitempty"` // 1~100
+	ColorTemp  *int     `json:"color_temp,omitempty"` // mireds
+	Color      *Color   `json:"color,omitempty"`
+	Effect     string   `json:"effect,omitempty"`     // flow preset name (see flows.Names), "none" stops flow
+	Transition *float64 `json:"transition,omitempty"` // seconds
+}
+
+// discoveryConfig is a Home Assistant MQTT discovery config of a light
+type discoveryConfig struct {
+	Name                string         `json:"name"`
+	UniqueID            string         `json:"unique_id"`
+	Schema              string         `json:"schema"`
+	CommandTopic        string         `json:"command_topic"`
+	StateTopic          string         `json:"state_topic"`
+	Availability        []availability `json:"availability"`
+	AvailabilityMode    string         `json:"availability_mode"`
+	Brightness          bool           `json:"brightness"`
+	BrightnessScale     int            `json:"brightness_scale"`
+	SupportedColorModes []string       `json:"supported_color_modes"`
+	MinMireds           int            `json:"min_mireds"`
+	MaxMireds           int            `json:"max_mireds"`
+	Effect              bool           `json:"effect"`
+	EffectList          []string       `json:"effect_list"`
+	Device              device         `json:"device"`
+}
+
+type availability struct {
+	Topic string `json:"topic"`
+}
+
+type device struct {
+	Identifiers   []string `json:"identifiers"`
+	Name          string   `json:"name"`
+	Manufacturer  string   `json:"manufacturer"`
+	SuggestedArea string   `json:"suggested_area,omitempty"`
+}
+
+// discovery builds discovery config of registered bulb
+func (b *Bridge) discovery(entry registry.Entry) ([]byte, error) {
+	return json.Marshal(discoveryConfig{
+		Name:         entry.Name,
+		UniqueID:     "yeelight_" + entry.ID,
+		Schema:       "json",
+		CommandTopic: b.topic(entry.ID, "set"),
+		StateTopic:   b.topic(entry.ID, "state"),
+		Availability: []availability{
+			{Topic: b.availabilityTopic()},
+			{Topic: b.topic(entry.ID, "availability")},
+		},
+		AvailabilityMode:    "all",
+		Brightness:          true,
+		BrightnessScale:     100,
+		SupportedColorModes: []string{"color_temp", "hs", "rgb"},
+		MinMireds:           minMireds,
+		MaxMireds:           maxMireds,
+		Effect:              true,
+		EffectList:          append(flows.Names(), "none"),
+		Device: device{
+			Identifiers:   []string{entry.ID},
+			Name:          entry.Name,
+			Manufacturer:  "Yeelight",
+			SuggestedArea: entry.Room,
+		},
+	})
+}
+
+// newState converts cached bulb state, effect is a name of last started preset
+func newState(s yl.State, effect string) State {
+	state := State{State: "OFF"}
+	if s.Get(yl.PROP_POWER) == "on" {
+		state.State = "ON"
+	}
+	if bright, err := s.Int(yl.PROP_BRIGHT); err == nil {
+		state.Brightness = bright
+	}
+
+	switch s.Get(yl.PROP_COLOR_MODE) {
+	case "1":
+		if rgb, err := s.Int(yl.PROP_RGB); err == nil {
+			r, g, b := rgb>>16&0xff, rgb>>8&0xff, rgb&0xff
+			state.ColorMode = "rgb"
+			state.Color = &Color{R: &r, G: &g, B: &b}
+		}
+	case "2":
+		if ct, err := s.Int(yl.PROP_CT); err == nil && ct > 0 {
+			state.ColorMode = "color_temp"
+			state.ColorTemp = int(math.Round(1e6 / float64(ct)))
+		}
+	case "3":
+		hue, hueErr := s.Int(yl.PROP_HUE)
+		sat, satErr := s.Int(yl.PROP_SAT)
+		if hueErr == nil && satErr == nil {
+			h, s := float64(hue), float64(sat)
+			state.ColorMode = "hs"
+			state.Color = &Color{H: &h, S: &s}
+		}
+	}
+
+	if s.Get(yl.PROP_FLOWING) == "1" {
+		state.Effect = effect
+	}
+	return state
+}
+
+// controller is implemented by *yl.Bulb
+type controller interface {
+	SetPower(on bool, d time.Duration) error
+	SetBrightness(brightness int, d time.Duration) error
+	SetTemperature(temp int, d time.Duration) error
+	SetRGB(rgb int, d time.Duration) error
+	SetHSV(hue, saturation int, d time.Duration) error
+	StartColorFlow(count int, action yl.CfAction, flowExpression yl.FlowExpression) error
+	StopColorFlow() error
+}
+
+// parseCommand decodes and validates command payload
+func parseCommand(payload []byte) (Command, error) {
+	var c Command
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Command{}, fmt.Errorf("invalid command: %v", err)
+	}
+	if c.State != "" && c.State != "ON" && c.State != "OFF" {
+		return Command{}, fmt.Errorf("invalid state \"%s\", expected ON or OFF", c.State)
+	}
+	if c.Transition != nil && *c.Transition < 0 {
+		return Command{}, errors.New("transition cannot be negative")
+	}
+	if c.Color != nil {
+		rgb := c.Color.R != nil && c.Color.G != nil && c.Color.B != nil
+		hs := c.Color.H != nil && c.Color.S != nil
+		if !rgb && !hs {
+			return Command{}, errors.New("color requires r, g, b or h, s fields")
+		}
+	}
+	if c.Effect != "" && !stopsEffect(c.Effect) {
+		if _, err := flows.Preset(c.Effect); err != nil {
+			return Command{}, err
+		}
+	}
+	return c, nil
+}
+
+func stopsEffect(effect string) bool {
+	return effect == "none" || effect == "stop"
+}
+
+// transition returns transition duration of the command
+func (c Command) transition() time.Duration {
+	if c.Transition == nil || *c.Transition == 0 {
+		return 0
+	}
+	return yl.Smooth(time.Duration(*c.Transition * float64(time.Second)))
+}
+
+// execute sends command to the bulb, powered on bulb is required for changing its color,
+// so light is turned on first
+func (c Command) execute(bulb controller) error {
+	d := c.transition()
+
+	if c.State == "OFF" {
+		return bulb.SetPower(false, d)
+	}
+	if c.State == "ON" {
+		if err := bulb.SetPower(true, d); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case c.Effect != "" && stopsEffect(c.Effect):
+		if err := bulb.StopColorFlow(); err != nil {
+			return err
+		}
+	case c.Effect != "":
+		flow, err := flows.Preset(c.Effect)
+		if err != nil {
+			return err
+		}
+		if err := flow.Start(bulb); err != nil {
+			return err
+		}
+	}
+
+	if c.ColorTemp != nil {
+		if err := bulb.SetTemperature(kelvin(*c.ColorTemp), d); err != nil {
+			return err
+		}
+	}
+	if color := c.Color; color != nil {
+		var err error
+		if color.R != nil && color.G != nil && color.B != nil {
+			err = bulb.SetRGB(clamp(*color.R, 0, 255)<<16|clamp(*color.G, 0, 255)<<8|clamp(*color.B, 0, 255), d)
+		} else {
+			err = bulb.SetHSV(clamp(int(math.Round(*color.H)), 0, 359), clamp(int(math.Round(*color.S)), 0, 100), d)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if c.Brightness != nil {
+		if err := bulb.SetBrightness(clamp(*c.Brightness, 1, 100), d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kelvin converts mireds into color temperature supported by bulbs
+func kelvin(mireds int) int {
+	if mireds <= 0 {
+		return 6500
+	}
+	return clamp(int(math.Round(1e6/float64(mireds))), 1700, 6500)
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// formatState encodes state payload
+func formatState(state State) []byte {
+	data, _ := json.Marshal(state)
+	return data
+}
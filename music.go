@@ -2,26 +2,21 @@ package yeelight
 
 import (
 	"encoding/json"
+	"errors"
 	"net"
+	"time"
 )
 
+// Music is a music mode connection returned by StartMusic, commands are sent without quota limitations
 // Music mode in theory supports all commands, but due to device behaviour
 // commands are somehow limited
 // for instance you can PowerOff bulb in music mode but as a consequence
 // device will also exits music mode immediately ¯\_(ツ)_/¯
-// I decided to expose only most useful commands here
+// so only color, brightness and color flow commands are exposed here, power and scene commands
+// should be sent with Bulb
 // Also in music mode device doesn't respond on commands so errors cannot be returned
-type musicSupportedCommands interface {
-	Temperature(temp, duration int)
-	RGB(rgb, duration int)
-	HSV(hue, saturation, duration int)
-	Brightness(brightness, duration int)
-	StartColorFlow(count int, action CfAction, flowExpression FlowExpression)
-	StopColorFlow()
-}
-
 type Music struct {
-	commonCommands
+	commands commonCommands // not embedded, so commands unsupported in music mode are not exposed
 
 	conn net.Conn
 }
@@ -43,40 +38,68 @@ func (m *Music) executeCommand(c partialCommand) error {
 	return nil
 }
 
+// queryCommand is not supported, device doesn't respond on commands in music mode
+func (m *Music) queryCommand(c partialCommand) ([]string, error) {
+	return nil, errors.New("music mode doesn't support commands with results")
+}
+
+// Stop closes music mode connection, device exits music mode
 func (m *Music) Stop() error {
 	return m.conn.Close()
 }
 
 func NewMusic(conn net.Conn) *Music {
-	music := &Music{
-		commonCommands{},
-		conn,
-	}
-	music.commonCommands.commander = music
+	music := &Music{conn: conn}
+	music.commands.commander = music
 
 	return music
 }
 
+// SetCalibration sets corrections applied to sent values, nil disables calibration
+func (m *Music) SetCalibration(calibration *Calibration) {
+	m.commands.calibration = calibration
+}
+
 func (m *Music) Temperature(temp, duration int) {
-	_ = m.commonCommands.Temperature(temp, duration)
+	_ = m.commands.Temperature(temp, duration)
 }
 
 func (m *Music) RGB(rgb, duration int) {
-	_ = m.commonCommands.RGB(rgb, duration)
+	_ = m.commands.RGB(rgb, duration)
 }
 
 func (m *Music) HSV(hue, saturation, duration int) {
-	_ = m.commonCommands.HSV(hue, saturation, duration)
+	_ = m.commands.HSV(hue, saturation, duration)
+}
+
+func (m *Music) SetTemperature(temp int, d time.Duration) {
+	_ = m.commands.SetTemperature(temp, d)
+}
+
+func (m *Music) SetRGB(rgb int, d time.Duration) {
+	_ = m.commands.SetRGB(rgb, d)
+}
+
+func (m *Music) SetHSV(hue, saturation int, d time.Duration) {
+	_ = m.commands.SetHSV(hue, saturation, d)
+}
+
+func (m *Music) SetBrightness(brightness int, d time.Duration) {
+	_ = m.commands.SetBrightness(brightness, d)
+}
+
+func (m *Music) SetColor(color Color, d time.Duration) {
+	_ = m.commands.SetColor(color, d)
 }
 
 func (m *Music) Brightness(brightness, duration int) {
-	_ = m.commonCommands.Brightness(brightness, duration)
+	_ = m.commands.Brightness(brightness, duration)
 }
 
 func (m *Music) StartColorFlow(count int, action CfAction, flowExpression FlowExpression) {
-	_ = m.commonCommands.StartColorFlow(count, action, flowExpression)
+	_ = m.commands.StartColorFlow(count, action, flowExpression)
 }
 
 func (m *Music) StopColorFlow() {
-	_ = m.commonCommands.StopColorFlow()
+	_ = m.commands.StopColorFlow()
 }
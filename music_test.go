@@ -0,0 +1,50 @@
+package yeelight
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMusic(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	music := NewMusic(server)
+	defer music.Stop()
+	music.SetCalibration(&Calibration{TemperatureOffset: -200})
+
+	go func() {
+		music.SetRGB(0xff0000, time.Second)
+		music.SetTemperature(2700, Sudden)
+	}()
+
+	reader := bufio.NewReader(client)
+	expected := []string{
+		"set_rgb [1.671168e+07 smooth 1000]",
+		"set_ct_abx [2500 sudden 0]",
+	}
+	for _, want := range expected {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		var command struct {
+			Method string
+			Params []interface{}
+		}
+		if err := json.Unmarshal(line, &command); err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("%s %v", command.Method, command.Params); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+
+	// device doesn't respond in music mode
+	if _, err := music.queryCommand(partialCommand{"get_prop", params{"power"}}); err == nil {
+		t.Error("expected error for command with result")
+	}
+}
@@ -0,0 +1,67 @@
+package yeelight
+
+// Subscription receives notifications sent by device (property changes reported with "props" method).
+// Channel is closed when subscription is closed or connection to the device is lost
+type Subscription struct {
+	C <-chan Notification
+
+	ch   chan Notification
+	bulb *Bulb
+}
+
+// Subscribe creates subscription for device notifications, notifications are dropped
+// when subscriber doesn't keep up with reading them
+func (b *Bulb) Subscribe() *Subscription {
+	ch := make(chan Notification, 16)
+	subscription := &Subscription{C: ch, ch: ch, bulb: b}
+
+	b.subscribersMtx.Lock()
+	defer b.subscribersMtx.Unlock()
+
+	// connection is already closed, subscription wouldn't be ever closed otherwise
+	b.resultsMtx.Lock()
+	closed := b.closed
+	b.resultsMtx.Unlock()
+	if closed {
+		close(ch)
+		return subscription
+	}
+
+	b.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Close stops receiving notifications and closes subscription channel
+func (s *Subscription) Close() {
+	s.bulb.subscribersMtx.Lock()
+	defer s.bulb.subscribersMtx.Unlock()
+
+	if _, ok := s.bulb.subscribers[s]; ok {
+		delete(s.bulb.subscribers, s)
+		close(s.ch)
+	}
+}
+
+// publish passes notification to all subscribers
+func (b *Bulb) publish(notification Notification) {
+	b.subscribersMtx.Lock()
+	defer b.subscribersMtx.Unlock()
+
+	for subscription := range b.subscribers {
+		select {
+		case subscription.ch <- notification:
+		default:
+		}
+	}
+}
+
+// closeSubscriptions closes all subscriptions, invoked after connection is closed
+func (b *Bulb) closeSubscriptions() {
+	b.subscribersMtx.Lock()
+	defer b.subscribersMtx.Unlock()
+
+	for subscription := range b.subscribers {
+		delete(b.subscribers, subscription)
+		close(subscription.ch)
+	}
+}
@@ -0,0 +1,272 @@
+package yeelight
+
+import (
+	"container/heap"
+	"errors"
+	"time"
+)
+
+// NotifyPattern describes transient effect played by Notify
+type NotifyPattern interface {
+	// Flow returns finite color flow played by notification
+	Flow() (count int, expression FlowExpression, err error)
+}
+
+type flowPattern struct {
+	count      int
+	expression FlowExpression
+	err        error
+}
+
+func (p flowPattern) Flow() (int, FlowExpression, error) {
+	return p.count, p.expression, p.err
+}
+
+func newFlowPattern(repeat int, builder *FlowBuilder) NotifyPattern {
+	expression, err := builder.Build()
+	if err != nil {
+		return flowPattern{err: err}
+	}
+	return flowPattern{count: repeat * len(expression.states), expression: expression}
+}
+
+// BlinkPattern blinks given color given amount of times
+func BlinkPattern(rgb, times int) NotifyPattern {
+	if times < 1 {
+		return flowPattern{err: errors.New("times required to be >= 1")}
+	}
+	return newFlowPattern(times, NewFlowBuilder().
+		Color(rgb, 50*time.Millisecond, 100).
+		Sleep(250*time.Millisecond).
+		Color(rgb, 50*time.Millisecond, 1).
+		Sleep(250*time.Millisecond))
+}
+
+// PulsePattern smoothly fades given color in and out given amount of times
+func PulsePattern(rgb, times int) NotifyPattern {
+	if times < 1 {
+		return flowPattern{err: errors.New("times required to be >= 1")}
+	}
+	return newFlowPattern(times, NewFlowBuilder().
+		Color(rgb, 500*time.Millisecond, 100).
+		Color(rgb, 500*time.Millisecond, 1))
+}
+
+// RainbowPattern sweeps through rainbow colors given amount of times
+func RainbowPattern(times int) NotifyPattern {
+	if times < 1 {
+		return flowPattern{err: errors.New("times required to be >= 1")}
+	}
+	return newFlowPattern(times, NewFlowBuilder().
+		Color(0xff0000, 300*time.Millisecond, 100).
+		Color(0xff7f00, 300*time.Millisecond, 100).
+		Color(0xffff00, 300*time.Millisecond, 100).
+		Color(0x00ff00, 300*time.Millisecond, 100).
+		Color(0x0000ff, 300*time.Millisecond, 100).
+		Color(0x4b0082, 300*time.Millisecond, 100).
+		Color(0x8f00ff, 300*time.Millisecond, 100))
+}
+
+// FlowPattern plays custom flow, count is required to be finite
+func FlowPattern(count int, expression FlowExpression) NotifyPattern {
+	if count == CF_COUNT_INF {
+		return flowPattern{err: errors.New("notification requires finite flow count")}
+	}
+	return flowPattern{count: count, expression: expression}
+}
+
+// NotifyOptions changes Notify behaviour
+type NotifyOptions struct {
+	// Priority decides order of waiting notifications, higher priority is played first.
+	// Notification already being played is never interrupted
+	Priority int
+	// HostRestore restores previous state from snapshot taken before notification,
+	// instead of relying on device CF_ACTION_RECOVER support
+	HostRestore bool
+}
+
+// Notify plays transient effect and brings device back to previous state, also when device is turned off.
+// Concurrent notifications are queued and played one by one, Notify blocks until given notification is finished.
+// Notification identical to one already waiting in queue (same flow and options) is played once for both callers.
+// When device rejects flow with CF_ACTION_RECOVER, previous state is restored by host (see Snapshot)
+func (b *Bulb) Notify(pattern NotifyPattern, options NotifyOptions) error {
+	count, expression, err := pattern.Flow()
+	if err != nil {
+		return err
+	}
+	if len(expression.states) == 0 {
+		return errors.New("notification flow is empty")
+	}
+
+	done := make(chan error, 1)
+
+	b.notifyMtx.Lock()
+	if queued := b.notifyQueue.find(count, expression, options); queued != nil {
+		// the same notification is already waiting, it's played once for all callers
+		queued.done = append(queued.done, done)
+	} else {
+		heap.Push(&b.notifyQueue, &notifyRequest{
+			count:      count,
+			expression: expression,
+			options:    options,
+			seq:        b.notifySeq,
+			done:       []chan error{done},
+		})
+		b.notifySeq++
+	}
+	if !b.notifying {
+		b.notifying = true
+		go b.notifyWorker()
+	}
+	b.notifyMtx.Unlock()
+
+	return <-done
+}
+
+// notifyWorker plays queued notifications until queue is empty
+func (b *Bulb) notifyWorker() {
+	for {
+		b.notifyMtx.Lock()
+		if b.notifyQueue.Len() == 0 {
+			b.notifying = false
+			b.notifyMtx.Unlock()
+			return
+		}
+		request := heap.Pop(&b.notifyQueue).(*notifyRequest)
+		b.notifyMtx.Unlock()
+
+		err := b.playNotification(request)
+
+		b.notifyMtx.Lock()
+		for _, done := range request.done {
+			done <- err
+		}
+		b.notifyMtx.Unlock()
+	}
+}
+
+func (b *Bulb) playNotification(request *notifyRequest) error {
+	values, err := b.Prop(PROP_POWER)
+	if err != nil {
+		return err
+	}
+	wasOn := values[string(PROP_POWER)] == "on"
+
+	// duration of played flow, device counts every step as a state change
+	var duration time.Duration
+	for i := 0; i < request.count; i++ {
+		duration += request.expression.states[i%len(request.expression.states)].Length()
+	}
+
+	if !request.options.HostRestore {
+		started, err := b.notifyWithRecover(request, wasOn, duration)
+		if started || !isDeviceError(err) {
+			return err
+		}
+		// device rejected the flow, it doesn't support recovering, falling back to host side restore
+	}
+	return b.notifyWithSnapshot(request, wasOn, duration)
+}
+
+// notifyWithRecover plays notification relying on CF_ACTION_RECOVER, started reports whether the flow
+// was accepted by device, so errors returned afterwards don't cause playing notification again
+func (b *Bulb) notifyWithRecover(request *notifyRequest, wasOn bool, duration time.Duration) (started bool, err error) {
+	if !wasOn {
+		if err := b.PowerOn(0); err != nil {
+			return false, err
+		}
+	}
+
+	err = b.StartColorFlow(request.count, CF_ACTION_RECOVER, request.expression)
+	if err != nil {
+		if !wasOn {
+			_ = b.PowerOff(0)
+		}
+		return false, err
+	}
+
+	time.Sleep(duration + notifyMargin)
+	if !wasOn {
+		return true, b.PowerOff(0)
+	}
+	return true, nil
+}
+
+func (b *Bulb) notifyWithSnapshot(request *notifyRequest, wasOn bool, duration time.Duration) error {
+	snapshot, err := b.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	if !wasOn {
+		if err := b.PowerOn(0); err != nil {
+			return err
+		}
+	}
+
+	err = b.StartColorFlow(request.count, CF_ACTION_STAY, request.expression)
+	if err == nil {
+		time.Sleep(duration + notifyMargin)
+	}
+
+	if restoreErr := b.Restore(snapshot); restoreErr != nil {
+		return restoreErr
+	}
+	return err
+}
+
+// notifyMargin is additional time given to device for finishing flow
+const notifyMargin = 200 * time.Millisecond
+
+// isDeviceError returns true when error was reported by device, not by connection
+func isDeviceError(err error) bool {
+	_, ok := err.(*DeviceError)
+	return ok
+}
+
+type notifyRequest struct {
+	count      int
+	expression FlowExpression
+	options    NotifyOptions
+	seq        int          // keeps order of notifications with the same priority
+	done       []chan error // callers waiting for the notification, more than one when coalesced
+}
+
+// notifyQueue is a priority queue of notifications, implements heap.Interface
+type notifyQueue []*notifyRequest
+
+func (q notifyQueue) Len() int {
+	return len(q)
+}
+
+func (q notifyQueue) Less(i, j int) bool {
+	if q[i].options.Priority != q[j].options.Priority {
+		return q[i].options.Priority > q[j].options.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q notifyQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *notifyQueue) Push(x interface{}) {
+	*q = append(*q, x.(*notifyRequest))
+}
+
+func (q *notifyQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// find returns waiting notification with the same flow and options, nil when there is no such notification
+func (q notifyQueue) find(count int, expression FlowExpression, options NotifyOptions) *notifyRequest {
+	for _, request := range q {
+		if request.count == count && request.options == options && request.expression.encode() == expression.encode() {
+			return request
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package yeelight_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+// notifyDevice returns fake device together with bulb connected to it
+func notifyDevice(t *testing.T) (*yeelighttest.Device, *yl.Bulb) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bulb, err := device.Bulb()
+	if err != nil {
+		device.Close()
+		t.Fatal(err)
+	}
+	return device, bulb
+}
+
+// sleepPattern returns notification lasting given time, color flow param tells patterns apart
+func sleepPattern(d time.Duration) yl.NotifyPattern {
+	expression, err := yl.NewFlowBuilder().Sleep(d).Build()
+	if err != nil {
+		panic(err)
+	}
+	return yl.FlowPattern(1, expression)
+}
+
+// flows returns expressions of started color flows
+func flows(device *yeelighttest.Device) []string {
+	var started []string
+	for _, command := range device.Commands() {
+		if command.Method == "start_cf" {
+			started = append(started, command.Params[2].(string))
+		}
+	}
+	return started
+}
+
+// waitFlows waits until device receives given number of color flows
+func waitFlows(t *testing.T, device *yeelighttest.Device, n int) {
+	for start := time.Now(); len(flows(device)) < n; time.Sleep(5 * time.Millisecond) {
+		if time.Since(start) > 2*time.Second {
+			t.Fatalf("expected %d flows, got %v", n, flows(device))
+		}
+	}
+}
+
+func TestNotifyTurnedOff(t *testing.T) {
+	device, bulb := notifyDevice(t)
+	defer device.Close()
+	defer bulb.Disconnect()
+
+	if err := bulb.Notify(yl.BlinkPattern(0xff0000, 1), yl.NotifyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if power := device.Prop(yl.PROP_POWER); power != "off" {
+		t.Errorf("expected device turned off again, got %s", power)
+	}
+
+	var methods []string
+	for _, command := range device.Commands() {
+		methods = append(methods, command.Method)
+	}
+	expected := []string{"get_prop", "set_power", "start_cf", "set_power"}
+	if len(methods) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, methods)
+	}
+	for i := range expected {
+		if methods[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, methods)
+		}
+	}
+	if action := device.Commands()[2].Params[1]; action != float64(yl.CF_ACTION_RECOVER) {
+		t.Errorf("expected recover action, got %v", action)
+	}
+}
+
+func TestNotifyPriority(t *testing.T) {
+	device, bulb := notifyDevice(t)
+	defer device.Close()
+	defer bulb.Disconnect()
+
+	var wg sync.WaitGroup
+	notify := func(d time.Duration, priority int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := bulb.Notify(sleepPattern(d), yl.NotifyOptions{Priority: priority}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// the first notification is being played while the others are queued
+	notify(300*time.Millisecond, 0)
+	waitFlows(t, device, 1)
+	notify(100*time.Millisecond, 0)
+	time.Sleep(20 * time.Millisecond)
+	notify(200*time.Millisecond, 5)
+	time.Sleep(20 * time.Millisecond)
+	notify(150*time.Millisecond, 0)
+	wg.Wait()
+
+	expected := []string{"300,7,0,0", "200,7,0,0", "100,7,0,0", "150,7,0,0"}
+	started := flows(device)
+	if len(started) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, started)
+	}
+	for i := range expected {
+		if started[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, started)
+		}
+	}
+}
+
+func TestNotifyCoalescing(t *testing.T) {
+	device, bulb := notifyDevice(t)
+	defer device.Close()
+	defer bulb.Disconnect()
+
+	var wg sync.WaitGroup
+	notify := func(d time.Duration, options yl.NotifyOptions) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := bulb.Notify(sleepPattern(d), options); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	notify(300*time.Millisecond, yl.NotifyOptions{})
+	waitFlows(t, device, 1)
+	for i := 0; i < 3; i++ {
+		notify(100*time.Millisecond, yl.NotifyOptions{})
+	}
+	// different options are not coalesced
+	notify(100*time.Millisecond, yl.NotifyOptions{Priority: -1})
+	wg.Wait()
+
+	if started := flows(device); len(started) != 3 {
+		t.Errorf("expected 3 flows, got %v", started)
+	}
+}
+
+func TestNotifyHostRestore(t *testing.T) {
+	device, bulb := notifyDevice(t)
+	defer device.Close()
+	defer bulb.Disconnect()
+
+	device.SetProp(yl.PROP_POWER, "on")
+	device.SetProp(yl.PROP_COLOR_MODE, "1")
+	device.SetProp(yl.PROP_RGB, "255")
+
+	// device doesn't support color flows, it's interrupted and previous state is restored by host
+	device.Fail("start_cf", &yl.DeviceError{Code: -1, Message: "general error"})
+	if err := bulb.Notify(yl.BlinkPattern(0xff0000, 1), yl.NotifyOptions{}); err == nil {
+		t.Fatal("expected error of rejected flow")
+	}
+	if started := len(flows(device)); started != 2 {
+		t.Errorf("expected flow tried with recover and with host restore, got %d", started)
+	}
+	if rgb, power := device.Prop(yl.PROP_RGB), device.Prop(yl.PROP_POWER); rgb != "255" || power != "on" {
+		t.Errorf("expected restored state, got rgb %s, power %s", rgb, power)
+	}
+
+	// flow is accepted with host restore, device is brought back to previous color
+	device.Fail("start_cf", nil)
+	device.Reset()
+	if err := bulb.Notify(yl.BlinkPattern(0xff0000, 1), yl.NotifyOptions{HostRestore: true}); err != nil {
+		t.Fatal(err)
+	}
+	started := flows(device)
+	if len(started) != 1 || device.Commands()[len(device.Commands())-1].Method == "start_cf" {
+		t.Errorf("expected single flow followed by restore, got %+v", device.Commands())
+	}
+	if rgb := device.Prop(yl.PROP_RGB); rgb != "255" {
+		t.Errorf("expected restored color, got %s", rgb)
+	}
+}
+
+func TestNotifyNotReplayed(t *testing.T) {
+	device, bulb := notifyDevice(t)
+	defer device.Close()
+	defer bulb.Disconnect()
+
+	// device fails turning off after the flow was played, notification isn't played again
+	go func() {
+		for len(flows(device)) == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		device.Fail("set_power", &yl.DeviceError{Code: -1, Message: "general error"})
+	}()
+	if err := bulb.Notify(sleepPattern(100*time.Millisecond), yl.NotifyOptions{}); err == nil {
+		t.Fatal("expected error of rejected power off")
+	}
+	if started := flows(device); len(started) != 1 {
+		t.Errorf("expected notification played once, got %v", started)
+	}
+}
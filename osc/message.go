@@ -0,0 +1,259 @@
+// Package osc controls bulbs by Open Sound Control messages (OSC 1.0 over UDP), as sent by control surfaces
+// (TouchOSC, Open Stage Control) and DAWs. Default addresses are built from prefix, target (bulb name or
+// any selector supported by registry.Select, including patterns like "office/*") and action:
+//
+//   /yeelight/{target}/rgb            r, g, b (floats 0-1 or ints 0-255) or a single int 0xRRGGBB
+//   /yeelight/{target}/hsv            h, s[, v] (floats 0-1 or ints 0-359, 0-100, 0-100), v sets brightness
+//   /yeelight/{target}/bright         float 0-1 or int 1-100
+//   /yeelight/{target}/ct             float 0-1 (warm - cold) or int 1700-6500
+//   /yeelight/{target}/power          0 or 1, toggles without argument
+//   /yeelight/{target}/flow/{preset}  starts flow preset (see flows.Names), ignored when argument is 0
+//   /yeelight/{target}/stop           stops running flow
+//
+// Continuous controls (rgb, hsv, bright, ct) are sent through music mode, so faders don't consume quota.
+// Discrete controls (power, flow, stop) are sent as regular commands. Other addresses can be bound
+// to actions with Map
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Message is an OSC message, arguments are int32, float32, string, []byte, bool, nil, int64 or float64
+type Message struct {
+	Address string
+	Args    []interface{}
+}
+
+// String formats message for logging
+func (m Message) String() string {
+	return fmt.Sprintf("%s %v", m.Address, m.Args)
+}
+
+// Float returns argument as float64, numeric and bool arguments are accepted
+func (m Message) Float(i int) (float64, bool) {
+	if i >= len(m.Args) {
+		return 0, false
+	}
+	switch v := m.Args[i].(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// IsInt returns true when argument is an integer
+func (m Message) IsInt(i int) bool {
+	if i >= len(m.Args) {
+		return false
+	}
+	switch m.Args[i].(type) {
+	case int32, int64:
+		return true
+	}
+	return false
+}
+
+var bundleID = []byte("#bundle\x00")
+
+// ParsePacket decodes OSC packet, messages of bundles are returned in order (time tags are ignored,
+// messages are applied immediately)
+func ParsePacket(packet []byte) ([]Message, error) {
+	if !bytes.HasPrefix(packet, bundleID) {
+		m, err := parseMessage(packet)
+		if err != nil {
+			return nil, err
+		}
+		return []Message{m}, nil
+	}
+
+	if len(packet) < 16 {
+		return nil, errors.New("bundle too short")
+	}
+	var messages []Message
+	for rest := packet[16:]; len(rest) > 0; {
+		if len(rest) < 4 {
+			return nil, errors.New("truncated bundle element")
+		}
+		size := int(binary.BigEndian.Uint32(rest))
+		if size < 0 || size%4 != 0 || len(rest) < 4+size {
+			return nil, fmt.Errorf("invalid bundle element size %d", size)
+		}
+		elements, err := ParsePacket(rest[4 : 4+size])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, elements...)
+		rest = rest[4+size:]
+	}
+	return messages, nil
+}
+
+func parseMessage(packet []byte) (Message, error) {
+	address, rest, err := readString(packet)
+	if err != nil {
+		return Message{}, err
+	}
+	if len(address) == 0 || address[0] != '/' {
+		return Message{}, fmt.Errorf("invalid address \"%s\"", address)
+	}
+	m := Message{Address: address}
+	if len(rest) == 0 {
+		return m, nil // type tag string may be omitted by old implementations
+	}
+
+	tags, rest, err := readString(rest)
+	if err != nil {
+		return Message{}, err
+	}
+	if len(tags) == 0 || tags[0] != ',' {
+		return Message{}, fmt.Errorf("invalid type tag string \"%s\"", tags)
+	}
+
+	for _, tag := range tags[1:] {
+		var arg interface{}
+		switch tag {
+		case 'i', 'f', 'c', 'r', 'm':
+			if len(rest) < 4 {
+				return Message{}, errors.New("truncated argument")
+			}
+			bits := binary.BigEndian.Uint32(rest)
+			switch tag {
+			case 'i', 'c', 'r', 'm': // char, color and MIDI message are passed as int32
+				arg = int32(bits)
+			case 'f':
+				arg = math.Float32frombits(bits)
+			}
+			rest = rest[4:]
+		case 'h', 'd', 't':
+			if len(rest) < 8 {
+				return Message{}, errors.New("truncated argument")
+			}
+			bits := binary.BigEndian.Uint64(rest)
+			if tag == 'd' {
+				arg = math.Float64frombits(bits)
+			} else {
+				arg = int64(bits)
+			}
+			rest = rest[8:]
+		case 's', 'S':
+			if arg, rest, err = readString(rest); err != nil {
+				return Message{}, err
+			}
+		case 'b':
+			if len(rest) < 4 {
+				return Message{}, errors.New("truncated blob")
+			}
+			size := int(binary.BigEndian.Uint32(rest))
+			padded := 4 + (size+3)/4*4
+			if size < 0 || len(rest) < padded {
+				return Message{}, errors.New("truncated blob")
+			}
+			arg = append([]byte{}, rest[4:4+size]...)
+			rest = rest[padded:]
+		case 'T':
+			arg = true
+		case 'F':
+			arg = false
+		case 'N', 'I':
+			arg = nil
+		case '[', ']':
+			continue // arrays are flattened
+		default:
+			return Message{}, fmt.Errorf("unsupported argument type '%c'", tag)
+		}
+		m.Args = append(m.Args, arg)
+	}
+	return m, nil
+}
+
+// readString reads null terminated string padded to 4 bytes
+func readString(data []byte) (string, []byte, error) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return "", nil, errors.New("unterminated string")
+	}
+	padded := (end + 4) / 4 * 4
+	if len(data) < padded {
+		return "", nil, errors.New("truncated string")
+	}
+	return string(data[:end]), data[padded:], nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.Write(make([]byte, 4-len(s)%4))
+}
+
+// MarshalBinary encodes message, unsupported argument types are reported as error
+func (m Message) MarshalBinary() ([]byte, error) {
+	var (
+		tags = []byte{','}
+		args bytes.Buffer
+		word = make([]byte, 8)
+	)
+	for _, arg := range m.Args {
+		switch v := arg.(type) {
+		case int32:
+			tags = append(tags, 'i')
+			binary.BigEndian.PutUint32(word, uint32(v))
+			args.Write(word[:4])
+		case int:
+			tags = append(tags, 'i')
+			binary.BigEndian.PutUint32(word, uint32(int32(v)))
+			args.Write(word[:4])
+		case float32:
+			tags = append(tags, 'f')
+			binary.BigEndian.PutUint32(word, math.Float32bits(v))
+			args.Write(word[:4])
+		case int64:
+			tags = append(tags, 'h')
+			binary.BigEndian.PutUint64(word, uint64(v))
+			args.Write(word)
+		case float64:
+			tags = append(tags, 'd')
+			binary.BigEndian.PutUint64(word, math.Float64bits(v))
+			args.Write(word)
+		case string:
+			tags = append(tags, 's')
+			writeString(&args, v)
+		case []byte:
+			tags = append(tags, 'b')
+			binary.BigEndian.PutUint32(word, uint32(len(v)))
+			args.Write(word[:4])
+			args.Write(v)
+			args.Write(make([]byte, (4-len(v)%4)%4))
+		case bool:
+			if v {
+				tags = append(tags, 'T')
+			} else {
+				tags = append(tags, 'F')
+			}
+		case nil:
+			tags = append(tags, 'N')
+		default:
+			return nil, fmt.Errorf("unsupported argument type %T", arg)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeString(&buf, m.Address)
+	writeString(&buf, string(tags))
+	buf.Write(args.Bytes())
+	return buf.Bytes(), nil
+}
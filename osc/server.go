@@ -0,0 +1,489 @@
+package osc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// actions available for addresses, continuous actions are sent through music mode
+var (
+	continuousActions = map[string]bool{"rgb": true, "hsv": true, "bright": true, "ct": true}
+	discreteActions   = map[string]bool{"power": true, "flow": true, "stop": true}
+)
+
+// Binding binds address to action of target
+type Binding struct {
+	Target string `json:"target"`           // bulb name or selector, see registry.Select
+	Action string `json:"action"`           // rgb, hsv, bright, ct, power, flow or stop
+	Preset string `json:"preset,omitempty"` // flow preset of "flow" action
+}
+
+// Map binds addresses of control surface layout to actions
+type Map map[string]Binding
+
+// Validate checks actions and presets
+func (m Map) Validate() error {
+	for address, b := range m {
+		if !strings.HasPrefix(address, "/") {
+			return fmt.Errorf("address \"%s\" has to start with \"/\"", address)
+		}
+		if b.Target == "" {
+			return fmt.Errorf("\"%s\": target is required", address)
+		}
+		if !continuousActions[b.Action] && !discreteActions[b.Action] {
+			return fmt.Errorf("\"%s\": unknown action \"%s\"", address, b.Action)
+		}
+		if b.Action == "flow" {
+			if _, err := flows.Preset(b.Preset); err != nil {
+				return fmt.Errorf("\"%s\": %v", address, err)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadMap reads address map from JSON file, example (TouchOSC layout):
+//   {
+//     "/1/fader1": {"target": "office/desk", "action": "bright"},
+//     "/1/xy1": {"target": "room:kitchen", "action": "hsv"},
+//     "/1/toggle1": {"target": "all", "action": "power"},
+//     "/1/push1": {"target": "tag:stage", "action": "flow", "preset": "police"}
+//   }
+func LoadMap(path string) (Map, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Server executes received messages. Continuous controls of every bulb are sent by its own worker,
+// values received faster than update interval are coalesced. Discrete controls are queued
+// and executed in order of arrival
+type Server struct {
+	registry *registry.Registry
+	prefix   string
+	bindings Map
+	iface    string
+	interval time.Duration
+
+	outputsMtx sync.Mutex
+	outputs    map[string]*output // keyed by device ID
+
+	commands chan func()
+	done     chan struct{}
+	workers  sync.WaitGroup
+}
+
+// output is a music mode session of a single bulb with pending continuous values
+type output struct {
+	entry registry.Entry
+
+	mtx     sync.Mutex
+	color   *yl.Color
+	bright  int // 0 when not changed
+	stopped bool
+	wake    chan struct{}
+
+	// owned by worker
+	music *yl.Music
+}
+
+// New creates server controlling bulbs from given registry
+func New(reg *registry.Registry) *Server {
+	s := &Server{
+		registry: reg,
+		prefix:   "/yeelight",
+		interval: 50 * time.Millisecond,
+		outputs:  make(map[string]*output),
+		commands: make(chan func(), 16),
+		done:     make(chan struct{}),
+	}
+	s.workers.Add(1)
+	go s.runCommands()
+	return s
+}
+
+// SetPrefix sets prefix of default addresses, "/yeelight" by default, empty prefix disables default addresses
+func (s *Server) SetPrefix(prefix string) {
+	s.prefix = strings.TrimSuffix(prefix, "/")
+}
+
+// SetMap sets addresses bound to actions, bound addresses take precedence over default addresses
+func (s *Server) SetMap(m Map) {
+	s.bindings = m
+}
+
+// SetMusicInterface sets network interface used for music mode connections, see registry.Registry.StartMusic
+func (s *Server) SetMusicInterface(iface string) {
+	s.iface = iface
+}
+
+// SetInterval sets minimum interval between updates of a single bulb, 50ms by default
+func (s *Server) SetInterval(interval time.Duration) {
+	s.interval = interval
+}
+
+// Serve executes messages received from connection until connection is closed,
+// invalid messages are logged and ignored
+func (s *Server) Serve(conn net.PacketConn) error {
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		messages, err := ParsePacket(buf[:n])
+		if err != nil {
+			log.Printf("[osc] invalid packet: %v\n", err)
+			continue
+		}
+		for _, m := range messages {
+			if err := s.Handle(m); err != nil {
+				log.Printf("[osc] %s: %v\n", m.Address, err)
+			}
+		}
+	}
+}
+
+// Close stops workers and music mode of all bulbs
+func (s *Server) Close() {
+	select {
+	case <-s.done:
+		return
+	default:
+		close(s.done)
+	}
+	s.workers.Wait()
+}
+
+// resolve returns binding of address, address may be a pattern matching bound address
+func (s *Server) resolve(address string) (Binding, bool) {
+	if b, ok := s.bindings[address]; ok {
+		return b, true
+	}
+	var bound []string
+	for a := range s.bindings {
+		bound = append(bound, a)
+	}
+	sort.Strings(bound)
+	for _, a := range bound {
+		if ok, _ := path.Match(address, a); ok {
+			return s.bindings[a], true
+		}
+	}
+
+	if s.prefix == "" || !strings.HasPrefix(address, s.prefix+"/") {
+		return Binding{}, false
+	}
+	rest := strings.TrimPrefix(address, s.prefix+"/")
+	if i := strings.LastIndex(rest, "/flow/"); i > 0 {
+		return Binding{Target: rest[:i], Action: "flow", Preset: rest[i+len("/flow/"):]}, true
+	}
+	i := strings.LastIndex(rest, "/")
+	if i <= 0 {
+		return Binding{}, false
+	}
+	action := rest[i+1:]
+	if !continuousActions[action] && !discreteActions[action] {
+		return Binding{}, false
+	}
+	return Binding{Target: rest[:i], Action: action}, true
+}
+
+// Handle executes message, errors of continuous controls and discrete commands are logged
+func (s *Server) Handle(m Message) error {
+	b, ok := s.resolve(m.Address)
+	if !ok {
+		return fmt.Errorf("address not bound to any action")
+	}
+	entries, err := s.registry.Select(b.Target)
+	if err != nil {
+		return err
+	}
+
+	if continuousActions[b.Action] {
+		color, bright, err := parseContinuous(b.Action, m)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			s.output(entry).update(color, bright)
+		}
+		return nil
+	}
+
+	var (
+		run func(bulb *yl.Bulb) error
+		on  *bool // power action, nil toggles
+	)
+	switch b.Action {
+	case "power":
+		if len(m.Args) > 0 {
+			v, ok := m.Float(0)
+			if !ok {
+				return fmt.Errorf("numeric or bool argument expected, got %v", m.Args)
+			}
+			power := v != 0
+			on = &power
+		}
+		run = func(bulb *yl.Bulb) error {
+			if on == nil {
+				return bulb.Toggle()
+			}
+			return bulb.SetPower(*on, 0)
+		}
+	case "flow":
+		// buttons send 1 when pressed and 0 when released
+		if v, ok := m.Float(0); ok && v == 0 {
+			return nil
+		}
+		flow, err := flows.Preset(b.Preset)
+		if err != nil {
+			return err
+		}
+		run = func(bulb *yl.Bulb) error { return flow.Start(bulb) }
+	case "stop":
+		if v, ok := m.Float(0); ok && v == 0 {
+			return nil
+		}
+		run = func(bulb *yl.Bulb) error { return bulb.StopColorFlow() }
+	}
+
+	// toggle cannot be repeated after lost connection, it could switch the bulb twice
+	execute := s.registry.Execute
+	if b.Action == "power" && on == nil {
+		execute = s.registry.ExecuteOnce
+	}
+	command := func() {
+		for _, entry := range entries {
+			if b.Action == "power" && (on == nil || !*on) {
+				// bulb exits music mode when it's turned off
+				s.output(entry).reset()
+			}
+			yl.WaitQuota()
+			if err := execute(entry.ID, run); err != nil {
+				log.Printf("[osc] %s: bulb \"%s\": %v\n", m.Address, entry.Name, err)
+			}
+		}
+	}
+	select {
+	case s.commands <- command:
+		return nil
+	default:
+		return fmt.Errorf("command queue is full, command dropped")
+	}
+}
+
+// parseContinuous parses arguments of continuous action, zero brightness is returned when not changed
+func parseContinuous(action string, m Message) (*yl.Color, int, error) {
+	var values []float64
+	for i := range m.Args {
+		v, ok := m.Float(i)
+		if !ok {
+			return nil, 0, fmt.Errorf("numeric arguments expected, got %v", m.Args)
+		}
+		values = append(values, v)
+	}
+	// floats are normalized values of faders, integers are values in native ranges
+	normalized := len(values) > 0 && !m.IsInt(0)
+	scale := func(v, max float64) float64 {
+		if normalized {
+			v *= max
+		}
+		return math.Max(0, math.Min(max, v))
+	}
+
+	switch action {
+	case "rgb":
+		var color yl.Color
+		switch {
+		case len(values) == 1 && !normalized:
+			color = yl.ColorFromRGB(int(values[0]) & 0xffffff)
+		case len(values) >= 3:
+			r, g, b := scale(values[0], 255), scale(values[1], 255), scale(values[2], 255)
+			color = yl.ColorFromRGB(int(math.Round(r))<<16 | int(math.Round(g))<<8 | int(math.Round(b)))
+		default:
+			return nil, 0, fmt.Errorf("r, g, b or 0xRRGGBB expected, got %v", m.Args)
+		}
+		return &color, 0, nil
+	case "hsv":
+		if len(values) < 2 {
+			return nil, 0, fmt.Errorf("h, s[, v] expected, got %v", m.Args)
+		}
+		color := yl.ColorFromHSV(math.Mod(scale(values[0], 360), 360), scale(values[1], 100), 100)
+		bright := 0
+		if len(values) >= 3 {
+			bright = brightness(scale(values[2], 100))
+		}
+		return &color, bright, nil
+	case "bright":
+		if len(values) != 1 {
+			return nil, 0, fmt.Errorf("brightness expected, got %v", m.Args)
+		}
+		return nil, brightness(scale(values[0], 100)), nil
+	case "ct":
+		if len(values) != 1 {
+			return nil, 0, fmt.Errorf("temperature expected, got %v", m.Args)
+		}
+		temp := values[0]
+		if normalized {
+			temp = 1700 + scale(temp, 1)*(6500-1700)
+		}
+		color := yl.ColorFromKelvin(int(math.Round(math.Max(1700, math.Min(6500, temp)))))
+		return &color, 0, nil
+	}
+	return nil, 0, fmt.Errorf("unknown action \"%s\"", action)
+}
+
+func brightness(v float64) int {
+	b := int(math.Round(v))
+	if b < 1 {
+		return 1
+	}
+	return b
+}
+
+// output returns output of bulb, worker is started on first use
+func (s *Server) output(entry registry.Entry) *output {
+	s.outputsMtx.Lock()
+	defer s.outputsMtx.Unlock()
+
+	o, ok := s.outputs[entry.ID]
+	if !ok {
+		o = &output{entry: entry, wake: make(chan struct{}, 1)}
+		s.outputs[entry.ID] = o
+		s.workers.Add(1)
+		go s.run(o)
+	}
+	return o
+}
+
+// update queues continuous values, queued values not sent yet are replaced
+func (o *output) update(color *yl.Color, bright int) {
+	o.mtx.Lock()
+	if color != nil {
+		o.color = color
+	}
+	if bright != 0 {
+		o.bright = bright
+	}
+	o.mtx.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// reset drops music mode session, it's started again with the next continuous value
+func (o *output) reset() {
+	o.mtx.Lock()
+	o.stopped = true
+	o.mtx.Unlock()
+}
+
+// retryDelay is a delay after failed start of music mode
+const retryDelay = 5 * time.Second
+
+func (s *Server) run(o *output) {
+	defer s.workers.Done()
+	defer o.stopMusic()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-o.wake:
+		}
+
+		o.mtx.Lock()
+		color, bright, stopped := o.color, o.bright, o.stopped
+		o.color, o.bright, o.stopped = nil, 0, false
+		o.mtx.Unlock()
+		if stopped {
+			o.stopMusic()
+		}
+
+		wait := s.interval
+		if color != nil || bright != 0 {
+			if err := s.apply(o, color, bright); err != nil {
+				log.Printf("[osc] bulb \"%s\": %v\n", o.entry.Name, err)
+				wait = retryDelay
+			}
+		}
+
+		select {
+		case <-s.done:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// apply sends values through music mode, music mode is started when needed
+func (s *Server) apply(o *output, color *yl.Color, bright int) error {
+	if o.music == nil {
+		yl.WaitQuota()
+		music, err := s.registry.StartMusic(o.entry.ID, s.iface)
+		if err != nil {
+			return err
+		}
+		o.music = music
+	}
+
+	if color != nil {
+		o.music.SetColor(*color, 0)
+	}
+	if bright != 0 {
+		o.music.SetBrightness(bright, 0)
+	}
+	return nil
+}
+
+func (o *output) stopMusic() {
+	if o.music == nil {
+		return
+	}
+	_ = o.music.Stop()
+	o.music = nil
+}
+
+// runCommands executes queued discrete commands
+func (s *Server) runCommands() {
+	defer s.workers.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case command := <-s.commands:
+			command()
+		}
+	}
+}
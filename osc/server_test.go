@@ -0,0 +1,74 @@
+package osc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+// waitToggles waits until device receives given number of toggle commands
+func waitToggles(t *testing.T, device *yeelighttest.Device, expected int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		toggles := 0
+		for _, command := range device.Commands() {
+			if command.Method == "toggle" {
+				toggles++
+			}
+		}
+		if toggles == expected {
+			return
+		}
+		if toggles > expected || time.Now().After(deadline) {
+			t.Fatalf("expected %d toggles, got %d", expected, toggles)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestToggleNotRepeated(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	reg := registry.New("")
+	defer reg.Close()
+	if err := reg.Set(registry.Entry{ID: device.ID, Name: "desk", Ip: device.Ip, Port: device.Port}); err != nil {
+		t.Fatal(err)
+	}
+	s := New(reg)
+	defer s.Close()
+
+	toggle := Message{Address: "/yeelight/desk/power"}
+	if err := s.Handle(toggle); err != nil {
+		t.Fatal(err)
+	}
+	waitToggles(t, device, 1)
+
+	// drop connection and wait until bulb notices it
+	bulb, err := reg.Bulb("desk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscription := bulb.Subscribe()
+	device.DropConnections()
+	for range subscription.C {
+	}
+	device.Reset()
+
+	// toggle sent over closed connection fails, the next one connects again. Commands are executed
+	// in order, so the first one would be already repeated when the second one arrives
+	if err := s.Handle(toggle); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Handle(toggle); err != nil {
+		t.Fatal(err)
+	}
+	waitToggles(t, device, 1)
+	time.Sleep(50 * time.Millisecond)
+	waitToggles(t, device, 1)
+}
@@ -0,0 +1,22 @@
+package yeelight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuota(t *testing.T) {
+	q := newQuota(1200) // one token per 50ms
+	q.tokens = 1
+
+	start := time.Now()
+	q.wait()
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("available token should be taken immediately, waited %v", elapsed)
+	}
+
+	q.wait()
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected waiting for restored token, waited %v", elapsed)
+	}
+}
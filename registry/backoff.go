@@ -0,0 +1,38 @@
+package registry
+
+import "time"
+
+// reconnect delays used by Backoff
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Backoff is a delay between reconnection attempts of monitors keeping connections to bulbs,
+// it starts at one second and it's doubled after every wait, up to one minute. Zero value is ready to use
+type Backoff struct {
+	delay time.Duration
+}
+
+// Wait waits for current delay and doubles it, false is returned when stop was closed in the meantime
+func (b *Backoff) Wait(stop <-chan struct{}) bool {
+	if b.delay < minReconnectDelay {
+		b.delay = minReconnectDelay
+	}
+
+	select {
+	case <-stop:
+		return false
+	case <-time.After(b.delay):
+	}
+
+	if b.delay *= 2; b.delay > maxReconnectDelay {
+		b.delay = maxReconnectDelay
+	}
+	return true
+}
+
+// Reset brings delay back to one second, it should be called after connection was established
+func (b *Backoff) Reset() {
+	b.delay = minReconnectDelay
+}
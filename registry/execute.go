@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"net"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// UnreachableError is returned when connection to the bulb cannot be established
+type UnreachableError struct {
+	Name string // bulb name
+	Err  error
+}
+
+func (e *UnreachableError) Error() string {
+	return e.Err.Error()
+}
+
+// IsConnectionError returns true when error was caused by connection to the device
+func IsConnectionError(err error) bool {
+	if err == yl.ErrConnectionClosed {
+		return true
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
+
+// Execute runs function on bulb with given name or device ID. Bulb is disconnected on connection error,
+// so next call connects again, function is repeated once on a new connection when previous connection
+// was found closed (for instance after device restart). Commands which cannot be safely repeated
+// (toggle) should use ExecuteOnce
+func (r *Registry) Execute(name string, fn func(b *yl.Bulb) error) error {
+	return r.execute(name, fn, true)
+}
+
+// ExecuteOnce works like Execute, but function is never repeated
+func (r *Registry) ExecuteOnce(name string, fn func(b *yl.Bulb) error) error {
+	return r.execute(name, fn, false)
+}
+
+func (r *Registry) execute(name string, fn func(b *yl.Bulb) error, retry bool) error {
+	entry, err := r.Lookup(name)
+	if err != nil {
+		return err
+	}
+	bulb, err := r.connect(entry)
+	if err != nil {
+		return err
+	}
+
+	err = fn(bulb)
+	if !IsConnectionError(err) {
+		return err
+	}
+	r.release(entry.ID, bulb)
+	if err != yl.ErrConnectionClosed || !retry {
+		return err
+	}
+
+	if bulb, err = r.connect(entry); err != nil {
+		return err
+	}
+	if err = fn(bulb); IsConnectionError(err) {
+		r.release(entry.ID, bulb)
+	}
+	return err
+}
+
+// release disconnects broken bulb, unless it was already replaced by a new connection
+func (r *Registry) release(id string, bulb *yl.Bulb) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.bulbs[id] == bulb {
+		r.dropBulb(id)
+	}
+}
+
+// StartMusic turns bulb with given name on and starts music mode, see yeelight.Bulb.StartMusic.
+// Music mode commands are ignored by turned off bulb, so power is set before music mode is started
+func (r *Registry) StartMusic(name, iface string) (*yl.Music, error) {
+	var music *yl.Music
+	err := r.Execute(name, func(b *yl.Bulb) error {
+		if err := b.SetPower(true, 0); err != nil {
+			return err
+		}
+		var err error
+		music, err = b.StartMusic(iface)
+		return err
+	})
+	return music, err
+}
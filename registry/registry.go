@@ -0,0 +1,388 @@
+// Package registry maps device IDs to human friendly names, rooms and tags, so lights can be addressed
+// by name ("kitchen", "office/desk") instead of IP address. Configuration is persisted in JSON file
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// Entry describes single registered device
+type Entry struct {
+	ID   string   `json:"id"`             // device ID reported by discovery
+	Name string   `json:"name"`           // unique name, "/" may be used for hierarchy, for instance "office/desk"
+	Room string   `json:"room,omitempty"` // room or zone name
+	Tags []string `json:"tags,omitempty"`
+	Ip   string   `json:"ip"` // last known address, updated by discovery
+	Port int      `json:"port,omitempty"`
+}
+
+// Address returns "ip:port" address of the entry, default protocol port is used when port is unknown
+func (e Entry) Address() string {
+	port := e.Port
+	if port == 0 {
+		port = 55443
+	}
+	return net.JoinHostPort(e.Ip, strconv.Itoa(port))
+}
+
+// HasTag returns true when entry is tagged with given tag
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NotFoundError is returned when no registered bulb matches given name or selector
+type NotFoundError struct {
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no bulbs matching \"%s\"", e.Name)
+}
+
+// Registry holds registered devices and keeps connections to bulbs requested by name
+type Registry struct {
+	mtx     sync.Mutex
+	path    string
+	entries map[string]*Entry   // keyed by device ID
+	bulbs   map[string]*yl.Bulb // connected bulbs, keyed by device ID
+}
+
+// New creates empty registry persisted in given file
+func New(path string) *Registry {
+	return &Registry{
+		path:    path,
+		entries: make(map[string]*Entry),
+		bulbs:   make(map[string]*yl.Bulb),
+	}
+}
+
+// Load reads registry from given file, empty registry is returned when file doesn't exist
+func Load(path string) (*Registry, error) {
+	r := New(path)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, entry := range entries {
+		if err := r.Set(entry); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Save writes registry to the file it was loaded from
+func (r *Registry) Save() error {
+	data, err := json.MarshalIndent(r.Entries(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// Set adds or replaces entry, device ID and unique name are required
+func (r *Registry) Set(entry Entry) error {
+	if entry.ID == "" {
+		return errors.New("entry requires device id")
+	}
+	if entry.Name == "" {
+		return fmt.Errorf("entry \"%s\" requires name", entry.ID)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for id, e := range r.entries {
+		if id != entry.ID && e.Name == entry.Name {
+			return fmt.Errorf("name \"%s\" is already used by \"%s\"", entry.Name, id)
+		}
+	}
+	if old, ok := r.entries[entry.ID]; ok && (old.Ip != entry.Ip || old.Port != entry.Port) {
+		r.dropBulb(entry.ID)
+	}
+	r.entries[entry.ID] = &entry
+	return nil
+}
+
+// Remove removes entry with given device ID
+func (r *Registry) Remove(id string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.dropBulb(id)
+	delete(r.entries, id)
+}
+
+// Entries returns all entries sorted by name
+func (r *Registry) Entries() []Entry {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	var entries []Entry
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Update merges discovered devices into registry: addresses of known devices are updated,
+// unknown devices are added with device name (or ID when name is not set).
+// Entries which address has changed or were added are returned
+func (r *Registry) Update(devices []yl.Device) []Entry {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	var changed []Entry
+	for _, device := range devices {
+		entry, ok := r.entries[device.ID]
+		if !ok {
+			entry = &Entry{ID: device.ID, Name: r.uniqueName(device)}
+			r.entries[device.ID] = entry
+		} else if entry.Ip == device.Ip && entry.Port == device.Port {
+			continue
+		}
+
+		r.dropBulb(device.ID)
+		entry.Ip = device.Ip
+		entry.Port = device.Port
+		changed = append(changed, *entry)
+	}
+	return changed
+}
+
+// uniqueName prepares name for newly discovered device
+func (r *Registry) uniqueName(device yl.Device) string {
+	name := device.Name
+	if name == "" {
+		return device.ID
+	}
+	for _, e := range r.entries {
+		if e.Name == name {
+			return device.ID
+		}
+	}
+	return name
+}
+
+// Refresh runs discovery and updates registry, see Update
+func (r *Registry) Refresh(timeout time.Duration) ([]Entry, error) {
+	devices, err := yl.Discover(timeout)
+	if err != nil {
+		return nil, err
+	}
+	return r.Update(devices), nil
+}
+
+// Lookup returns entry by name or device ID
+func (r *Registry) Lookup(name string) (Entry, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if e, ok := r.entries[name]; ok {
+		return *e, nil
+	}
+	for _, e := range r.entries {
+		if e.Name == name {
+			return *e, nil
+		}
+	}
+	return Entry{}, &NotFoundError{Name: name}
+}
+
+// Select returns entries matching given selector, sorted by name. Supported selectors:
+//
+//	"*" or "all"      every entry
+//	"room:kitchen"    entries assigned to given room
+//	"tag:ceiling"     entries tagged with given tag
+//	"office/*"        entries which name matches given pattern (path.Match syntax)
+//	"office/desk"     entry with given name or device ID
+func (r *Registry) Select(selector string) ([]Entry, error) {
+	var match func(e Entry) bool
+
+	switch {
+	case selector == "*" || selector == "all":
+		match = func(e Entry) bool { return true }
+	case strings.HasPrefix(selector, "room:"):
+		room := strings.TrimPrefix(selector, "room:")
+		match = func(e Entry) bool { return e.Room == room }
+	case strings.HasPrefix(selector, "tag:"):
+		tag := strings.TrimPrefix(selector, "tag:")
+		match = func(e Entry) bool { return e.HasTag(tag) }
+	default:
+		if _, err := path.Match(selector, ""); err != nil {
+			return nil, fmt.Errorf("invalid selector \"%s\": %v", selector, err)
+		}
+		match = func(e Entry) bool {
+			ok, _ := path.Match(selector, e.Name)
+			return ok || e.ID == selector
+		}
+	}
+
+	var selected []Entry
+	for _, e := range r.Entries() {
+		if match(e) {
+			selected = append(selected, e)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, &NotFoundError{Name: selector}
+	}
+	return selected, nil
+}
+
+// Bulb returns connected bulb by name or device ID, connection is kept for subsequent calls
+func (r *Registry) Bulb(name string) (*yl.Bulb, error) {
+	entry, err := r.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return r.connect(entry)
+}
+
+// Group returns group of connected bulbs matching given selector, see Select and GroupOf
+func (r *Registry) Group(selector string) (*yl.Group, error) {
+	entries, err := r.Select(selector)
+	if err != nil {
+		return nil, err
+	}
+	return r.GroupOf(entries)
+}
+
+// GroupOf returns group of connected bulbs of given entries. Bulbs are connected in parallel,
+// when some of them cannot be connected group of reachable bulbs is returned together with
+// yeelight.GroupError describing the unreachable ones (keyed by Entry.Address)
+func (r *Registry) GroupOf(entries []Entry) (*yl.Group, error) {
+	var (
+		bulbs      = make([]*yl.Bulb, len(entries))
+		failed     = yl.GroupError{}
+		failedMtx  sync.Mutex
+		connecting sync.WaitGroup
+	)
+	for i, entry := range entries {
+		connecting.Add(1)
+		go func(i int, entry Entry) {
+			defer connecting.Done()
+			bulb, err := r.connect(entry)
+			if err != nil {
+				failedMtx.Lock()
+				failed[entry.Address()] = err
+				failedMtx.Unlock()
+				return
+			}
+			bulbs[i] = bulb
+		}(i, entry)
+	}
+	connecting.Wait()
+
+	// bulbs are kept in selector order
+	group := yl.NewGroup()
+	for _, bulb := range bulbs {
+		if bulb != nil {
+			group.Add(bulb)
+		}
+	}
+	if len(failed) > 0 {
+		return group, failed
+	}
+	return group, nil
+}
+
+// connectTimeout limits time of establishing connection to the bulb
+const connectTimeout = 5 * time.Second
+
+// connect returns cached bulb or connects it, UnreachableError is returned on failure,
+// registry lock isn't held while connecting, so unreachable bulb doesn't block the others
+func (r *Registry) connect(entry Entry) (*yl.Bulb, error) {
+	r.mtx.Lock()
+	bulb, ok := r.bulbs[entry.ID]
+	r.mtx.Unlock()
+	if ok {
+		return bulb, nil
+	}
+	if entry.Ip == "" {
+		return nil, &UnreachableError{Name: entry.Name, Err: fmt.Errorf("address of \"%s\" is unknown, discovery is required", entry.Name)}
+	}
+
+	bulb = yl.NewBulb(entry.Ip)
+	if entry.Port != 0 {
+		bulb.Port = entry.Port
+	}
+	if err := bulb.ConnectTimeout(connectTimeout); err != nil {
+		return nil, &UnreachableError{Name: entry.Name, Err: fmt.Errorf("\"%s\": %v", entry.Name, err)}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// bulb may be connected concurrently or its address may change in the meantime
+	if connected, ok := r.bulbs[entry.ID]; ok {
+		_ = bulb.Disconnect()
+		return connected, nil
+	}
+	if current, ok := r.entries[entry.ID]; !ok || current.Ip != entry.Ip || current.Port != entry.Port {
+		_ = bulb.Disconnect()
+		return nil, &UnreachableError{Name: entry.Name, Err: fmt.Errorf("\"%s\": address changed while connecting", entry.Name)}
+	}
+	r.bulbs[entry.ID] = bulb
+	return bulb, nil
+}
+
+// Disconnect closes connection of bulb with given name or device ID,
+// next Bulb or Group call connects again (for instance after device was restarted)
+func (r *Registry) Disconnect(name string) error {
+	entry, err := r.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.dropBulb(entry.ID)
+	return nil
+}
+
+// dropBulb disconnects cached bulb, registry lock is required
+func (r *Registry) dropBulb(id string) {
+	if bulb, ok := r.bulbs[id]; ok {
+		_ = bulb.Disconnect()
+		delete(r.bulbs, id)
+	}
+}
+
+// Close disconnects all bulbs connected by registry
+func (r *Registry) Close() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for id := range r.bulbs {
+		r.dropBulb(id)
+	}
+}
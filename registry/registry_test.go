@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+func TestGroupPartial(t *testing.T) {
+	reachable, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reachable.Close()
+	unreachable, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable.Close()
+
+	r := New("")
+	defer r.Close()
+	for _, entry := range []Entry{
+		{ID: reachable.ID, Name: "a", Ip: reachable.Ip, Port: reachable.Port},
+		{ID: unreachable.ID, Name: "b", Ip: unreachable.Ip, Port: unreachable.Port},
+	} {
+		if err := r.Set(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	group, err := r.Group("all")
+	failed, ok := err.(yl.GroupError)
+	if !ok {
+		t.Fatalf("expected GroupError, got %v", err)
+	}
+	if _, ok := failed[unreachable.Addr()]; !ok || len(failed) != 1 {
+		t.Errorf("expected error of %s only, got %v", unreachable.Addr(), failed)
+	}
+	bulbs := group.Bulbs()
+	if len(bulbs) != 1 || bulbs[0].Address() != reachable.Addr() {
+		t.Fatalf("expected reachable bulb only, got %v", bulbs)
+	}
+	if err := group.Toggle(); err != nil {
+		t.Fatal(err)
+	}
+	if commands := reachable.Commands(); len(commands) != 1 || commands[0].Method != "toggle" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+}
+
+func TestConnectReusesBulb(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	r := New("")
+	defer r.Close()
+	if err := r.Set(Entry{ID: device.ID, Name: "desk", Ip: device.Ip, Port: device.Port}); err != nil {
+		t.Fatal(err)
+	}
+
+	bulbs := make(chan *yl.Bulb, 4)
+	for i := 0; i < cap(bulbs); i++ {
+		go func() {
+			bulb, err := r.Bulb("desk")
+			if err != nil {
+				t.Error(err)
+			}
+			bulbs <- bulb
+		}()
+	}
+	first := <-bulbs
+	for i := 1; i < cap(bulbs); i++ {
+		if bulb := <-bulbs; bulb != first {
+			t.Error("concurrent connections returned different bulbs")
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	var backoff Backoff
+	stop := make(chan struct{})
+	close(stop)
+
+	start := time.Now()
+	if backoff.Wait(stop) {
+		t.Error("expected stopped wait")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("stopped wait took %v", elapsed)
+	}
+	if backoff.delay != minReconnectDelay {
+		t.Errorf("expected delay %v, got %v", minReconnectDelay, backoff.delay)
+	}
+
+	backoff.delay = maxReconnectDelay
+	backoff.Reset()
+	if backoff.delay != minReconnectDelay {
+		t.Errorf("expected delay %v after reset, got %v", minReconnectDelay, backoff.delay)
+	}
+}
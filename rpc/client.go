@@ -0,0 +1,237 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/gethiox/yeelight-go/rpc/yeelightpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client is a client of Yeelight service, generated client is available with API
+type Client struct {
+	conn *grpc.ClientConn
+	api  pb.YeelightClient
+}
+
+// Dial creates client of service at given address, plaintext connection is used when
+// no options are given
+func Dial(address string, opts ...grpc.DialOption) (*Client, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.NewClient(address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, api: pb.NewYeelightClient(conn)}, nil
+}
+
+// NewClient creates client using existing connection, connection is not closed by Close
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{api: pb.NewYeelightClient(conn)}
+}
+
+// API returns generated client
+func (c *Client) API() pb.YeelightClient {
+	return c.api
+}
+
+// Close closes connection opened by Dial
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Bulb returns target of a single bulb, by name or device ID
+func Bulb(name string) *pb.Target {
+	return &pb.Target{Target: &pb.Target_Bulb{Bulb: name}}
+}
+
+// Group returns target of bulbs matching selector ("all", "room:kitchen", "tag:ceiling", "office/*")
+func Group(selector string) *pb.Target {
+	return &pb.Target{Target: &pb.Target_Group{Group: selector}}
+}
+
+// Background returns target of background light of given bulbs
+func Background(target *pb.Target) *pb.Target {
+	return &pb.Target{Target: target.Target, Background: true}
+}
+
+// CommandError is returned when command failed on some of group bulbs
+type CommandError struct {
+	Failed []*pb.BulbResult
+}
+
+func (e *CommandError) Error() string {
+	var messages []string
+	for _, result := range e.Failed {
+		messages = append(messages, fmt.Sprintf("[%s] %s", result.Bulb, result.Error))
+	}
+	return fmt.Sprintf("%d of group bulbs failed: %s", len(e.Failed), strings.Join(messages, "; "))
+}
+
+// commandError returns CommandError of failed bulbs, nil when all bulbs succeeded
+func commandError(resp *pb.CommandResponse, err error) error {
+	if err != nil {
+		return err
+	}
+	var failed []*pb.BulbResult
+	for _, result := range resp.Results {
+		if result.Error != "" {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &CommandError{Failed: failed}
+}
+
+func milliseconds(d time.Duration) uint32 {
+	return uint32(d / time.Millisecond)
+}
+
+// Bulbs returns registered bulbs matching selector, all bulbs when selector is empty
+func (c *Client) Bulbs(ctx context.Context, selector string) ([]*pb.Bulb, error) {
+	resp, err := c.api.ListBulbs(ctx, &pb.ListBulbsRequest{Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Bulbs, nil
+}
+
+// State reads state of a single bulb
+func (c *Client) State(ctx context.Context, bulb string) (*pb.State, error) {
+	return c.api.GetState(ctx, &pb.GetStateRequest{Bulb: bulb})
+}
+
+// SetPower turns lights on or off
+func (c *Client) SetPower(ctx context.Context, target *pb.Target, on bool, d time.Duration) error {
+	return commandError(c.api.SetPower(ctx, &pb.SetPowerRequest{Target: target, On: on, DurationMs: milliseconds(d)}))
+}
+
+// SetColor sets color given by name ("#ff8800", "orange", "3000K", see yeelight.ParseColor),
+// brightness is kept when zero
+func (c *Client) SetColor(ctx context.Context, target *pb.Target, color string, brightness int, d time.Duration) error {
+	req := &pb.SetColorRequest{Target: target, Brightness: int32(brightness), DurationMs: milliseconds(d)}
+	if color != "" {
+		req.Color = &pb.SetColorRequest_Name{Name: color}
+	}
+	return commandError(c.api.SetColor(ctx, req))
+}
+
+// SetRGB sets RGB color
+func (c *Client) SetRGB(ctx context.Context, target *pb.Target, rgb int, d time.Duration) error {
+	req := &pb.SetColorRequest{Target: target, Color: &pb.SetColorRequest_Rgb{Rgb: int32(rgb)}, DurationMs: milliseconds(d)}
+	return commandError(c.api.SetColor(ctx, req))
+}
+
+// SetHSV sets hue and saturation
+func (c *Client) SetHSV(ctx context.Context, target *pb.Target, hue, saturation int, d time.Duration) error {
+	hsv := &pb.HSV{Hue: int32(hue), Saturation: int32(saturation)}
+	req := &pb.SetColorRequest{Target: target, Color: &pb.SetColorRequest_Hsv{Hsv: hsv}, DurationMs: milliseconds(d)}
+	return commandError(c.api.SetColor(ctx, req))
+}
+
+// SetTemperature sets color temperature in kelvins
+func (c *Client) SetTemperature(ctx context.Context, target *pb.Target, temp int, d time.Duration) error {
+	req := &pb.SetColorRequest{Target: target, Color: &pb.SetColorRequest_Temperature{Temperature: int32(temp)}, DurationMs: milliseconds(d)}
+	return commandError(c.api.SetColor(ctx, req))
+}
+
+// SetBrightness sets brightness, 1-100
+func (c *Client) SetBrightness(ctx context.Context, target *pb.Target, brightness int, d time.Duration) error {
+	return c.SetColor(ctx, target, "", brightness, d)
+}
+
+// StartPreset starts flow preset by name, see flows.Names
+func (c *Client) StartPreset(ctx context.Context, target *pb.Target, preset string) error {
+	flow := &pb.Flow{Flow: &pb.Flow_Preset{Preset: preset}}
+	return c.StartFlow(ctx, target, flow)
+}
+
+// StartFlow starts color flow
+func (c *Client) StartFlow(ctx context.Context, target *pb.Target, flow *pb.Flow) error {
+	return commandError(c.api.StartFlow(ctx, &pb.StartFlowRequest{Target: target, Flow: flow}))
+}
+
+// StopFlow stops running color flow
+func (c *Client) StopFlow(ctx context.Context, target *pb.Target) error {
+	return commandError(c.api.StopFlow(ctx, &pb.StopFlowRequest{Target: target}))
+}
+
+// SetScene sets light directly to specified state
+func (c *Client) SetScene(ctx context.Context, req *pb.SetSceneRequest) error {
+	return commandError(c.api.SetScene(ctx, req))
+}
+
+// Events calls handler for every received event until context is canceled or stream fails
+func (c *Client) Events(ctx context.Context, filter *pb.StreamEventsRequest, handler func(*pb.Event)) error {
+	if filter == nil {
+		filter = &pb.StreamEventsRequest{}
+	}
+	stream, err := c.api.StreamEvents(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		handler(event)
+	}
+}
+
+// MusicStream sends frames to bulbs in music mode
+type MusicStream struct {
+	stream pb.Yeelight_StreamMusicFramesClient
+	target *pb.Target // sent with the first frame
+}
+
+// Music opens music stream for target bulbs, music mode is started with the first frame
+func (c *Client) Music(ctx context.Context, target *pb.Target) (*MusicStream, error) {
+	stream, err := c.api.StreamMusicFrames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &MusicStream{stream: stream, target: target}, nil
+}
+
+// Send sends frame, frames are not acknowledged, so failed frame is reported by Close
+func (m *MusicStream) Send(frame *pb.MusicFrame) error {
+	if m.target != nil {
+		frame.Target = m.target
+		m.target = nil
+	}
+	return m.stream.Send(frame)
+}
+
+// RGB sends RGB color frame, brightness is kept when zero
+func (m *MusicStream) RGB(rgb, brightness int, d time.Duration) error {
+	return m.Send(&pb.MusicFrame{Color: &pb.MusicFrame_Rgb{Rgb: int32(rgb)}, Brightness: int32(brightness), DurationMs: milliseconds(d)})
+}
+
+// HSV sends hue and saturation frame, brightness is kept when zero
+func (m *MusicStream) HSV(hue, saturation, brightness int, d time.Duration) error {
+	hsv := &pb.HSV{Hue: int32(hue), Saturation: int32(saturation)}
+	return m.Send(&pb.MusicFrame{Color: &pb.MusicFrame_Hsv{Hsv: hsv}, Brightness: int32(brightness), DurationMs: milliseconds(d)})
+}
+
+// Close ends stream and stops music mode, number of frames sent to bulbs is returned
+func (m *MusicStream) Close() (int, error) {
+	resp, err := m.stream.CloseAndRecv()
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.Frames), nil
+}
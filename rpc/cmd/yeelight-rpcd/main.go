@@ -0,0 +1,113 @@
+// Command yeelight-rpcd serves bulbs from registry file as gRPC service (see rpc package),
+// REST API of gateway package can be served by the same process:
+//   yeelight-rpcd -listen :50051 -http :8080 -config bulbs.json
+//   grpcurl -plaintext -d '{"target": {"group": "all"}, "on": true}' localhost:50051 yeelight.v1.Yeelight/SetPower
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gethiox/yeelight-go/gateway"
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	var (
+		listen      = flag.String("listen", ":50051", "gRPC listen address")
+		httpListen  = flag.String("http", "", "REST API listen address, empty disables REST API")
+		configPath  = flag.String("config", defaultConfigPath(), "bulbs registry file")
+		refresh     = flag.Duration("refresh", 5*time.Minute, "discovery interval, 0 disables discovery")
+		timeout     = flag.Duration("timeout", 2*time.Second, "discovery timeout")
+		concurrency = flag.Int("concurrency", 4, "maximum number of bulbs controlled in parallel by group commands")
+		iface       = flag.String("iface", "", "network interface used for music mode")
+		verbose     = flag.Bool("v", false, "log commands sent to bulbs")
+	)
+	flag.Parse()
+
+	// library logs every command, daemon logs are kept separately
+	logger := log.New(os.Stderr, "yeelight-rpcd: ", log.LstdFlags)
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	reg, err := registry.Load(*configPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer reg.Close()
+
+	gw := gateway.New(reg)
+	gw.SetConcurrency(*concurrency)
+	gw.Monitor()
+
+	service := rpc.NewServer(gw)
+	service.SetConcurrency(*concurrency)
+	service.SetMusicInterface(*iface)
+
+	if *refresh > 0 {
+		discover(gw, *configPath, *timeout, logger)
+		go func() {
+			for range time.Tick(*refresh) {
+				discover(gw, *configPath, *timeout, logger)
+			}
+		}()
+	}
+
+	if *httpListen != "" {
+		go func() {
+			logger.Fatal(http.ListenAndServe(*httpListen, gw))
+		}()
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	server := grpc.NewServer()
+	service.Register(server)
+	reflection.Register(server)
+
+	logger.Printf("listening on %s, %d bulb(s) registered", *listen, len(reg.Entries()))
+	logger.Fatal(server.Serve(listener))
+}
+
+// discover updates registry with found devices and saves it when anything has changed
+func discover(gw *gateway.Server, configPath string, timeout time.Duration, logger *log.Logger) {
+	changed, err := gw.Discover(timeout)
+	if err != nil {
+		logger.Printf("discovery failed: %v", err)
+		return
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	for _, entry := range changed {
+		logger.Printf("bulb \"%s\" found at %s", entry.Name, entry.Ip)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		logger.Printf("saving registry failed: %v", err)
+		return
+	}
+	if err := gw.Registry().Save(); err != nil {
+		logger.Printf("saving registry failed: %v", err)
+	}
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "bulbs.json"
+	}
+	return filepath.Join(home, ".config", "yeelight", "bulbs.json")
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"strings"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invalidArgument returns error of invalid request
+func invalidArgument(format string, args ...interface{}) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
+
+// code maps error into status code:
+//   InvalidArgument     values rejected by the library
+//   NotFound            unknown bulb or no bulbs matching selector
+//   FailedPrecondition  command rejected by device
+//   Unimplemented       method not supported by device
+//   Unavailable         device is unreachable or connection was lost
+func code(err error) codes.Code {
+	switch e := err.(type) {
+	case *registry.NotFoundError:
+		return codes.NotFound
+	case *registry.UnreachableError:
+		return codes.Unavailable
+	case *yl.DeviceError:
+		if strings.Contains(e.Message, "not supported") {
+			return codes.Unimplemented
+		}
+		return codes.FailedPrecondition
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+	if registry.IsConnectionError(err) {
+		return codes.Unavailable
+	}
+	// remaining errors are returned by the library before command is sent (validation)
+	return codes.InvalidArgument
+}
+
+// statusError converts error into RPC error, device error code is kept in message
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(code(err), err.Error())
+}
+
+// deviceCode returns error code reported by device, 0 for remaining errors
+func deviceCode(err error) int32 {
+	if e, ok := err.(*yl.DeviceError); ok {
+		return int32(e.Code)
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"io"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+	pb "github.com/gethiox/yeelight-go/rpc/yeelightpb"
+)
+
+// StreamMusicFrames sends received frames to bulbs in music mode, music mode is stopped when stream ends
+func (s *Server) StreamMusicFrames(stream pb.Yeelight_StreamMusicFramesServer) error {
+	sessions := make(map[string]*yl.Music) // keyed by device ID
+	defer func() {
+		for _, music := range sessions {
+			_ = music.Stop()
+		}
+	}()
+
+	var (
+		targets []*yl.Music
+		frames  uint32
+	)
+	for {
+		frame, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&pb.StreamMusicFramesResponse{Frames: frames})
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := validateFrame(frame); err != nil {
+			return err
+		}
+		if frame.Target != nil {
+			if targets, err = s.startMusic(frame.Target, sessions); err != nil {
+				return statusError(err)
+			}
+		}
+		if targets == nil {
+			return invalidArgument("target is required in first frame")
+		}
+
+		d := duration(frame.DurationMs)
+		for _, m := range targets {
+			switch color := frame.Color.(type) {
+			case *pb.MusicFrame_Rgb:
+				m.SetRGB(int(color.Rgb), d)
+			case *pb.MusicFrame_Hsv:
+				m.SetHSV(int(color.Hsv.Hue), int(color.Hsv.Saturation), d)
+			case *pb.MusicFrame_Temperature:
+				m.SetTemperature(int(color.Temperature), d)
+			}
+			if frame.Brightness != 0 {
+				m.SetBrightness(int(frame.Brightness), d)
+			}
+		}
+		frames++
+	}
+}
+
+// validateFrame checks frame values, music mode commands cannot report errors
+func validateFrame(frame *pb.MusicFrame) error {
+	switch color := frame.Color.(type) {
+	case *pb.MusicFrame_Rgb:
+		if color.Rgb < 0 || color.Rgb > 0xffffff {
+			return invalidArgument("rgb expected in 0~16777215 range, got %d", color.Rgb)
+		}
+	case *pb.MusicFrame_Hsv:
+		if color.Hsv == nil {
+			return invalidArgument("hsv requires hue and saturation")
+		}
+		if color.Hsv.Hue < 0 || color.Hsv.Hue > 359 || color.Hsv.Saturation < 0 || color.Hsv.Saturation > 100 {
+			return invalidArgument("hue expected in 0~359 and saturation in 0~100 range")
+		}
+	case *pb.MusicFrame_Temperature:
+		if color.Temperature < 1700 || color.Temperature > 6500 {
+			return invalidArgument("temperature expected in 1700~6500 range, got %d", color.Temperature)
+		}
+	}
+	if frame.Brightness < 0 || frame.Brightness > 100 {
+		return invalidArgument("brightness expected in 1~100 range, got %d", frame.Brightness)
+	}
+	if d := duration(frame.DurationMs); d != 0 && d < yl.MinSmoothDuration {
+		return invalidArgument("duration expected 0 (sudden) or >= 30 milliseconds (smooth)")
+	}
+	return nil
+}
+
+// startMusic returns music mode connections of target bulbs, connections are started when needed
+func (s *Server) startMusic(target *pb.Target, sessions map[string]*yl.Music) ([]*yl.Music, error) {
+	if target.Background {
+		return nil, invalidArgument("music mode is not supported by background light")
+	}
+
+	var (
+		entries []registry.Entry
+		err     error
+	)
+	switch t := target.Target.(type) {
+	case *pb.Target_Bulb:
+		var entry registry.Entry
+		entry, err = s.registry.Lookup(t.Bulb)
+		entries = []registry.Entry{entry}
+	case *pb.Target_Group:
+		entries, err = s.registry.Select(t.Group)
+	default:
+		return nil, invalidArgument("bulb or group is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*yl.Music
+	for _, entry := range entries {
+		music, ok := sessions[entry.ID]
+		if !ok {
+			yl.WaitQuota()
+			if music, err = s.registry.StartMusic(entry.ID, s.iface); err != nil {
+				return nil, err
+			}
+			sessions[entry.ID] = music
+		}
+		targets = append(targets, music)
+	}
+	return targets, nil
+}
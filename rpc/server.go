@@ -0,0 +1,370 @@
+// Package rpc exposes bulbs registered in registry as gRPC service (see yeelight.proto),
+// a typed alternative of gateway's REST API for services in other languages.
+//
+// Server is built on gateway.Server, which keeps connections to bulbs and produces events,
+// both APIs can be served by one process. Client wraps generated client with convenience methods:
+//   client, err := rpc.Dial("localhost:50051")
+//   err = client.SetPower(ctx, rpc.Group("room:kitchen"), true, 500*time.Millisecond)
+package rpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+	"github.com/gethiox/yeelight-go/gateway"
+	"github.com/gethiox/yeelight-go/registry"
+	pb "github.com/gethiox/yeelight-go/rpc/yeelightpb"
+	"google.golang.org/grpc"
+)
+
+// Server implements Yeelight service
+type Server struct {
+	pb.UnimplementedYeelightServer
+
+	gateway  *gateway.Server
+	registry *registry.Registry
+
+	iface       string
+	concurrency int
+}
+
+// NewServer creates service controlling bulbs of given gateway, events are streamed for bulbs
+// monitored by the gateway (see gateway.Server.Monitor)
+func NewServer(gw *gateway.Server) *Server {
+	return &Server{gateway: gw, registry: gw.Registry(), concurrency: 4}
+}
+
+// Register registers service in gRPC server
+func (s *Server) Register(server *grpc.Server) {
+	pb.RegisterYeelightServer(server, s)
+}
+
+// SetMusicInterface sets network interface used for music mode connections, see yeelight.Bulb.StartMusic.
+// First available interface is used by default
+func (s *Server) SetMusicInterface(iface string) {
+	s.iface = iface
+}
+
+// SetConcurrency sets maximum number of bulbs controlled in parallel by group commands
+func (s *Server) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s.concurrency = concurrency
+}
+
+// ListBulbs returns registered bulbs matching selector
+func (s *Server) ListBulbs(ctx context.Context, req *pb.ListBulbsRequest) (*pb.ListBulbsResponse, error) {
+	entries := s.registry.Entries()
+	if req.Selector != "" {
+		var err error
+		if entries, err = s.registry.Select(req.Selector); err != nil {
+			return nil, statusError(err)
+		}
+	}
+
+	resp := &pb.ListBulbsResponse{}
+	for _, entry := range entries {
+		resp.Bulbs = append(resp.Bulbs, &pb.Bulb{
+			Id: entry.ID, Name: entry.Name, Room: entry.Room, Tags: entry.Tags, Ip: entry.Ip, Port: int32(entry.Port),
+		})
+	}
+	return resp, nil
+}
+
+// GetState reads state of a single bulb
+func (s *Server) GetState(ctx context.Context, req *pb.GetStateRequest) (*pb.State, error) {
+	var snapshot yl.Snapshot
+	err := s.registry.Execute(req.Bulb, func(b *yl.Bulb) error {
+		var err error
+		snapshot, err = b.Snapshot()
+		return err
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	state := &pb.State{Main: lightState(snapshot.Main), TakenAtUnixNano: snapshot.TakenAt.UnixNano()}
+	if snapshot.Background != nil {
+		state.Background = lightState(*snapshot.Background)
+	}
+	return state, nil
+}
+
+func lightState(s yl.LightSnapshot) *pb.LightState {
+	return &pb.LightState{
+		Power:       s.Power,
+		Brightness:  int32(s.Brightness),
+		ColorMode:   pb.ColorMode(s.ColorMode),
+		Rgb:         int32(s.RGB),
+		Temperature: int32(s.CT),
+		Hue:         int32(s.Hue),
+		Saturation:  int32(s.Sat),
+		Flowing:     s.Flowing,
+	}
+}
+
+// light is a set of commands supported by both main and background light
+type light interface {
+	SetPower(on bool, d time.Duration) error
+	SetRGB(rgb int, d time.Duration) error
+	SetHSV(hue, saturation int, d time.Duration) error
+	SetTemperature(temp int, d time.Duration) error
+	SetColor(color yl.Color, d time.Duration) error
+	SetBrightness(brightness int, d time.Duration) error
+	StartColorFlow(count int, action yl.CfAction, flowExpression yl.FlowExpression) error
+	StopColorFlow() error
+	SetScene(scene yl.Scene) error
+}
+
+// command is a single action executed on light
+type command func(l light) error
+
+func duration(ms uint32) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// SetPower turns lights on or off
+func (s *Server) SetPower(ctx context.Context, req *pb.SetPowerRequest) (*pb.CommandResponse, error) {
+	return s.run(req.Target, func(l light) error { return l.SetPower(req.On, duration(req.DurationMs)) })
+}
+
+// SetColor changes color and brightness
+func (s *Server) SetColor(ctx context.Context, req *pb.SetColorRequest) (*pb.CommandResponse, error) {
+	d := duration(req.DurationMs)
+
+	var setColor command
+	switch color := req.Color.(type) {
+	case *pb.SetColorRequest_Rgb:
+		setColor = func(l light) error { return l.SetRGB(int(color.Rgb), d) }
+	case *pb.SetColorRequest_Hsv:
+		if color.Hsv == nil {
+			return nil, invalidArgument("hsv requires hue and saturation")
+		}
+		setColor = func(l light) error { return l.SetHSV(int(color.Hsv.Hue), int(color.Hsv.Saturation), d) }
+	case *pb.SetColorRequest_Temperature:
+		setColor = func(l light) error { return l.SetTemperature(int(color.Temperature), d) }
+	case *pb.SetColorRequest_Name:
+		parsed, err := yl.ParseColor(color.Name)
+		if err != nil {
+			return nil, invalidArgument("%v", err)
+		}
+		setColor = func(l light) error { return l.SetColor(parsed, d) }
+	case nil:
+		if req.Brightness == 0 {
+			return nil, invalidArgument("color or brightness is required")
+		}
+	}
+
+	return s.run(req.Target, func(l light) error {
+		if setColor != nil {
+			if err := setColor(l); err != nil {
+				return err
+			}
+		}
+		if req.Brightness != 0 {
+			return l.SetBrightness(int(req.Brightness), d)
+		}
+		return nil
+	})
+}
+
+// parseFlow converts flow given in request
+func parseFlow(f *pb.Flow) (flows.Flow, error) {
+	if f == nil {
+		return flows.Flow{}, invalidArgument("flow is required")
+	}
+
+	switch source := f.Flow.(type) {
+	case *pb.Flow_Preset:
+		flow, err := flows.Preset(source.Preset)
+		if err != nil {
+			return flows.Flow{}, invalidArgument("%v", err)
+		}
+		return flow, nil
+	case *pb.Flow_Text:
+		flow, err := flows.ParseText(strings.NewReader(source.Text))
+		if err != nil {
+			return flows.Flow{}, invalidArgument("%v", err)
+		}
+		return flow, nil
+	case *pb.Flow_Expression:
+		expression, err := yl.ParseFlowExpression(source.Expression)
+		if err != nil {
+			return flows.Flow{}, invalidArgument("%v", err)
+		}
+		action, ok := map[pb.FlowAction]yl.CfAction{
+			pb.FlowAction_FLOW_ACTION_RECOVER: yl.CF_ACTION_RECOVER,
+			pb.FlowAction_FLOW_ACTION_STAY:    yl.CF_ACTION_STAY,
+			pb.FlowAction_FLOW_ACTION_OFF:     yl.CF_ACTION_POWEROFF,
+		}[f.Action]
+		if !ok {
+			return flows.Flow{}, invalidArgument("unknown flow action %v", f.Action)
+		}
+		return flows.Flow{Count: int(f.Count), Action: action, Expression: expression}, nil
+	}
+	return flows.Flow{}, invalidArgument("one of preset, text or expression is required")
+}
+
+// StartFlow starts color flow
+func (s *Server) StartFlow(ctx context.Context, req *pb.StartFlowRequest) (*pb.CommandResponse, error) {
+	flow, err := parseFlow(req.Flow)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(req.Target, func(l light) error { return l.StartColorFlow(flow.Count, flow.Action, flow.Expression) })
+}
+
+// StopFlow stops running color flow
+func (s *Server) StopFlow(ctx context.Context, req *pb.StopFlowRequest) (*pb.CommandResponse, error) {
+	return s.run(req.Target, func(l light) error { return l.StopColorFlow() })
+}
+
+// SetScene sets light directly to specified state, light is turned on if it's off
+func (s *Server) SetScene(ctx context.Context, req *pb.SetSceneRequest) (*pb.CommandResponse, error) {
+	var scene yl.Scene
+
+	switch source := req.Scene.(type) {
+	case *pb.SetSceneRequest_Rgb:
+		scene = yl.NewColorScene(int(source.Rgb), int(req.Brightness))
+	case *pb.SetSceneRequest_Hsv:
+		if source.Hsv == nil {
+			return nil, invalidArgument("hsv requires hue and saturation")
+		}
+		scene = yl.NewHSVScene(int(source.Hsv.Hue), int(source.Hsv.Saturation), int(req.Brightness))
+	case *pb.SetSceneRequest_Temperature:
+		scene = yl.NewTemperatureScene(int(source.Temperature), int(req.Brightness))
+	case *pb.SetSceneRequest_Flow:
+		flow, err := parseFlow(source.Flow)
+		if err != nil {
+			return nil, err
+		}
+		scene = yl.NewColorFlowScene(flow.Count, flow.Action, flow.Expression)
+	case *pb.SetSceneRequest_DelayOffMinutes:
+		scene = yl.NewAutoDelayOffScene(int(req.Brightness), int(source.DelayOffMinutes))
+	default:
+		return nil, invalidArgument("scene is required")
+	}
+
+	return s.run(req.Target, func(l light) error { return l.SetScene(scene) })
+}
+
+// run executes command on target bulbs. Errors of single bulb target are returned as RPC errors,
+// group commands report errors of every bulb in response
+func (s *Server) run(target *pb.Target, cmd command) (*pb.CommandResponse, error) {
+	if target == nil {
+		return nil, invalidArgument("target is required")
+	}
+	selectLight := func(b *yl.Bulb) light { return b }
+	if target.Background {
+		selectLight = func(b *yl.Bulb) light { return &b.Bg }
+	}
+	run := func(b *yl.Bulb) error { return cmd(selectLight(b)) }
+
+	switch t := target.Target.(type) {
+	case *pb.Target_Bulb:
+		entry, err := s.registry.Lookup(t.Bulb)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		yl.WaitQuota()
+		if err := s.registry.Execute(t.Bulb, run); err != nil {
+			return nil, statusError(err)
+		}
+		return &pb.CommandResponse{Results: []*pb.BulbResult{{Bulb: entry.Name}}}, nil
+	case *pb.Target_Group:
+		results, err := s.executeGroup(t.Group, run)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		return &pb.CommandResponse{Results: results}, nil
+	}
+	return nil, invalidArgument("bulb or group is required")
+}
+
+// executeGroup runs function on every bulb matching selector as yeelight.Group,
+// bulbs which cannot be connected are reported as failed
+func (s *Server) executeGroup(selector string, fn func(b *yl.Bulb) error) ([]*pb.BulbResult, error) {
+	entries, err := s.registry.Select(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(entries)) // keyed by bulb address
+	for _, entry := range entries {
+		names[entry.Address()] = entry.Name
+	}
+
+	group, err := s.registry.GroupOf(entries)
+	failed := yl.GroupError{}
+	if unreachable, ok := err.(yl.GroupError); ok {
+		failed = unreachable
+	}
+	if len(group.Bulbs()) > 0 {
+		group.SetConcurrency(s.concurrency)
+		// bulb is already connected, Execute reuses connection and reconnects when it was lost
+		err := group.Each(func(b *yl.Bulb) error { return s.registry.Execute(names[b.Address()], fn) })
+		if errs, ok := err.(yl.GroupError); ok {
+			for address, err := range errs {
+				failed[address] = err
+			}
+		}
+	}
+
+	results := make([]*pb.BulbResult, len(entries))
+	for i, entry := range entries {
+		results[i] = &pb.BulbResult{Bulb: entry.Name}
+		if err, ok := failed[entry.Address()]; ok {
+			results[i].Error = err.Error()
+			results[i].DeviceCode = deviceCode(err)
+		}
+	}
+	return results, nil
+}
+
+// StreamEvents streams events published by gateway until client cancels the stream
+func (s *Server) StreamEvents(req *pb.StreamEventsRequest, stream pb.Yeelight_StreamEventsServer) error {
+	filter := gateway.Filter{Bulbs: req.Bulbs, Props: req.Props}
+	for _, t := range req.Types {
+		name, ok := eventTypes[t]
+		if !ok {
+			return invalidArgument("unknown event type %v", t)
+		}
+		filter.Types = append(filter.Types, name)
+	}
+
+	events, cancel := s.gateway.Subscribe(filter)
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e := <-events:
+			event := &pb.Event{
+				Bulb: e.Bulb, Id: e.ID, Ip: e.Ip, Props: e.Props, Error: e.Error, TimeUnixNano: e.Time.UnixNano(),
+			}
+			for t, name := range eventTypes {
+				if name == e.Type {
+					event.Type = t
+				}
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// eventTypes maps event types into gateway event names
+var eventTypes = map[pb.EventType]string{
+	pb.EventType_EVENT_TYPE_PROPS:           gateway.EventProps,
+	pb.EventType_EVENT_TYPE_CONNECTED:       gateway.EventConnected,
+	pb.EventType_EVENT_TYPE_DISCONNECTED:    gateway.EventDisconnected,
+	pb.EventType_EVENT_TYPE_DISCOVERED:      gateway.EventDiscovered,
+	pb.EventType_EVENT_TYPE_ADDRESS_CHANGED: gateway.EventAddressChanged,
+	pb.EventType_EVENT_TYPE_LOST:            gateway.EventLost,
+}
@@ -0,0 +1,196 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/gateway"
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testService is gRPC service served over bufconn listener, controlling fake devices
+type testService struct {
+	client   *Client
+	server   *grpc.Server
+	registry *registry.Registry
+	devices  map[string]*yeelighttest.Device
+}
+
+func (s *testService) Close() {
+	_ = s.client.Close()
+	s.server.Stop()
+	s.registry.Close()
+	for _, device := range s.devices {
+		device.Close()
+	}
+}
+
+// newTestService starts service controlling fake devices registered under given names
+func newTestService(t *testing.T, names ...string) *testService {
+	reg := registry.New("")
+	devices := make(map[string]*yeelighttest.Device)
+	for _, name := range names {
+		device, err := yeelighttest.NewDevice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		devices[name] = device
+		entry := registry.Entry{ID: device.ID, Name: name, Room: "office", Ip: device.Ip, Port: device.Port}
+		if err := reg.Set(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	NewServer(gateway.New(reg)).Register(server)
+	go server.Serve(listener)
+
+	client, err := Dial("passthrough:///bufnet", grpc.WithContextDialer(
+		func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testService{client: client, server: server, registry: reg, devices: devices}
+}
+
+func TestSetPower(t *testing.T) {
+	service := newTestService(t, "desk")
+	defer service.Close()
+
+	if err := service.client.SetPower(context.Background(), Bulb("desk"), true, 500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	commands := service.devices["desk"].Commands()
+	if len(commands) != 1 || commands[0].Method != "set_power" || commands[0].Params[0] != "on" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	service := newTestService(t, "desk", "lamp")
+	defer service.Close()
+	service.devices["desk"].Fail("set_power", &yl.DeviceError{Code: -5000, Message: "general error"})
+	service.devices["desk"].Fail("set_bright", &yl.DeviceError{Code: -1, Message: "method not supported"})
+	service.devices["lamp"].Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"unknown bulb", service.client.SetPower(ctx, Bulb("kitchen"), true, 0), codes.NotFound},
+		{"invalid color", service.client.SetColor(ctx, Bulb("desk"), "nope", 0, 0), codes.InvalidArgument},
+		{"device error", service.client.SetPower(ctx, Bulb("desk"), true, 0), codes.FailedPrecondition},
+		{"not supported", service.client.SetBrightness(ctx, Bulb("desk"), 50, 0), codes.Unimplemented},
+		{"unreachable", service.client.SetPower(ctx, Bulb("lamp"), true, 0), codes.Unavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := status.Code(test.err); code != test.code {
+				t.Errorf("expected %v, got %v (%v)", test.code, code, test.err)
+			}
+		})
+	}
+}
+
+func TestGroupCommand(t *testing.T) {
+	service := newTestService(t, "desk", "shelf", "lamp")
+	defer service.Close()
+	service.devices["lamp"].Close()
+	service.devices["shelf"].Fail("set_power", &yl.DeviceError{Code: -5000, Message: "general error"})
+
+	err := service.client.SetPower(context.Background(), Group("room:office"), true, 0)
+	var commandErr *CommandError
+	if !errors.As(err, &commandErr) {
+		t.Fatalf("expected CommandError, got %v", err)
+	}
+	failed := make(map[string]int32)
+	for _, result := range commandErr.Failed {
+		failed[result.Bulb] = result.DeviceCode
+	}
+	if len(failed) != 2 || failed["shelf"] != -5000 || failed["lamp"] != 0 {
+		t.Errorf("unexpected failed bulbs: %v", commandErr)
+	}
+	if service.devices["desk"].Prop(yl.PROP_POWER) != "on" {
+		t.Error("reachable bulb wasn't powered on")
+	}
+}
+
+func TestGetState(t *testing.T) {
+	service := newTestService(t, "desk")
+	defer service.Close()
+	service.devices["desk"].SetProp(yl.PROP_POWER, "on")
+	service.devices["desk"].SetProp(yl.PROP_BRIGHT, "42")
+
+	state, err := service.client.State(context.Background(), "desk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Main.Power || state.Main.Brightness != 42 || state.Background != nil {
+		t.Errorf("unexpected state: %v", state)
+	}
+}
+
+func TestMusic(t *testing.T) {
+	service := newTestService(t, "desk")
+	defer service.Close()
+	device := service.devices["desk"]
+
+	music, err := service.client.Music(context.Background(), Bulb("desk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := music.RGB(0xff0000, 50, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := music.HSV(120, 100, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := music.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames != 2 {
+		t.Errorf("expected 2 frames, got %d", frames)
+	}
+
+	// music mode commands are not acknowledged
+	expected := []string{"set_rgb", "set_bright", "set_hsv"}
+	deadline := time.Now().Add(time.Second)
+	for {
+		var received []string
+		for _, command := range device.Commands() {
+			if command.Music {
+				received = append(received, command.Method)
+			}
+		}
+		if len(received) == len(expected) {
+			for i := range expected {
+				if received[i] != expected[i] {
+					t.Errorf("expected %v, got %v", expected, received)
+				}
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v, got %v", expected, received)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if device.Prop(yl.PROP_POWER) != "on" {
+		t.Error("bulb wasn't turned on for music mode")
+	}
+}
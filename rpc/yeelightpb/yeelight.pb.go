@@ -0,0 +1,2016 @@
+// Remote control of bulbs registered in registry, implemented by rpc.Server.
+// Go code in yeelightpb is generated with:
+//   buf generate
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: yeelight.proto
+
+package yeelightpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ColorMode int32
+
+const (
+	ColorMode_COLOR_MODE_UNSPECIFIED ColorMode = 0
+	ColorMode_COLOR_MODE_RGB         ColorMode = 1
+	ColorMode_COLOR_MODE_TEMPERATURE ColorMode = 2
+	ColorMode_COLOR_MODE_HSV         ColorMode = 3
+)
+
+// Enum value maps for ColorMode.
+var (
+	ColorMode_name = map[int32]string{
+		0: "COLOR_MODE_UNSPECIFIED",
+		1: "COLOR_MODE_RGB",
+		2: "COLOR_MODE_TEMPERATURE",
+		3: "COLOR_MODE_HSV",
+	}
+	ColorMode_value = map[string]int32{
+		"COLOR_MODE_UNSPECIFIED": 0,
+		"COLOR_MODE_RGB":         1,
+		"COLOR_MODE_TEMPERATURE": 2,
+		"COLOR_MODE_HSV":         3,
+	}
+)
+
+func (x ColorMode) Enum() *ColorMode {
+	p := new(ColorMode)
+	*p = x
+	return p
+}
+
+func (x ColorMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ColorMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_yeelight_proto_enumTypes[0].Descriptor()
+}
+
+func (ColorMode) Type() protoreflect.EnumType {
+	return &file_yeelight_proto_enumTypes[0]
+}
+
+func (x ColorMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ColorMode.Descriptor instead.
+func (ColorMode) EnumDescriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{0}
+}
+
+type FlowAction int32
+
+const (
+	FlowAction_FLOW_ACTION_RECOVER FlowAction = 0
+	FlowAction_FLOW_ACTION_STAY    FlowAction = 1
+	FlowAction_FLOW_ACTION_OFF     FlowAction = 2
+)
+
+// Enum value maps for FlowAction.
+var (
+	FlowAction_name = map[int32]string{
+		0: "FLOW_ACTION_RECOVER",
+		1: "FLOW_ACTION_STAY",
+		2: "FLOW_ACTION_OFF",
+	}
+	FlowAction_value = map[string]int32{
+		"FLOW_ACTION_RECOVER": 0,
+		"FLOW_ACTION_STAY":    1,
+		"FLOW_ACTION_OFF":     2,
+	}
+)
+
+func (x FlowAction) Enum() *FlowAction {
+	p := new(FlowAction)
+	*p = x
+	return p
+}
+
+func (x FlowAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FlowAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_yeelight_proto_enumTypes[1].Descriptor()
+}
+
+func (FlowAction) Type() protoreflect.EnumType {
+	return &file_yeelight_proto_enumTypes[1]
+}
+
+func (x FlowAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FlowAction.Descriptor instead.
+func (FlowAction) EnumDescriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{1}
+}
+
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED EventType = 0
+	// bulb reported property changes
+	EventType_EVENT_TYPE_PROPS        EventType = 1
+	EventType_EVENT_TYPE_CONNECTED    EventType = 2
+	EventType_EVENT_TYPE_DISCONNECTED EventType = 3
+	// new bulb was found by discovery
+	EventType_EVENT_TYPE_DISCOVERED EventType = 4
+	// known bulb was found on a different address
+	EventType_EVENT_TYPE_ADDRESS_CHANGED EventType = 5
+	// known bulb didn't respond to discovery
+	EventType_EVENT_TYPE_LOST EventType = 6
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_PROPS",
+		2: "EVENT_TYPE_CONNECTED",
+		3: "EVENT_TYPE_DISCONNECTED",
+		4: "EVENT_TYPE_DISCOVERED",
+		5: "EVENT_TYPE_ADDRESS_CHANGED",
+		6: "EVENT_TYPE_LOST",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED":     0,
+		"EVENT_TYPE_PROPS":           1,
+		"EVENT_TYPE_CONNECTED":       2,
+		"EVENT_TYPE_DISCONNECTED":    3,
+		"EVENT_TYPE_DISCOVERED":      4,
+		"EVENT_TYPE_ADDRESS_CHANGED": 5,
+		"EVENT_TYPE_LOST":            6,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_yeelight_proto_enumTypes[2].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_yeelight_proto_enumTypes[2]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{2}
+}
+
+type Bulb struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Room          string                 `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"`
+	Tags          []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	Ip            string                 `protobuf:"bytes,5,opt,name=ip,proto3" json:"ip,omitempty"`
+	Port          int32                  `protobuf:"varint,6,opt,name=port,proto3" json:"port,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bulb) Reset() {
+	*x = Bulb{}
+	mi := &file_yeelight_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bulb) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bulb) ProtoMessage() {}
+
+func (x *Bulb) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bulb.ProtoReflect.Descriptor instead.
+func (*Bulb) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Bulb) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Bulb) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Bulb) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Bulb) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Bulb) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Bulb) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+type ListBulbsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "all", "room:kitchen", "tag:ceiling", "office/*", see registry.Select
+	Selector      string `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBulbsRequest) Reset() {
+	*x = ListBulbsRequest{}
+	mi := &file_yeelight_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBulbsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBulbsRequest) ProtoMessage() {}
+
+func (x *ListBulbsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBulbsRequest.ProtoReflect.Descriptor instead.
+func (*ListBulbsRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListBulbsRequest) GetSelector() string {
+	if x != nil {
+		return x.Selector
+	}
+	return ""
+}
+
+type ListBulbsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bulbs         []*Bulb                `protobuf:"bytes,1,rep,name=bulbs,proto3" json:"bulbs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBulbsResponse) Reset() {
+	*x = ListBulbsResponse{}
+	mi := &file_yeelight_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBulbsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBulbsResponse) ProtoMessage() {}
+
+func (x *ListBulbsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBulbsResponse.ProtoReflect.Descriptor instead.
+func (*ListBulbsResponse) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListBulbsResponse) GetBulbs() []*Bulb {
+	if x != nil {
+		return x.Bulbs
+	}
+	return nil
+}
+
+// Target selects controlled bulbs
+type Target struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Target:
+	//
+	//	*Target_Bulb
+	//	*Target_Group
+	Target isTarget_Target `protobuf_oneof:"target"`
+	// commands are sent to background light
+	Background    bool `protobuf:"varint,3,opt,name=background,proto3" json:"background,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Target) Reset() {
+	*x = Target{}
+	mi := &file_yeelight_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Target) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Target) ProtoMessage() {}
+
+func (x *Target) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Target.ProtoReflect.Descriptor instead.
+func (*Target) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Target) GetTarget() isTarget_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *Target) GetBulb() string {
+	if x != nil {
+		if x, ok := x.Target.(*Target_Bulb); ok {
+			return x.Bulb
+		}
+	}
+	return ""
+}
+
+func (x *Target) GetGroup() string {
+	if x != nil {
+		if x, ok := x.Target.(*Target_Group); ok {
+			return x.Group
+		}
+	}
+	return ""
+}
+
+func (x *Target) GetBackground() bool {
+	if x != nil {
+		return x.Background
+	}
+	return false
+}
+
+type isTarget_Target interface {
+	isTarget_Target()
+}
+
+type Target_Bulb struct {
+	// bulb name or device ID, command errors are returned as RPC errors
+	Bulb string `protobuf:"bytes,1,opt,name=bulb,proto3,oneof"`
+}
+
+type Target_Group struct {
+	// selector, command errors are reported per bulb by CommandResponse
+	Group string `protobuf:"bytes,2,opt,name=group,proto3,oneof"`
+}
+
+func (*Target_Bulb) isTarget_Target() {}
+
+func (*Target_Group) isTarget_Target() {}
+
+type GetStateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// bulb name or device ID
+	Bulb          string `protobuf:"bytes,1,opt,name=bulb,proto3" json:"bulb,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateRequest) Reset() {
+	*x = GetStateRequest{}
+	mi := &file_yeelight_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateRequest) ProtoMessage() {}
+
+func (x *GetStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateRequest.ProtoReflect.Descriptor instead.
+func (*GetStateRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetStateRequest) GetBulb() string {
+	if x != nil {
+		return x.Bulb
+	}
+	return ""
+}
+
+type LightState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Power         bool                   `protobuf:"varint,1,opt,name=power,proto3" json:"power,omitempty"`
+	Brightness    int32                  `protobuf:"varint,2,opt,name=brightness,proto3" json:"brightness,omitempty"`
+	ColorMode     ColorMode              `protobuf:"varint,3,opt,name=color_mode,json=colorMode,proto3,enum=yeelight.v1.ColorMode" json:"color_mode,omitempty"`
+	Rgb           int32                  `protobuf:"varint,4,opt,name=rgb,proto3" json:"rgb,omitempty"`
+	Temperature   int32                  `protobuf:"varint,5,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	Hue           int32                  `protobuf:"varint,6,opt,name=hue,proto3" json:"hue,omitempty"`
+	Saturation    int32                  `protobuf:"varint,7,opt,name=saturation,proto3" json:"saturation,omitempty"`
+	Flowing       bool                   `protobuf:"varint,8,opt,name=flowing,proto3" json:"flowing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LightState) Reset() {
+	*x = LightState{}
+	mi := &file_yeelight_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LightState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LightState) ProtoMessage() {}
+
+func (x *LightState) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LightState.ProtoReflect.Descriptor instead.
+func (*LightState) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LightState) GetPower() bool {
+	if x != nil {
+		return x.Power
+	}
+	return false
+}
+
+func (x *LightState) GetBrightness() int32 {
+	if x != nil {
+		return x.Brightness
+	}
+	return 0
+}
+
+func (x *LightState) GetColorMode() ColorMode {
+	if x != nil {
+		return x.ColorMode
+	}
+	return ColorMode_COLOR_MODE_UNSPECIFIED
+}
+
+func (x *LightState) GetRgb() int32 {
+	if x != nil {
+		return x.Rgb
+	}
+	return 0
+}
+
+func (x *LightState) GetTemperature() int32 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *LightState) GetHue() int32 {
+	if x != nil {
+		return x.Hue
+	}
+	return 0
+}
+
+func (x *LightState) GetSaturation() int32 {
+	if x != nil {
+		return x.Saturation
+	}
+	return 0
+}
+
+func (x *LightState) GetFlowing() bool {
+	if x != nil {
+		return x.Flowing
+	}
+	return false
+}
+
+type State struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Main  *LightState            `protobuf:"bytes,1,opt,name=main,proto3" json:"main,omitempty"`
+	// not set when device doesn't have background light
+	Background      *LightState `protobuf:"bytes,2,opt,name=background,proto3" json:"background,omitempty"`
+	TakenAtUnixNano int64       `protobuf:"varint,3,opt,name=taken_at_unix_nano,json=takenAtUnixNano,proto3" json:"taken_at_unix_nano,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_yeelight_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *State) GetMain() *LightState {
+	if x != nil {
+		return x.Main
+	}
+	return nil
+}
+
+func (x *State) GetBackground() *LightState {
+	if x != nil {
+		return x.Background
+	}
+	return nil
+}
+
+func (x *State) GetTakenAtUnixNano() int64 {
+	if x != nil {
+		return x.TakenAtUnixNano
+	}
+	return 0
+}
+
+// CommandResponse reports result of every controlled bulb
+type CommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BulbResult          `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResponse) Reset() {
+	*x = CommandResponse{}
+	mi := &file_yeelight_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResponse) ProtoMessage() {}
+
+func (x *CommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
+func (*CommandResponse) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CommandResponse) GetResults() []*BulbResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BulbResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// bulb name
+	Bulb string `protobuf:"bytes,1,opt,name=bulb,proto3" json:"bulb,omitempty"`
+	// empty when command succeeded
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// error code reported by device, 0 when error wasn't reported by device
+	DeviceCode    int32 `protobuf:"varint,3,opt,name=device_code,json=deviceCode,proto3" json:"device_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BulbResult) Reset() {
+	*x = BulbResult{}
+	mi := &file_yeelight_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulbResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulbResult) ProtoMessage() {}
+
+func (x *BulbResult) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulbResult.ProtoReflect.Descriptor instead.
+func (*BulbResult) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BulbResult) GetBulb() string {
+	if x != nil {
+		return x.Bulb
+	}
+	return ""
+}
+
+func (x *BulbResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BulbResult) GetDeviceCode() int32 {
+	if x != nil {
+		return x.DeviceCode
+	}
+	return 0
+}
+
+type SetPowerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        *Target                `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	On            bool                   `protobuf:"varint,2,opt,name=on,proto3" json:"on,omitempty"`
+	DurationMs    uint32                 `protobuf:"varint,3,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetPowerRequest) Reset() {
+	*x = SetPowerRequest{}
+	mi := &file_yeelight_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetPowerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetPowerRequest) ProtoMessage() {}
+
+func (x *SetPowerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetPowerRequest.ProtoReflect.Descriptor instead.
+func (*SetPowerRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SetPowerRequest) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *SetPowerRequest) GetOn() bool {
+	if x != nil {
+		return x.On
+	}
+	return false
+}
+
+func (x *SetPowerRequest) GetDurationMs() uint32 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type HSV struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hue           int32                  `protobuf:"varint,1,opt,name=hue,proto3" json:"hue,omitempty"`
+	Saturation    int32                  `protobuf:"varint,2,opt,name=saturation,proto3" json:"saturation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HSV) Reset() {
+	*x = HSV{}
+	mi := &file_yeelight_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HSV) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HSV) ProtoMessage() {}
+
+func (x *HSV) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HSV.ProtoReflect.Descriptor instead.
+func (*HSV) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *HSV) GetHue() int32 {
+	if x != nil {
+		return x.Hue
+	}
+	return 0
+}
+
+func (x *HSV) GetSaturation() int32 {
+	if x != nil {
+		return x.Saturation
+	}
+	return 0
+}
+
+type SetColorRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Target *Target                `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// color is required unless only brightness is changed
+	//
+	// Types that are valid to be assigned to Color:
+	//
+	//	*SetColorRequest_Rgb
+	//	*SetColorRequest_Hsv
+	//	*SetColorRequest_Temperature
+	//	*SetColorRequest_Name
+	Color isSetColorRequest_Color `protobuf_oneof:"color"`
+	// 1-100, 0 keeps current brightness
+	Brightness    int32  `protobuf:"varint,6,opt,name=brightness,proto3" json:"brightness,omitempty"`
+	DurationMs    uint32 `protobuf:"varint,7,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetColorRequest) Reset() {
+	*x = SetColorRequest{}
+	mi := &file_yeelight_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetColorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetColorRequest) ProtoMessage() {}
+
+func (x *SetColorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetColorRequest.ProtoReflect.Descriptor instead.
+func (*SetColorRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SetColorRequest) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *SetColorRequest) GetColor() isSetColorRequest_Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+func (x *SetColorRequest) GetRgb() int32 {
+	if x != nil {
+		if x, ok := x.Color.(*SetColorRequest_Rgb); ok {
+			return x.Rgb
+		}
+	}
+	return 0
+}
+
+func (x *SetColorRequest) GetHsv() *HSV {
+	if x != nil {
+		if x, ok := x.Color.(*SetColorRequest_Hsv); ok {
+			return x.Hsv
+		}
+	}
+	return nil
+}
+
+func (x *SetColorRequest) GetTemperature() int32 {
+	if x != nil {
+		if x, ok := x.Color.(*SetColorRequest_Temperature); ok {
+			return x.Temperature
+		}
+	}
+	return 0
+}
+
+func (x *SetColorRequest) GetName() string {
+	if x != nil {
+		if x, ok := x.Color.(*SetColorRequest_Name); ok {
+			return x.Name
+		}
+	}
+	return ""
+}
+
+func (x *SetColorRequest) GetBrightness() int32 {
+	if x != nil {
+		return x.Brightness
+	}
+	return 0
+}
+
+func (x *SetColorRequest) GetDurationMs() uint32 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type isSetColorRequest_Color interface {
+	isSetColorRequest_Color()
+}
+
+type SetColorRequest_Rgb struct {
+	Rgb int32 `protobuf:"varint,2,opt,name=rgb,proto3,oneof"`
+}
+
+type SetColorRequest_Hsv struct {
+	Hsv *HSV `protobuf:"bytes,3,opt,name=hsv,proto3,oneof"`
+}
+
+type SetColorRequest_Temperature struct {
+	// color temperature in kelvins
+	Temperature int32 `protobuf:"varint,4,opt,name=temperature,proto3,oneof"`
+}
+
+type SetColorRequest_Name struct {
+	// "#ff8800", "orange", "3000K", see yeelight.ParseColor
+	Name string `protobuf:"bytes,5,opt,name=name,proto3,oneof"`
+}
+
+func (*SetColorRequest_Rgb) isSetColorRequest_Color() {}
+
+func (*SetColorRequest_Hsv) isSetColorRequest_Color() {}
+
+func (*SetColorRequest_Temperature) isSetColorRequest_Color() {}
+
+func (*SetColorRequest_Name) isSetColorRequest_Color() {}
+
+type Flow struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Flow:
+	//
+	//	*Flow_Preset
+	//	*Flow_Text
+	//	*Flow_Expression
+	Flow isFlow_Flow `protobuf_oneof:"flow"`
+	// used with expression, 0 for infinite flow
+	Count int32 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	// used with expression
+	Action        FlowAction `protobuf:"varint,5,opt,name=action,proto3,enum=yeelight.v1.FlowAction" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Flow) Reset() {
+	*x = Flow{}
+	mi := &file_yeelight_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Flow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Flow) ProtoMessage() {}
+
+func (x *Flow) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Flow.ProtoReflect.Descriptor instead.
+func (*Flow) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Flow) GetFlow() isFlow_Flow {
+	if x != nil {
+		return x.Flow
+	}
+	return nil
+}
+
+func (x *Flow) GetPreset() string {
+	if x != nil {
+		if x, ok := x.Flow.(*Flow_Preset); ok {
+			return x.Preset
+		}
+	}
+	return ""
+}
+
+func (x *Flow) GetText() string {
+	if x != nil {
+		if x, ok := x.Flow.(*Flow_Text); ok {
+			return x.Text
+		}
+	}
+	return ""
+}
+
+func (x *Flow) GetExpression() string {
+	if x != nil {
+		if x, ok := x.Flow.(*Flow_Expression); ok {
+			return x.Expression
+		}
+	}
+	return ""
+}
+
+func (x *Flow) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Flow) GetAction() FlowAction {
+	if x != nil {
+		return x.Action
+	}
+	return FlowAction_FLOW_ACTION_RECOVER
+}
+
+type isFlow_Flow interface {
+	isFlow_Flow()
+}
+
+type Flow_Preset struct {
+	// preset name, see flows.Names
+	Preset string `protobuf:"bytes,1,opt,name=preset,proto3,oneof"`
+}
+
+type Flow_Text struct {
+	// flow file format, see flows.ParseText
+	Text string `protobuf:"bytes,2,opt,name=text,proto3,oneof"`
+}
+
+type Flow_Expression struct {
+	// comma separated flow tuples
+	Expression string `protobuf:"bytes,3,opt,name=expression,proto3,oneof"`
+}
+
+func (*Flow_Preset) isFlow_Flow() {}
+
+func (*Flow_Text) isFlow_Flow() {}
+
+func (*Flow_Expression) isFlow_Flow() {}
+
+type StartFlowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        *Target                `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Flow          *Flow                  `protobuf:"bytes,2,opt,name=flow,proto3" json:"flow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartFlowRequest) Reset() {
+	*x = StartFlowRequest{}
+	mi := &file_yeelight_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartFlowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartFlowRequest) ProtoMessage() {}
+
+func (x *StartFlowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartFlowRequest.ProtoReflect.Descriptor instead.
+func (*StartFlowRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StartFlowRequest) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *StartFlowRequest) GetFlow() *Flow {
+	if x != nil {
+		return x.Flow
+	}
+	return nil
+}
+
+type StopFlowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        *Target                `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopFlowRequest) Reset() {
+	*x = StopFlowRequest{}
+	mi := &file_yeelight_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopFlowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopFlowRequest) ProtoMessage() {}
+
+func (x *StopFlowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopFlowRequest.ProtoReflect.Descriptor instead.
+func (*StopFlowRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StopFlowRequest) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+type SetSceneRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Target *Target                `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// Types that are valid to be assigned to Scene:
+	//
+	//	*SetSceneRequest_Rgb
+	//	*SetSceneRequest_Hsv
+	//	*SetSceneRequest_Temperature
+	//	*SetSceneRequest_Flow
+	//	*SetSceneRequest_DelayOffMinutes
+	Scene isSetSceneRequest_Scene `protobuf_oneof:"scene"`
+	// required by all scenes except flow
+	Brightness    int32 `protobuf:"varint,7,opt,name=brightness,proto3" json:"brightness,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetSceneRequest) Reset() {
+	*x = SetSceneRequest{}
+	mi := &file_yeelight_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSceneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSceneRequest) ProtoMessage() {}
+
+func (x *SetSceneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSceneRequest.ProtoReflect.Descriptor instead.
+func (*SetSceneRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SetSceneRequest) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *SetSceneRequest) GetScene() isSetSceneRequest_Scene {
+	if x != nil {
+		return x.Scene
+	}
+	return nil
+}
+
+func (x *SetSceneRequest) GetRgb() int32 {
+	if x != nil {
+		if x, ok := x.Scene.(*SetSceneRequest_Rgb); ok {
+			return x.Rgb
+		}
+	}
+	return 0
+}
+
+func (x *SetSceneRequest) GetHsv() *HSV {
+	if x != nil {
+		if x, ok := x.Scene.(*SetSceneRequest_Hsv); ok {
+			return x.Hsv
+		}
+	}
+	return nil
+}
+
+func (x *SetSceneRequest) GetTemperature() int32 {
+	if x != nil {
+		if x, ok := x.Scene.(*SetSceneRequest_Temperature); ok {
+			return x.Temperature
+		}
+	}
+	return 0
+}
+
+func (x *SetSceneRequest) GetFlow() *Flow {
+	if x != nil {
+		if x, ok := x.Scene.(*SetSceneRequest_Flow); ok {
+			return x.Flow
+		}
+	}
+	return nil
+}
+
+func (x *SetSceneRequest) GetDelayOffMinutes() int32 {
+	if x != nil {
+		if x, ok := x.Scene.(*SetSceneRequest_DelayOffMinutes); ok {
+			return x.DelayOffMinutes
+		}
+	}
+	return 0
+}
+
+func (x *SetSceneRequest) GetBrightness() int32 {
+	if x != nil {
+		return x.Brightness
+	}
+	return 0
+}
+
+type isSetSceneRequest_Scene interface {
+	isSetSceneRequest_Scene()
+}
+
+type SetSceneRequest_Rgb struct {
+	Rgb int32 `protobuf:"varint,2,opt,name=rgb,proto3,oneof"`
+}
+
+type SetSceneRequest_Hsv struct {
+	Hsv *HSV `protobuf:"bytes,3,opt,name=hsv,proto3,oneof"`
+}
+
+type SetSceneRequest_Temperature struct {
+	Temperature int32 `protobuf:"varint,4,opt,name=temperature,proto3,oneof"`
+}
+
+type SetSceneRequest_Flow struct {
+	Flow *Flow `protobuf:"bytes,5,opt,name=flow,proto3,oneof"`
+}
+
+type SetSceneRequest_DelayOffMinutes struct {
+	// power off timer in minutes
+	DelayOffMinutes int32 `protobuf:"varint,6,opt,name=delay_off_minutes,json=delayOffMinutes,proto3,oneof"`
+}
+
+func (*SetSceneRequest_Rgb) isSetSceneRequest_Scene() {}
+
+func (*SetSceneRequest_Hsv) isSetSceneRequest_Scene() {}
+
+func (*SetSceneRequest_Temperature) isSetSceneRequest_Scene() {}
+
+func (*SetSceneRequest_Flow) isSetSceneRequest_Scene() {}
+
+func (*SetSceneRequest_DelayOffMinutes) isSetSceneRequest_Scene() {}
+
+type StreamEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// bulb names, device IDs or name patterns ("office/*"), empty matches all bulbs
+	Bulbs []string `protobuf:"bytes,1,rep,name=bulbs,proto3" json:"bulbs,omitempty"`
+	// properties included in props events, empty includes all properties
+	Props []string `protobuf:"bytes,2,rep,name=props,proto3" json:"props,omitempty"`
+	// event types, empty matches all types
+	Types         []EventType `protobuf:"varint,3,rep,packed,name=types,proto3,enum=yeelight.v1.EventType" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_yeelight_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StreamEventsRequest) GetBulbs() []string {
+	if x != nil {
+		return x.Bulbs
+	}
+	return nil
+}
+
+func (x *StreamEventsRequest) GetProps() []string {
+	if x != nil {
+		return x.Props
+	}
+	return nil
+}
+
+func (x *StreamEventsRequest) GetTypes() []EventType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=yeelight.v1.EventType" json:"type,omitempty"`
+	// bulb name
+	Bulb string `protobuf:"bytes,2,opt,name=bulb,proto3" json:"bulb,omitempty"`
+	// device ID
+	Id string `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Ip string `protobuf:"bytes,4,opt,name=ip,proto3" json:"ip,omitempty"`
+	// changed properties, props events only
+	Props         map[string]string `protobuf:"bytes,5,rep,name=props,proto3" json:"props,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Error         string            `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	TimeUnixNano  int64             `protobuf:"varint,7,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_yeelight_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Event) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *Event) GetBulb() string {
+	if x != nil {
+		return x.Bulb
+	}
+	return ""
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Event) GetProps() map[string]string {
+	if x != nil {
+		return x.Props
+	}
+	return nil
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Event) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+type MusicFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// required in first frame, following frames without target are sent to the same bulbs
+	Target *Target `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// Types that are valid to be assigned to Color:
+	//
+	//	*MusicFrame_Rgb
+	//	*MusicFrame_Hsv
+	//	*MusicFrame_Temperature
+	Color isMusicFrame_Color `protobuf_oneof:"color"`
+	// 1-100, 0 keeps current brightness
+	Brightness    int32  `protobuf:"varint,5,opt,name=brightness,proto3" json:"brightness,omitempty"`
+	DurationMs    uint32 `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MusicFrame) Reset() {
+	*x = MusicFrame{}
+	mi := &file_yeelight_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MusicFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MusicFrame) ProtoMessage() {}
+
+func (x *MusicFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MusicFrame.ProtoReflect.Descriptor instead.
+func (*MusicFrame) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *MusicFrame) GetTarget() *Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *MusicFrame) GetColor() isMusicFrame_Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+func (x *MusicFrame) GetRgb() int32 {
+	if x != nil {
+		if x, ok := x.Color.(*MusicFrame_Rgb); ok {
+			return x.Rgb
+		}
+	}
+	return 0
+}
+
+func (x *MusicFrame) GetHsv() *HSV {
+	if x != nil {
+		if x, ok := x.Color.(*MusicFrame_Hsv); ok {
+			return x.Hsv
+		}
+	}
+	return nil
+}
+
+func (x *MusicFrame) GetTemperature() int32 {
+	if x != nil {
+		if x, ok := x.Color.(*MusicFrame_Temperature); ok {
+			return x.Temperature
+		}
+	}
+	return 0
+}
+
+func (x *MusicFrame) GetBrightness() int32 {
+	if x != nil {
+		return x.Brightness
+	}
+	return 0
+}
+
+func (x *MusicFrame) GetDurationMs() uint32 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type isMusicFrame_Color interface {
+	isMusicFrame_Color()
+}
+
+type MusicFrame_Rgb struct {
+	Rgb int32 `protobuf:"varint,2,opt,name=rgb,proto3,oneof"`
+}
+
+type MusicFrame_Hsv struct {
+	Hsv *HSV `protobuf:"bytes,3,opt,name=hsv,proto3,oneof"`
+}
+
+type MusicFrame_Temperature struct {
+	Temperature int32 `protobuf:"varint,4,opt,name=temperature,proto3,oneof"`
+}
+
+func (*MusicFrame_Rgb) isMusicFrame_Color() {}
+
+func (*MusicFrame_Hsv) isMusicFrame_Color() {}
+
+func (*MusicFrame_Temperature) isMusicFrame_Color() {}
+
+type StreamMusicFramesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// number of frames sent to bulbs
+	Frames        uint32 `protobuf:"varint,1,opt,name=frames,proto3" json:"frames,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamMusicFramesResponse) Reset() {
+	*x = StreamMusicFramesResponse{}
+	mi := &file_yeelight_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamMusicFramesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamMusicFramesResponse) ProtoMessage() {}
+
+func (x *StreamMusicFramesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_yeelight_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamMusicFramesResponse.ProtoReflect.Descriptor instead.
+func (*StreamMusicFramesResponse) Descriptor() ([]byte, []int) {
+	return file_yeelight_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StreamMusicFramesResponse) GetFrames() uint32 {
+	if x != nil {
+		return x.Frames
+	}
+	return 0
+}
+
+var File_yeelight_proto protoreflect.FileDescriptor
+
+const file_yeelight_proto_rawDesc = "" +
+	"\n" +
+	"\x0eyeelight.proto\x12\vyeelight.v1\"v\n" +
+	"\x04Bulb\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04room\x18\x03 \x01(\tR\x04room\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12\x0e\n" +
+	"\x02ip\x18\x05 \x01(\tR\x02ip\x12\x12\n" +
+	"\x04port\x18\x06 \x01(\x05R\x04port\".\n" +
+	"\x10ListBulbsRequest\x12\x1a\n" +
+	"\bselector\x18\x01 \x01(\tR\bselector\"<\n" +
+	"\x11ListBulbsResponse\x12'\n" +
+	"\x05bulbs\x18\x01 \x03(\v2\x11.yeelight.v1.BulbR\x05bulbs\"`\n" +
+	"\x06Target\x12\x14\n" +
+	"\x04bulb\x18\x01 \x01(\tH\x00R\x04bulb\x12\x16\n" +
+	"\x05group\x18\x02 \x01(\tH\x00R\x05group\x12\x1e\n" +
+	"\n" +
+	"background\x18\x03 \x01(\bR\n" +
+	"backgroundB\b\n" +
+	"\x06target\"%\n" +
+	"\x0fGetStateRequest\x12\x12\n" +
+	"\x04bulb\x18\x01 \x01(\tR\x04bulb\"\xf9\x01\n" +
+	"\n" +
+	"LightState\x12\x14\n" +
+	"\x05power\x18\x01 \x01(\bR\x05power\x12\x1e\n" +
+	"\n" +
+	"brightness\x18\x02 \x01(\x05R\n" +
+	"brightness\x125\n" +
+	"\n" +
+	"color_mode\x18\x03 \x01(\x0e2\x16.yeelight.v1.ColorModeR\tcolorMode\x12\x10\n" +
+	"\x03rgb\x18\x04 \x01(\x05R\x03rgb\x12 \n" +
+	"\vtemperature\x18\x05 \x01(\x05R\vtemperature\x12\x10\n" +
+	"\x03hue\x18\x06 \x01(\x05R\x03hue\x12\x1e\n" +
+	"\n" +
+	"saturation\x18\a \x01(\x05R\n" +
+	"saturation\x12\x18\n" +
+	"\aflowing\x18\b \x01(\bR\aflowing\"\x9a\x01\n" +
+	"\x05State\x12+\n" +
+	"\x04main\x18\x01 \x01(\v2\x17.yeelight.v1.LightStateR\x04main\x127\n" +
+	"\n" +
+	"background\x18\x02 \x01(\v2\x17.yeelight.v1.LightStateR\n" +
+	"background\x12+\n" +
+	"\x12taken_at_unix_nano\x18\x03 \x01(\x03R\x0ftakenAtUnixNano\"D\n" +
+	"\x0fCommandResponse\x121\n" +
+	"\aresults\x18\x01 \x03(\v2\x17.yeelight.v1.BulbResultR\aresults\"W\n" +
+	"\n" +
+	"BulbResult\x12\x12\n" +
+	"\x04bulb\x18\x01 \x01(\tR\x04bulb\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1f\n" +
+	"\vdevice_code\x18\x03 \x01(\x05R\n" +
+	"deviceCode\"o\n" +
+	"\x0fSetPowerRequest\x12+\n" +
+	"\x06target\x18\x01 \x01(\v2\x13.yeelight.v1.TargetR\x06target\x12\x0e\n" +
+	"\x02on\x18\x02 \x01(\bR\x02on\x12\x1f\n" +
+	"\vduration_ms\x18\x03 \x01(\rR\n" +
+	"durationMs\"7\n" +
+	"\x03HSV\x12\x10\n" +
+	"\x03hue\x18\x01 \x01(\x05R\x03hue\x12\x1e\n" +
+	"\n" +
+	"saturation\x18\x02 \x01(\x05R\n" +
+	"saturation\"\xfc\x01\n" +
+	"\x0fSetColorRequest\x12+\n" +
+	"\x06target\x18\x01 \x01(\v2\x13.yeelight.v1.TargetR\x06target\x12\x12\n" +
+	"\x03rgb\x18\x02 \x01(\x05H\x00R\x03rgb\x12$\n" +
+	"\x03hsv\x18\x03 \x01(\v2\x10.yeelight.v1.HSVH\x00R\x03hsv\x12\"\n" +
+	"\vtemperature\x18\x04 \x01(\x05H\x00R\vtemperature\x12\x14\n" +
+	"\x04name\x18\x05 \x01(\tH\x00R\x04name\x12\x1e\n" +
+	"\n" +
+	"brightness\x18\x06 \x01(\x05R\n" +
+	"brightness\x12\x1f\n" +
+	"\vduration_ms\x18\a \x01(\rR\n" +
+	"durationMsB\a\n" +
+	"\x05color\"\xa7\x01\n" +
+	"\x04Flow\x12\x18\n" +
+	"\x06preset\x18\x01 \x01(\tH\x00R\x06preset\x12\x14\n" +
+	"\x04text\x18\x02 \x01(\tH\x00R\x04text\x12 \n" +
+	"\n" +
+	"expression\x18\x03 \x01(\tH\x00R\n" +
+	"expression\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x05R\x05count\x12/\n" +
+	"\x06action\x18\x05 \x01(\x0e2\x17.yeelight.v1.FlowActionR\x06actionB\x06\n" +
+	"\x04flow\"f\n" +
+	"\x10StartFlowRequest\x12+\n" +
+	"\x06target\x18\x01 \x01(\v2\x13.yeelight.v1.TargetR\x06target\x12%\n" +
+	"\x04flow\x18\x02 \x01(\v2\x11.yeelight.v1.FlowR\x04flow\">\n" +
+	"\x0fStopFlowRequest\x12+\n" +
+	"\x06target\x18\x01 \x01(\v2\x13.yeelight.v1.TargetR\x06target\"\x9c\x02\n" +
+	"\x0fSetSceneRequest\x12+\n" +
+	"\x06target\x18\x01 \x01(\v2\x13.yeelight.v1.TargetR\x06target\x12\x12\n" +
+	"\x03rgb\x18\x02 \x01(\x05H\x00R\x03rgb\x12$\n" +
+	"\x03hsv\x18\x03 \x01(\v2\x10.yeelight.v1.HSVH\x00R\x03hsv\x12\"\n" +
+	"\vtemperature\x18\x04 \x01(\x05H\x00R\vtemperature\x12'\n" +
+	"\x04flow\x18\x05 \x01(\v2\x11.yeelight.v1.FlowH\x00R\x04flow\x12,\n" +
+	"\x11delay_off_minutes\x18\x06 \x01(\x05H\x00R\x0fdelayOffMinutes\x12\x1e\n" +
+	"\n" +
+	"brightness\x18\a \x01(\x05R\n" +
+	"brightnessB\a\n" +
+	"\x05scene\"o\n" +
+	"\x13StreamEventsRequest\x12\x14\n" +
+	"\x05bulbs\x18\x01 \x03(\tR\x05bulbs\x12\x14\n" +
+	"\x05props\x18\x02 \x03(\tR\x05props\x12,\n" +
+	"\x05types\x18\x03 \x03(\x0e2\x16.yeelight.v1.EventTypeR\x05types\"\x92\x02\n" +
+	"\x05Event\x12*\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x16.yeelight.v1.EventTypeR\x04type\x12\x12\n" +
+	"\x04bulb\x18\x02 \x01(\tR\x04bulb\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\x0e\n" +
+	"\x02ip\x18\x04 \x01(\tR\x02ip\x123\n" +
+	"\x05props\x18\x05 \x03(\v2\x1d.yeelight.v1.Event.PropsEntryR\x05props\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12$\n" +
+	"\x0etime_unix_nano\x18\a \x01(\x03R\ftimeUnixNano\x1a8\n" +
+	"\n" +
+	"PropsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe1\x01\n" +
+	"\n" +
+	"MusicFrame\x12+\n" +
+	"\x06target\x18\x01 \x01(\v2\x13.yeelight.v1.TargetR\x06target\x12\x12\n" +
+	"\x03rgb\x18\x02 \x01(\x05H\x00R\x03rgb\x12$\n" +
+	"\x03hsv\x18\x03 \x01(\v2\x10.yeelight.v1.HSVH\x00R\x03hsv\x12\"\n" +
+	"\vtemperature\x18\x04 \x01(\x05H\x00R\vtemperature\x12\x1e\n" +
+	"\n" +
+	"brightness\x18\x05 \x01(\x05R\n" +
+	"brightness\x12\x1f\n" +
+	"\vduration_ms\x18\x06 \x01(\rR\n" +
+	"durationMsB\a\n" +
+	"\x05color\"3\n" +
+	"\x19StreamMusicFramesResponse\x12\x16\n" +
+	"\x06frames\x18\x01 \x01(\rR\x06frames*k\n" +
+	"\tColorMode\x12\x1a\n" +
+	"\x16COLOR_MODE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eCOLOR_MODE_RGB\x10\x01\x12\x1a\n" +
+	"\x16COLOR_MODE_TEMPERATURE\x10\x02\x12\x12\n" +
+	"\x0eCOLOR_MODE_HSV\x10\x03*P\n" +
+	"\n" +
+	"FlowAction\x12\x17\n" +
+	"\x13FLOW_ACTION_RECOVER\x10\x00\x12\x14\n" +
+	"\x10FLOW_ACTION_STAY\x10\x01\x12\x13\n" +
+	"\x0fFLOW_ACTION_OFF\x10\x02*\xc4\x01\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10EVENT_TYPE_PROPS\x10\x01\x12\x18\n" +
+	"\x14EVENT_TYPE_CONNECTED\x10\x02\x12\x1b\n" +
+	"\x17EVENT_TYPE_DISCONNECTED\x10\x03\x12\x19\n" +
+	"\x15EVENT_TYPE_DISCOVERED\x10\x04\x12\x1e\n" +
+	"\x1aEVENT_TYPE_ADDRESS_CHANGED\x10\x05\x12\x13\n" +
+	"\x0fEVENT_TYPE_LOST\x10\x062\x9e\x05\n" +
+	"\bYeelight\x12J\n" +
+	"\tListBulbs\x12\x1d.yeelight.v1.ListBulbsRequest\x1a\x1e.yeelight.v1.ListBulbsResponse\x12<\n" +
+	"\bGetState\x12\x1c.yeelight.v1.GetStateRequest\x1a\x12.yeelight.v1.State\x12F\n" +
+	"\bSetPower\x12\x1c.yeelight.v1.SetPowerRequest\x1a\x1c.yeelight.v1.CommandResponse\x12F\n" +
+	"\bSetColor\x12\x1c.yeelight.v1.SetColorRequest\x1a\x1c.yeelight.v1.CommandResponse\x12H\n" +
+	"\tStartFlow\x12\x1d.yeelight.v1.StartFlowRequest\x1a\x1c.yeelight.v1.CommandResponse\x12F\n" +
+	"\bStopFlow\x12\x1c.yeelight.v1.StopFlowRequest\x1a\x1c.yeelight.v1.CommandResponse\x12F\n" +
+	"\bSetScene\x12\x1c.yeelight.v1.SetSceneRequest\x1a\x1c.yeelight.v1.CommandResponse\x12F\n" +
+	"\fStreamEvents\x12 .yeelight.v1.StreamEventsRequest\x1a\x12.yeelight.v1.Event0\x01\x12V\n" +
+	"\x11StreamMusicFrames\x12\x17.yeelight.v1.MusicFrame\x1a&.yeelight.v1.StreamMusicFramesResponse(\x01B/Z-github.com/gethiox/yeelight-go/rpc/yeelightpbb\x06proto3"
+
+var (
+	file_yeelight_proto_rawDescOnce sync.Once
+	file_yeelight_proto_rawDescData []byte
+)
+
+func file_yeelight_proto_rawDescGZIP() []byte {
+	file_yeelight_proto_rawDescOnce.Do(func() {
+		file_yeelight_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_yeelight_proto_rawDesc), len(file_yeelight_proto_rawDesc)))
+	})
+	return file_yeelight_proto_rawDescData
+}
+
+var file_yeelight_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_yeelight_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_yeelight_proto_goTypes = []any{
+	(ColorMode)(0),                    // 0: yeelight.v1.ColorMode
+	(FlowAction)(0),                   // 1: yeelight.v1.FlowAction
+	(EventType)(0),                    // 2: yeelight.v1.EventType
+	(*Bulb)(nil),                      // 3: yeelight.v1.Bulb
+	(*ListBulbsRequest)(nil),          // 4: yeelight.v1.ListBulbsRequest
+	(*ListBulbsResponse)(nil),         // 5: yeelight.v1.ListBulbsResponse
+	(*Target)(nil),                    // 6: yeelight.v1.Target
+	(*GetStateRequest)(nil),           // 7: yeelight.v1.GetStateRequest
+	(*LightState)(nil),                // 8: yeelight.v1.LightState
+	(*State)(nil),                     // 9: yeelight.v1.State
+	(*CommandResponse)(nil),           // 10: yeelight.v1.CommandResponse
+	(*BulbResult)(nil),                // 11: yeelight.v1.BulbResult
+	(*SetPowerRequest)(nil),           // 12: yeelight.v1.SetPowerRequest
+	(*HSV)(nil),                       // 13: yeelight.v1.HSV
+	(*SetColorRequest)(nil),           // 14: yeelight.v1.SetColorRequest
+	(*Flow)(nil),                      // 15: yeelight.v1.Flow
+	(*StartFlowRequest)(nil),          // 16: yeelight.v1.StartFlowRequest
+	(*StopFlowRequest)(nil),           // 17: yeelight.v1.StopFlowRequest
+	(*SetSceneRequest)(nil),           // 18: yeelight.v1.SetSceneRequest
+	(*StreamEventsRequest)(nil),       // 19: yeelight.v1.StreamEventsRequest
+	(*Event)(nil),                     // 20: yeelight.v1.Event
+	(*MusicFrame)(nil),                // 21: yeelight.v1.MusicFrame
+	(*StreamMusicFramesResponse)(nil), // 22: yeelight.v1.StreamMusicFramesResponse
+	nil,                               // 23: yeelight.v1.Event.PropsEntry
+}
+var file_yeelight_proto_depIdxs = []int32{
+	3,  // 0: yeelight.v1.ListBulbsResponse.bulbs:type_name -> yeelight.v1.Bulb
+	0,  // 1: yeelight.v1.LightState.color_mode:type_name -> yeelight.v1.ColorMode
+	8,  // 2: yeelight.v1.State.main:type_name -> yeelight.v1.LightState
+	8,  // 3: yeelight.v1.State.background:type_name -> yeelight.v1.LightState
+	11, // 4: yeelight.v1.CommandResponse.results:type_name -> yeelight.v1.BulbResult
+	6,  // 5: yeelight.v1.SetPowerRequest.target:type_name -> yeelight.v1.Target
+	6,  // 6: yeelight.v1.SetColorRequest.target:type_name -> yeelight.v1.Target
+	13, // 7: yeelight.v1.SetColorRequest.hsv:type_name -> yeelight.v1.HSV
+	1,  // 8: yeelight.v1.Flow.action:type_name -> yeelight.v1.FlowAction
+	6,  // 9: yeelight.v1.StartFlowRequest.target:type_name -> yeelight.v1.Target
+	15, // 10: yeelight.v1.StartFlowRequest.flow:type_name -> yeelight.v1.Flow
+	6,  // 11: yeelight.v1.StopFlowRequest.target:type_name -> yeelight.v1.Target
+	6,  // 12: yeelight.v1.SetSceneRequest.target:type_name -> yeelight.v1.Target
+	13, // 13: yeelight.v1.SetSceneRequest.hsv:type_name -> yeelight.v1.HSV
+	15, // 14: yeelight.v1.SetSceneRequest.flow:type_name -> yeelight.v1.Flow
+	2,  // 15: yeelight.v1.StreamEventsRequest.types:type_name -> yeelight.v1.EventType
+	2,  // 16: yeelight.v1.Event.type:type_name -> yeelight.v1.EventType
+	23, // 17: yeelight.v1.Event.props:type_name -> yeelight.v1.Event.PropsEntry
+	6,  // 18: yeelight.v1.MusicFrame.target:type_name -> yeelight.v1.Target
+	13, // 19: yeelight.v1.MusicFrame.hsv:type_name -> yeelight.v1.HSV
+	4,  // 20: yeelight.v1.Yeelight.ListBulbs:input_type -> yeelight.v1.ListBulbsRequest
+	7,  // 21: yeelight.v1.Yeelight.GetState:input_type -> yeelight.v1.GetStateRequest
+	12, // 22: yeelight.v1.Yeelight.SetPower:input_type -> yeelight.v1.SetPowerRequest
+	14, // 23: yeelight.v1.Yeelight.SetColor:input_type -> yeelight.v1.SetColorRequest
+	16, // 24: yeelight.v1.Yeelight.StartFlow:input_type -> yeelight.v1.StartFlowRequest
+	17, // 25: yeelight.v1.Yeelight.StopFlow:input_type -> yeelight.v1.StopFlowRequest
+	18, // 26: yeelight.v1.Yeelight.SetScene:input_type -> yeelight.v1.SetSceneRequest
+	19, // 27: yeelight.v1.Yeelight.StreamEvents:input_type -> yeelight.v1.StreamEventsRequest
+	21, // 28: yeelight.v1.Yeelight.StreamMusicFrames:input_type -> yeelight.v1.MusicFrame
+	5,  // 29: yeelight.v1.Yeelight.ListBulbs:output_type -> yeelight.v1.ListBulbsResponse
+	9,  // 30: yeelight.v1.Yeelight.GetState:output_type -> yeelight.v1.State
+	10, // 31: yeelight.v1.Yeelight.SetPower:output_type -> yeelight.v1.CommandResponse
+	10, // 32: yeelight.v1.Yeelight.SetColor:output_type -> yeelight.v1.CommandResponse
+	10, // 33: yeelight.v1.Yeelight.StartFlow:output_type -> yeelight.v1.CommandResponse
+	10, // 34: yeelight.v1.Yeelight.StopFlow:output_type -> yeelight.v1.CommandResponse
+	10, // 35: yeelight.v1.Yeelight.SetScene:output_type -> yeelight.v1.CommandResponse
+	20, // 36: yeelight.v1.Yeelight.StreamEvents:output_type -> yeelight.v1.Event
+	22, // 37: yeelight.v1.Yeelight.StreamMusicFrames:output_type -> yeelight.v1.StreamMusicFramesResponse
+	29, // [29:38] is the sub-list for method output_type
+	20, // [20:29] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
+}
+
+func init() { file_yeelight_proto_init() }
+func file_yeelight_proto_init() {
+	if File_yeelight_proto != nil {
+		return
+	}
+	file_yeelight_proto_msgTypes[3].OneofWrappers = []any{
+		(*Target_Bulb)(nil),
+		(*Target_Group)(nil),
+	}
+	file_yeelight_proto_msgTypes[11].OneofWrappers = []any{
+		(*SetColorRequest_Rgb)(nil),
+		(*SetColorRequest_Hsv)(nil),
+		(*SetColorRequest_Temperature)(nil),
+		(*SetColorRequest_Name)(nil),
+	}
+	file_yeelight_proto_msgTypes[12].OneofWrappers = []any{
+		(*Flow_Preset)(nil),
+		(*Flow_Text)(nil),
+		(*Flow_Expression)(nil),
+	}
+	file_yeelight_proto_msgTypes[15].OneofWrappers = []any{
+		(*SetSceneRequest_Rgb)(nil),
+		(*SetSceneRequest_Hsv)(nil),
+		(*SetSceneRequest_Temperature)(nil),
+		(*SetSceneRequest_Flow)(nil),
+		(*SetSceneRequest_DelayOffMinutes)(nil),
+	}
+	file_yeelight_proto_msgTypes[18].OneofWrappers = []any{
+		(*MusicFrame_Rgb)(nil),
+		(*MusicFrame_Hsv)(nil),
+		(*MusicFrame_Temperature)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_yeelight_proto_rawDesc), len(file_yeelight_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   21,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_yeelight_proto_goTypes,
+		DependencyIndexes: file_yeelight_proto_depIdxs,
+		EnumInfos:         file_yeelight_proto_enumTypes,
+		MessageInfos:      file_yeelight_proto_msgTypes,
+	}.Build()
+	File_yeelight_proto = out.File
+	file_yeelight_proto_goTypes = nil
+	file_yeelight_proto_depIdxs = nil
+}
@@ -0,0 +1,436 @@
+// Remote control of bulbs registered in registry, implemented by rpc.Server.
+// Go code in yeelightpb is generated with:
+//   buf generate
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: yeelight.proto
+
+package yeelightpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Yeelight_ListBulbs_FullMethodName         = "/yeelight.v1.Yeelight/ListBulbs"
+	Yeelight_GetState_FullMethodName          = "/yeelight.v1.Yeelight/GetState"
+	Yeelight_SetPower_FullMethodName          = "/yeelight.v1.Yeelight/SetPower"
+	Yeelight_SetColor_FullMethodName          = "/yeelight.v1.Yeelight/SetColor"
+	Yeelight_StartFlow_FullMethodName         = "/yeelight.v1.Yeelight/StartFlow"
+	Yeelight_StopFlow_FullMethodName          = "/yeelight.v1.Yeelight/StopFlow"
+	Yeelight_SetScene_FullMethodName          = "/yeelight.v1.Yeelight/SetScene"
+	Yeelight_StreamEvents_FullMethodName      = "/yeelight.v1.Yeelight/StreamEvents"
+	Yeelight_StreamMusicFrames_FullMethodName = "/yeelight.v1.Yeelight/StreamMusicFrames"
+)
+
+// YeelightClient is the client API for Yeelight service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type YeelightClient interface {
+	// ListBulbs returns registered bulbs matching selector, all bulbs when selector is empty
+	ListBulbs(ctx context.Context, in *ListBulbsRequest, opts ...grpc.CallOption) (*ListBulbsResponse, error)
+	// GetState reads current state of a single bulb
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error)
+	SetPower(ctx context.Context, in *SetPowerRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	SetColor(ctx context.Context, in *SetColorRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	StartFlow(ctx context.Context, in *StartFlowRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	StopFlow(ctx context.Context, in *StopFlowRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	SetScene(ctx context.Context, in *SetSceneRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	// StreamEvents streams bulb notifications, connection state changes and discovery results
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// StreamMusicFrames sends frames to bulbs over music mode connections, frames are not limited by quota.
+	// Music mode is started on first frame addressed to a bulb and stopped when stream ends
+	StreamMusicFrames(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[MusicFrame, StreamMusicFramesResponse], error)
+}
+
+type yeelightClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewYeelightClient(cc grpc.ClientConnInterface) YeelightClient {
+	return &yeelightClient{cc}
+}
+
+func (c *yeelightClient) ListBulbs(ctx context.Context, in *ListBulbsRequest, opts ...grpc.CallOption) (*ListBulbsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBulbsResponse)
+	err := c.cc.Invoke(ctx, Yeelight_ListBulbs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yeelightClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(State)
+	err := c.cc.Invoke(ctx, Yeelight_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yeelightClient) SetPower(ctx context.Context, in *SetPowerRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Yeelight_SetPower_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yeelightClient) SetColor(ctx context.Context, in *SetColorRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Yeelight_SetColor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yeelightClient) StartFlow(ctx context.Context, in *StartFlowRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Yeelight_StartFlow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yeelightClient) StopFlow(ctx context.Context, in *StopFlowRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Yeelight_StopFlow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yeelightClient) SetScene(ctx context.Context, in *SetSceneRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Yeelight_SetScene_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yeelightClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Yeelight_ServiceDesc.Streams[0], Yeelight_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Yeelight_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *yeelightClient) StreamMusicFrames(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[MusicFrame, StreamMusicFramesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Yeelight_ServiceDesc.Streams[1], Yeelight_StreamMusicFrames_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MusicFrame, StreamMusicFramesResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Yeelight_StreamMusicFramesClient = grpc.ClientStreamingClient[MusicFrame, StreamMusicFramesResponse]
+
+// YeelightServer is the server API for Yeelight service.
+// All implementations must embed UnimplementedYeelightServer
+// for forward compatibility.
+type YeelightServer interface {
+	// ListBulbs returns registered bulbs matching selector, all bulbs when selector is empty
+	ListBulbs(context.Context, *ListBulbsRequest) (*ListBulbsResponse, error)
+	// GetState reads current state of a single bulb
+	GetState(context.Context, *GetStateRequest) (*State, error)
+	SetPower(context.Context, *SetPowerRequest) (*CommandResponse, error)
+	SetColor(context.Context, *SetColorRequest) (*CommandResponse, error)
+	StartFlow(context.Context, *StartFlowRequest) (*CommandResponse, error)
+	StopFlow(context.Context, *StopFlowRequest) (*CommandResponse, error)
+	SetScene(context.Context, *SetSceneRequest) (*CommandResponse, error)
+	// StreamEvents streams bulb notifications, connection state changes and discovery results
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	// StreamMusicFrames sends frames to bulbs over music mode connections, frames are not limited by quota.
+	// Music mode is started on first frame addressed to a bulb and stopped when stream ends
+	StreamMusicFrames(grpc.ClientStreamingServer[MusicFrame, StreamMusicFramesResponse]) error
+	mustEmbedUnimplementedYeelightServer()
+}
+
+// UnimplementedYeelightServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedYeelightServer struct{}
+
+func (UnimplementedYeelightServer) ListBulbs(context.Context, *ListBulbsRequest) (*ListBulbsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBulbs not implemented")
+}
+func (UnimplementedYeelightServer) GetState(context.Context, *GetStateRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedYeelightServer) SetPower(context.Context, *SetPowerRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPower not implemented")
+}
+func (UnimplementedYeelightServer) SetColor(context.Context, *SetColorRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetColor not implemented")
+}
+func (UnimplementedYeelightServer) StartFlow(context.Context, *StartFlowRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartFlow not implemented")
+}
+func (UnimplementedYeelightServer) StopFlow(context.Context, *StopFlowRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopFlow not implemented")
+}
+func (UnimplementedYeelightServer) SetScene(context.Context, *SetSceneRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetScene not implemented")
+}
+func (UnimplementedYeelightServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedYeelightServer) StreamMusicFrames(grpc.ClientStreamingServer[MusicFrame, StreamMusicFramesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMusicFrames not implemented")
+}
+func (UnimplementedYeelightServer) mustEmbedUnimplementedYeelightServer() {}
+func (UnimplementedYeelightServer) testEmbeddedByValue()                  {}
+
+// UnsafeYeelightServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to YeelightServer will
+// result in compilation errors.
+type UnsafeYeelightServer interface {
+	mustEmbedUnimplementedYeelightServer()
+}
+
+func RegisterYeelightServer(s grpc.ServiceRegistrar, srv YeelightServer) {
+	// If the following call pancis, it indicates UnimplementedYeelightServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Yeelight_ServiceDesc, srv)
+}
+
+func _Yeelight_ListBulbs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBulbsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YeelightServer).ListBulbs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yeelight_ListBulbs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YeelightServer).ListBulbs(ctx, req.(*ListBulbsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yeelight_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YeelightServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yeelight_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YeelightServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yeelight_SetPower_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPowerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YeelightServer).SetPower(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yeelight_SetPower_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YeelightServer).SetPower(ctx, req.(*SetPowerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yeelight_SetColor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetColorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YeelightServer).SetColor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yeelight_SetColor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YeelightServer).SetColor(ctx, req.(*SetColorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yeelight_StartFlow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartFlowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YeelightServer).StartFlow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yeelight_StartFlow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YeelightServer).StartFlow(ctx, req.(*StartFlowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yeelight_StopFlow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopFlowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YeelightServer).StopFlow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yeelight_StopFlow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YeelightServer).StopFlow(ctx, req.(*StopFlowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yeelight_SetScene_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetSceneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YeelightServer).SetScene(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yeelight_SetScene_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YeelightServer).SetScene(ctx, req.(*SetSceneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yeelight_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(YeelightServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Yeelight_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+func _Yeelight_StreamMusicFrames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(YeelightServer).StreamMusicFrames(&grpc.GenericServerStream[MusicFrame, StreamMusicFramesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Yeelight_StreamMusicFramesServer = grpc.ClientStreamingServer[MusicFrame, StreamMusicFramesResponse]
+
+// Yeelight_ServiceDesc is the grpc.ServiceDesc for Yeelight service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Yeelight_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "yeelight.v1.Yeelight",
+	HandlerType: (*YeelightServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBulbs",
+			Handler:    _Yeelight_ListBulbs_Handler,
+		},
+		{
+			MethodName: "GetState",
+			Handler:    _Yeelight_GetState_Handler,
+		},
+		{
+			MethodName: "SetPower",
+			Handler:    _Yeelight_SetPower_Handler,
+		},
+		{
+			MethodName: "SetColor",
+			Handler:    _Yeelight_SetColor_Handler,
+		},
+		{
+			MethodName: "StartFlow",
+			Handler:    _Yeelight_StartFlow_Handler,
+		},
+		{
+			MethodName: "StopFlow",
+			Handler:    _Yeelight_StopFlow_Handler,
+		},
+		{
+			MethodName: "SetScene",
+			Handler:    _Yeelight_SetScene_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Yeelight_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamMusicFrames",
+			Handler:       _Yeelight_StreamMusicFrames_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "yeelight.proto",
+}
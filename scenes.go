@@ -1,7 +1,11 @@
 package yeelight
 
+import (
+	"errors"
+)
+
 type Scene interface {
-	toParams() params
+	toParams() (params, error)
 }
 
 type BaseScene struct {
@@ -13,8 +17,19 @@ type ColorScene struct {
 	rgb, brightness int
 }
 
-func (s ColorScene) toParams() params {
-	return params{s.name, s.rgb, s.brightness}
+// NewColorScene creates scene which sets color and brightness
+func NewColorScene(rgb, brightness int) ColorScene {
+	return ColorScene{BaseScene{"color"}, rgb, brightness}
+}
+
+func (s ColorScene) toParams() (params, error) {
+	if s.rgb < 0 || s.rgb > 0xffffff {
+		return nil, errors.New("rgb expected range: 0-0xFFFFFF")
+	}
+	if err := validateSceneBrightness(s.brightness); err != nil {
+		return nil, err
+	}
+	return params{s.name, s.rgb, s.brightness}, nil
 }
 
 type HSVScene struct {
@@ -22,8 +37,22 @@ type HSVScene struct {
 	hue, saturation, brightness int
 }
 
-func (s HSVScene) toParams() params {
-	return params{s.name, s.hue, s.saturation, s.brightness}
+// NewHSVScene creates scene which sets hue, saturation and brightness
+func NewHSVScene(hue, saturation, brightness int) HSVScene {
+	return HSVScene{BaseScene{"hsv"}, hue, saturation, brightness}
+}
+
+func (s HSVScene) toParams() (params, error) {
+	if s.hue < 0 || s.hue > 359 {
+		return nil, errors.New("hue expected range: 0-359")
+	}
+	if s.saturation < 0 || s.saturation > 100 {
+		return nil, errors.New("saturation expected range: 0-100")
+	}
+	if err := validateSceneBrightness(s.brightness); err != nil {
+		return nil, err
+	}
+	return params{s.name, s.hue, s.saturation, s.brightness}, nil
 }
 
 type TemperatureScene struct {
@@ -31,8 +60,19 @@ type TemperatureScene struct {
 	temperature, brightness int
 }
 
-func (s TemperatureScene) toParams() params {
-	return params{s.name, s.temperature, s.brightness}
+// NewTemperatureScene creates scene which sets temperature and brightness
+func NewTemperatureScene(temperature, brightness int) TemperatureScene {
+	return TemperatureScene{BaseScene{"ct"}, temperature, brightness}
+}
+
+func (s TemperatureScene) toParams() (params, error) {
+	if s.temperature < 1700 || s.temperature > 6500 {
+		return nil, errors.New("temperature expected range: 1700-6500")
+	}
+	if err := validateSceneBrightness(s.brightness); err != nil {
+		return nil, err
+	}
+	return params{s.name, s.temperature, s.brightness}, nil
 }
 
 type ColorFlowScene struct {
@@ -41,8 +81,16 @@ type ColorFlowScene struct {
 	flowExpression FlowExpression
 }
 
-func (s ColorFlowScene) toParams() params {
-	return params{s.name, s.count, s.action, s.flowExpression}
+// NewColorFlowScene creates scene which starts color flow
+func NewColorFlowScene(count int, action CfAction, flowExpression FlowExpression) ColorFlowScene {
+	return ColorFlowScene{BaseScene{"cf"}, count, int(action), flowExpression}
+}
+
+func (s ColorFlowScene) toParams() (params, error) {
+	if len(s.flowExpression.states) == 0 {
+		return nil, errors.New("flow expression is empty")
+	}
+	return params{s.name, s.count, s.action, s.flowExpression.encode()}, nil
 }
 
 // automatic shutdown after specified amount of minutes
@@ -51,6 +99,25 @@ type AutoDelayOffScene struct {
 	brightness, minutes int
 }
 
-func (s AutoDelayOffScene) toParams() params {
-	return params{s.name, s.brightness, s.minutes}
+// NewAutoDelayOffScene creates scene which turns device on with given brightness
+// and turns it off after given amount of minutes
+func NewAutoDelayOffScene(brightness, minutes int) AutoDelayOffScene {
+	return AutoDelayOffScene{BaseScene{"auto_delay_off"}, brightness, minutes}
+}
+
+func (s AutoDelayOffScene) toParams() (params, error) {
+	if err := validateSceneBrightness(s.brightness); err != nil {
+		return nil, err
+	}
+	if s.minutes < 1 {
+		return nil, errors.New("minutes required to be >= 1")
+	}
+	return params{s.name, s.brightness, s.minutes}, nil
+}
+
+func validateSceneBrightness(brightness int) error {
+	if brightness < 1 || brightness > 100 {
+		return errors.New("brightness expected range: 1-100")
+	}
+	return nil
 }
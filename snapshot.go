@@ -0,0 +1,171 @@
+package yeelight
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// LightSnapshot holds state of main or background light
+type LightSnapshot struct {
+	Power      bool `json:"power"`
+	Brightness int  `json:"brightness"`
+	ColorMode  int  `json:"color_mode"` // 1: rgb mode / 2: color temperature mode / 3: hsv mode
+	RGB        int  `json:"rgb"`
+	CT         int  `json:"ct"`
+	Hue        int  `json:"hue"`
+	Sat        int  `json:"sat"`
+
+	Flowing    bool            `json:"flowing"`
+	FlowCount  int             `json:"flow_count,omitempty"`
+	FlowAction CfAction        `json:"flow_action,omitempty"`
+	Flow       *FlowExpression `json:"flow,omitempty"` // running flow, nil when flow is not running
+}
+
+// Snapshot holds device state which can be restored later, see Bulb.Snapshot and Bulb.Restore
+type Snapshot struct {
+	Main       LightSnapshot  `json:"main"`
+	Background *LightSnapshot `json:"background,omitempty"` // nil when device doesn't have background light
+	TakenAt    time.Time      `json:"taken_at"`
+}
+
+// snapshotProperties holds names of properties required for taking snapshot of main or background light
+type snapshotProperties struct {
+	power, bright, colorMode, rgb, ct, hue, sat, flowing, flowParams Property
+}
+
+var (
+	mainSnapshotProperties = snapshotProperties{
+		PROP_POWER, PROP_BRIGHT, PROP_COLOR_MODE, PROP_RGB, PROP_CT, PROP_HUE, PROP_SAT,
+		PROP_FLOWING, PROP_FLOW_PARAMS,
+	}
+	backgroundSnapshotProperties = snapshotProperties{
+		PROP_BG_POWER, PROP_BG_BRIGHT, PROP_BG_LMODE, PROP_BG_RGB, PROP_BG_CT, PROP_BG_HUE, PROP_BG_SAT,
+		PROP_BG_FLOWING, PROP_BG_FLOW_PARAMS,
+	}
+)
+
+func (p snapshotProperties) list() []Property {
+	return []Property{p.power, p.bright, p.colorMode, p.rgb, p.ct, p.hue, p.sat, p.flowing, p.flowParams}
+}
+
+// Snapshot reads current state of the device (power, color mode, color, brightness, running flow
+// and background light state), so it can be restored with Restore
+func (b *Bulb) Snapshot() (Snapshot, error) {
+	props := append(mainSnapshotProperties.list(), backgroundSnapshotProperties.list()...)
+
+	values, err := b.Prop(props...)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{TakenAt: time.Now()}
+	snapshot.Main, err = parseLightSnapshot(values, mainSnapshotProperties)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	// properties not supported by device are reported as empty strings
+	if values[string(PROP_BG_POWER)] != "" {
+		background, err := parseLightSnapshot(values, backgroundSnapshotProperties)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("background: %v", err)
+		}
+		snapshot.Background = &background
+	}
+	return snapshot, nil
+}
+
+func parseLightSnapshot(values map[string]interface{}, p snapshotProperties) (LightSnapshot, error) {
+	get := func(prop Property) string {
+		return fmt.Sprintf("%v", values[string(prop)])
+	}
+	atoi := func(prop Property) int {
+		value, _ := strconv.Atoi(get(prop))
+		return value
+	}
+
+	snapshot := LightSnapshot{
+		Power:      get(p.power) == "on",
+		Brightness: atoi(p.bright),
+		ColorMode:  atoi(p.colorMode),
+		RGB:        atoi(p.rgb),
+		CT:         atoi(p.ct),
+		Hue:        atoi(p.hue),
+		Sat:        atoi(p.sat),
+		Flowing:    get(p.flowing) == "1",
+	}
+
+	if snapshot.Flowing {
+		count, action, flow, err := ParseFlowParams(get(p.flowParams))
+		if err != nil {
+			return LightSnapshot{}, fmt.Errorf("failed to parse running flow: %v", err)
+		}
+		snapshot.FlowCount, snapshot.FlowAction, snapshot.Flow = count, action, &flow
+	}
+	return snapshot, nil
+}
+
+// Restore brings device back to the state saved in snapshot. Values are sent as they were reported
+// by device, so calibration is not applied again. Color of turned off light is not restored,
+// as it's not possible without turning it on
+func (b *Bulb) Restore(snapshot Snapshot) error {
+	if err := restoreLight(&b.commonCommands, snapshot.Main); err != nil {
+		return err
+	}
+	if snapshot.Background != nil {
+		if err := restoreLight(&b.Bg.commonCommands, *snapshot.Background); err != nil {
+			return fmt.Errorf("background: %v", err)
+		}
+	}
+	return nil
+}
+
+func restoreLight(c *commonCommands, snapshot LightSnapshot) error {
+	execute := func(method string, p params) error {
+		return c.commander.executeCommand(partialCommand{c.prefix + method, p})
+	}
+
+	if !snapshot.Power {
+		return execute("set_power", params{"off", "sudden", 0})
+	}
+
+	// device is turned on directly in saved color mode, so previous color doesn't flash
+	mode := MODE_DEFAUTL
+	switch snapshot.ColorMode {
+	case 1:
+		mode = MODE_RGB
+	case 2:
+		mode = MDOE_CT
+	case 3:
+		mode = MODE_HSV
+	}
+	if err := execute("set_power", params{"on", "sudden", 0, mode}); err != nil {
+		return err
+	}
+
+	var err error
+	switch snapshot.ColorMode {
+	case 1:
+		err = execute("set_rgb", params{snapshot.RGB, "sudden", 0})
+	case 2:
+		err = execute("set_ct_abx", params{snapshot.CT, "sudden", 0})
+	case 3:
+		err = execute("set_hsv", params{snapshot.Hue, snapshot.Sat, "sudden", 0})
+	}
+	if err != nil {
+		return err
+	}
+
+	if snapshot.Brightness >= 1 && snapshot.Brightness <= 100 {
+		if err := execute("set_bright", params{snapshot.Brightness, "sudden", 0}); err != nil {
+			return err
+		}
+	}
+
+	// flow is started after static state, so CF_ACTION_RECOVER recovers to it
+	if snapshot.Flowing && snapshot.Flow != nil {
+		return execute("start_cf", params{snapshot.FlowCount, snapshot.FlowAction, snapshot.Flow.encode()})
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package yeelight_test
+
+import (
+	"testing"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	tests := []struct {
+		name  string
+		props map[yl.Property]string
+		want  yl.LightSnapshot
+	}{
+		{"rgb", map[yl.Property]string{
+			yl.PROP_POWER: "on", yl.PROP_COLOR_MODE: "1", yl.PROP_RGB: "16744448", yl.PROP_BRIGHT: "40",
+		}, yl.LightSnapshot{Power: true, Brightness: 40, ColorMode: 1, RGB: 0xff8000, CT: 4000}},
+		{"temperature", map[yl.Property]string{
+			yl.PROP_POWER: "on", yl.PROP_COLOR_MODE: "2", yl.PROP_CT: "2700", yl.PROP_BRIGHT: "80",
+		}, yl.LightSnapshot{Power: true, Brightness: 80, ColorMode: 2, RGB: 0xffffff, CT: 2700}},
+		{"hsv", map[yl.Property]string{
+			yl.PROP_POWER: "on", yl.PROP_COLOR_MODE: "3", yl.PROP_HUE: "200", yl.PROP_SAT: "70", yl.PROP_BRIGHT: "10",
+		}, yl.LightSnapshot{Power: true, Brightness: 10, ColorMode: 3, RGB: 0xffffff, CT: 4000, Hue: 200, Sat: 70}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			device, err := yeelighttest.NewDevice()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer device.Close()
+			bulb, err := device.Bulb()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer bulb.Disconnect()
+
+			for prop, value := range test.props {
+				device.SetProp(prop, value)
+			}
+			snapshot, err := bulb.Snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot.Main != test.want {
+				t.Fatalf("expected %+v, got %+v", test.want, snapshot.Main)
+			}
+			if snapshot.Background != nil {
+				t.Errorf("unexpected background snapshot: %+v", snapshot.Background)
+			}
+
+			// state changed by somebody else, in other color mode
+			device.SetProp(yl.PROP_COLOR_MODE, map[string]string{"1": "2", "2": "3", "3": "1"}[test.props[yl.PROP_COLOR_MODE]])
+			device.SetProp(yl.PROP_RGB, "255")
+			device.SetProp(yl.PROP_CT, "6500")
+			device.SetProp(yl.PROP_HUE, "10")
+			device.SetProp(yl.PROP_SAT, "100")
+			device.SetProp(yl.PROP_BRIGHT, "100")
+
+			if err := bulb.Restore(snapshot); err != nil {
+				t.Fatal(err)
+			}
+			for prop, value := range test.props {
+				if got := device.Prop(prop); got != value {
+					t.Errorf("%s: expected %s, got %s", prop, value, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRestorePoweredOff(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+	bulb, err := device.Bulb()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bulb.Disconnect()
+
+	snapshot, err := bulb.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Main.Power {
+		t.Fatal("expected powered off snapshot")
+	}
+
+	device.SetProp(yl.PROP_POWER, "on")
+	device.SetProp(yl.PROP_COLOR_MODE, "1")
+	device.Reset()
+	if err := bulb.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if power := device.Prop(yl.PROP_POWER); power != "off" {
+		t.Errorf("expected device turned off, got %s", power)
+	}
+	for _, command := range device.Commands() {
+		if command.Method != "set_power" || command.Params[0] != "off" {
+			t.Errorf("unexpected command restoring turned off device: %+v", command)
+		}
+	}
+
+	// restoring already turned off device doesn't turn it on
+	device.Reset()
+	if err := bulb.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if power := device.Prop(yl.PROP_POWER); power != "off" {
+		t.Errorf("expected device turned off, got %s", power)
+	}
+}
+
+func TestRestoreBackground(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+	device.EnableBackground()
+	bulb, err := device.Bulb()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bulb.Disconnect()
+
+	device.SetProp(yl.PROP_BG_POWER, "on")
+	device.SetProp(yl.PROP_BG_LMODE, "3")
+	device.SetProp(yl.PROP_BG_HUE, "120")
+	device.SetProp(yl.PROP_BG_SAT, "50")
+	snapshot, err := bulb.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Background == nil || snapshot.Background.ColorMode != 3 || snapshot.Background.Hue != 120 {
+		t.Fatalf("unexpected background snapshot: %+v", snapshot.Background)
+	}
+
+	device.SetProp(yl.PROP_BG_LMODE, "2")
+	device.SetProp(yl.PROP_POWER, "on")
+	if err := bulb.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if mode, hue, power := device.Prop(yl.PROP_BG_LMODE), device.Prop(yl.PROP_BG_HUE), device.Prop(yl.PROP_POWER); mode != "3" || hue != "120" || power != "off" {
+		t.Errorf("unexpected state after restore: lmode %s, hue %s, power %s", mode, hue, power)
+	}
+}
@@ -0,0 +1,286 @@
+package yeelight
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedProperties are read by state cache on every synchronization
+var cachedProperties = []Property{
+	PROP_POWER, PROP_BRIGHT, PROP_CT, PROP_RGB, PROP_HUE, PROP_SAT, PROP_COLOR_MODE,
+	PROP_FLOWING, PROP_FLOW_PARAMS, PROP_DELAYOFF, PROP_MUSIC_ON, PROP_NAME,
+	PROP_BG_POWER, PROP_BG_BRIGHT, PROP_BG_CT, PROP_BG_RGB, PROP_BG_HUE, PROP_BG_SAT,
+	PROP_BG_LMODE, PROP_BG_FLOWING, PROP_BG_FLOW_PARAMS,
+}
+
+// State is a snapshot of cached device properties
+type State struct {
+	Props     map[Property]string // property values as reported by device
+	UpdatedAt time.Time           // last update by notification, command or synchronization
+	SyncedAt  time.Time           // last full synchronization with device
+}
+
+// Get returns property value, empty string when property is unknown
+func (s State) Get(prop Property) string {
+	return s.Props[prop]
+}
+
+// Int returns property value as integer
+func (s State) Int(prop Property) (int, error) {
+	value, ok := s.Props[prop]
+	if !ok || value == "" {
+		return 0, fmt.Errorf("property \"%s\" is not available", prop)
+	}
+	return strconv.Atoi(value)
+}
+
+// Age returns time elapsed since last update
+func (s State) Age() time.Duration {
+	return time.Since(s.UpdatedAt)
+}
+
+func (s State) copy() State {
+	props := make(map[Property]string, len(s.Props))
+	for k, v := range s.Props {
+		props[k] = v
+	}
+	s.Props = props
+	return s
+}
+
+type stateCache struct {
+	mtx   sync.Mutex
+	state State
+	stop  chan struct{}
+}
+
+func (c *stateCache) update(props map[Property]string, synced bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := time.Now()
+	for k, v := range props {
+		c.state.Props[k] = v
+	}
+	c.state.UpdatedAt = now
+	if synced {
+		c.state.SyncedAt = now
+	}
+}
+
+// EnableStateCache enables local copy of device state, seeded by Prop and kept in sync by notifications
+// and successful commands. Cache is fully synchronized every resync interval for correcting a drift,
+// zero interval disables periodic synchronization. Bulb is required to be connected
+func (b *Bulb) EnableStateCache(resync time.Duration) error {
+	b.cacheMtx.Lock()
+	if b.cache != nil {
+		b.cacheMtx.Unlock()
+		return errors.New("state cache is already enabled")
+	}
+	cache := &stateCache{state: State{Props: make(map[Property]string)}, stop: make(chan struct{})}
+	b.cache = cache
+	b.cacheMtx.Unlock()
+
+	if err := b.SyncState(); err != nil {
+		b.DisableStateCache()
+		return err
+	}
+
+	if resync > 0 {
+		go func() {
+			ticker := time.NewTicker(resync)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-cache.stop:
+					return
+				case <-ticker.C:
+					err := b.SyncState()
+					if err == ErrConnectionClosed {
+						// synchronization cannot succeed anymore, cache is dropped with the connection
+						b.dropStateCache(cache)
+						return
+					}
+					if err != nil {
+						log.Printf("[%s] state synchronization failed: %v\n", b.Ip, err)
+					}
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// DisableStateCache disables state cache and stops periodic synchronization
+func (b *Bulb) DisableStateCache() {
+	b.cacheMtx.Lock()
+	defer b.cacheMtx.Unlock()
+
+	if b.cache != nil {
+		close(b.cache.stop)
+		b.cache = nil
+	}
+}
+
+// dropStateCache disables given cache unless it was already replaced
+func (b *Bulb) dropStateCache(cache *stateCache) {
+	b.cacheMtx.Lock()
+	defer b.cacheMtx.Unlock()
+
+	if b.cache == cache {
+		close(cache.stop)
+		b.cache = nil
+	}
+}
+
+// SyncState reads all cached properties from device
+func (b *Bulb) SyncState() error {
+	cache := b.stateCache()
+	if cache == nil {
+		return errors.New("state cache is not enabled")
+	}
+
+	values, err := b.Prop(cachedProperties...)
+	if err != nil {
+		return err
+	}
+
+	props := make(map[Property]string, len(values))
+	for k, v := range values {
+		props[Property(k)] = fmt.Sprintf("%v", v)
+	}
+	cache.update(props, true)
+	return nil
+}
+
+// State returns snapshot of cached device state, state cache needs to be enabled
+func (b *Bulb) State() (State, error) {
+	cache := b.stateCache()
+	if cache == nil {
+		return State{}, errors.New("state cache is not enabled")
+	}
+
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	return cache.state.copy(), nil
+}
+
+func (b *Bulb) stateCache() *stateCache {
+	b.cacheMtx.Lock()
+	defer b.cacheMtx.Unlock()
+	return b.cache
+}
+
+func (b *Bulb) updateCacheFromNotification(notification Notification) {
+	cache := b.stateCache()
+	if cache == nil || notification.Method != "props" {
+		return
+	}
+
+	props := make(map[Property]string, len(notification.Params))
+	for k, v := range notification.Params {
+		props[Property(k)] = v
+	}
+	cache.update(props, false)
+}
+
+// cachedPower returns cached power state of the light controlled by given command
+func (b *Bulb) cachedPower(c partialCommand) string {
+	cache := b.stateCache()
+	if cache == nil {
+		return ""
+	}
+
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+	if strings.HasPrefix(c.Method, "bg_") {
+		return cache.state.Props[PROP_BG_POWER]
+	}
+	return cache.state.Props[PROP_POWER]
+}
+
+// updateCacheFromCommand applies effects of executed command, power is state before command was sent
+func (b *Bulb) updateCacheFromCommand(c partialCommand, power string) {
+	cache := b.stateCache()
+	if cache == nil {
+		return
+	}
+
+	props := commandEffects(c, power)
+	if len(props) > 0 {
+		cache.update(props, false)
+	}
+}
+
+// commandEffects returns properties changed by successfully executed command,
+// power is power state before the command, required for toggle commands
+func commandEffects(c partialCommand, power string) map[Property]string {
+	var (
+		method = strings.TrimPrefix(c.Method, "bg_")
+		prefix = strings.TrimSuffix(c.Method, method)
+		props  = make(map[Property]string)
+	)
+
+	param := func(i int) string {
+		if i >= len(c.Params) {
+			return ""
+		}
+		return fmt.Sprintf("%v", c.Params[i])
+	}
+	colorMode := func(mode string) {
+		if prefix == "bg_" {
+			props[PROP_BG_LMODE] = mode
+		} else {
+			props[PROP_COLOR_MODE] = mode
+		}
+	}
+	set := func(prop Property, value string) {
+		props[Property(prefix)+prop] = value
+	}
+
+	switch method {
+	case "set_rgb":
+		set(PROP_RGB, param(0))
+		colorMode("1")
+	case "set_ct_abx":
+		set(PROP_CT, param(0))
+		colorMode("2")
+	case "set_hsv":
+		set(PROP_HUE, param(0))
+		set(PROP_SAT, param(1))
+		colorMode("3")
+	case "set_bright":
+		set(PROP_BRIGHT, param(0))
+	case "set_power":
+		set(PROP_POWER, param(0))
+	case "toggle":
+		if power == "on" {
+			set(PROP_POWER, "off")
+		} else if power == "off" {
+			set(PROP_POWER, "on")
+		}
+	case "dev_toggle":
+		// both lights are switched according to the main light
+		if power == "on" {
+			props[PROP_POWER], props[PROP_BG_POWER] = "off", "off"
+		} else if power == "off" {
+			props[PROP_POWER], props[PROP_BG_POWER] = "on", "on"
+		}
+	case "start_cf":
+		set(PROP_FLOWING, "1")
+	case "stop_cf":
+		set(PROP_FLOWING, "0")
+	case "set_name":
+		props[PROP_NAME] = param(0)
+	case "cron_add":
+		props[PROP_DELAYOFF] = param(1)
+	case "cron_del":
+		props[PROP_DELAYOFF] = "0"
+	}
+	return props
+}
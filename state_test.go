@@ -0,0 +1,89 @@
+package yeelight_test
+
+import (
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+func TestStateCacheDevToggle(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+	device.EnableBackground()
+	device.SetProp(yl.PROP_BG_POWER, "off")
+
+	bulb, err := device.Bulb()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bulb.Disconnect()
+	if err := bulb.EnableStateCache(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bulb.Bg.DevToggle(); err != nil {
+		t.Fatal(err)
+	}
+	state, err := bulb.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Get(yl.PROP_POWER) != "on" || state.Get(yl.PROP_BG_POWER) != "on" {
+		t.Errorf("expected both lights on, got power=%s bg_power=%s", state.Get(yl.PROP_POWER), state.Get(yl.PROP_BG_POWER))
+	}
+}
+
+func TestStateCacheDisconnect(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	bulb, err := device.Bulb()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bulb.EnableStateCache(time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := bulb.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bulb.State(); err == nil {
+		t.Error("state cache is still enabled after disconnect")
+	}
+}
+
+func TestStateCacheConnectionLost(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bulb, err := device.Bulb()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bulb.Disconnect()
+	if err := bulb.EnableStateCache(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	device.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := bulb.State(); err != nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("state cache is still enabled after connection was lost")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,449 @@
+package wled
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+)
+
+// State is a WLED device state
+type State struct {
+	On           bool       `json:"on"`
+	Bri          int        `json:"bri"`        // 1-255
+	Transition   int        `json:"transition"` // multiple of 100 ms
+	Preset       int        `json:"ps"`
+	Playlist     int        `json:"pl"`
+	Nightlight   nightlight `json:"nl"`
+	UDPSync      udpSync    `json:"udpn"`
+	LiveOverride int        `json:"lor"`
+	MainSegment  int        `json:"mainseg"`
+	Segments     []Segment  `json:"seg"`
+}
+
+type nightlight struct {
+	On        bool `json:"on"`
+	Duration  int  `json:"dur"`
+	Mode      int  `json:"mode"`
+	TargetBri int  `json:"tbri"`
+	Remaining int  `json:"rem"`
+}
+
+type udpSync struct {
+	Send    bool `json:"send"`
+	Receive bool `json:"recv"`
+}
+
+// Segment is a WLED segment, target is presented as a single segment with a single LED
+type Segment struct {
+	ID        int       `json:"id"`
+	Start     int       `json:"start"`
+	Stop      int       `json:"stop"`
+	Len       int       `json:"len"`
+	Group     int       `json:"grp"`
+	Spacing   int       `json:"spc"`
+	Offset    int       `json:"of"`
+	On        bool      `json:"on"`
+	Freeze    bool      `json:"frz"`
+	Bri       int       `json:"bri"`
+	CCT       int       `json:"cct"` // 0 (warm) - 255 (cold)
+	Colors    [3][3]int `json:"col"` // primary, secondary and tertiary RGB color
+	Effect    int       `json:"fx"`
+	Speed     int       `json:"sx"`
+	Intensity int       `json:"ix"`
+	Palette   int       `json:"pal"`
+	Selected  bool      `json:"sel"`
+	Reverse   bool      `json:"rev"`
+	Mirror    bool      `json:"mi"`
+}
+
+// temperature range of Yeelight bulbs, mapped onto WLED relative white balance
+const (
+	minTemperature = 1700
+	maxTemperature = 6500
+)
+
+// State returns current state, read from state cache of target bulbs. Group is reported as turned on
+// when any of its bulbs is on, color and brightness are reported by the first turned on bulb
+func (l *Light) State() (State, error) {
+	entries, err := l.registry.Select(l.target)
+	if err != nil {
+		return State{}, err
+	}
+
+	var (
+		current yl.State
+		found   bool
+		lastErr error
+	)
+	for _, entry := range entries {
+		bulb, err := l.bulb(entry)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		state, err := bulb.State()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !found || current.Get(yl.PROP_POWER) != "on" && state.Get(yl.PROP_POWER) == "on" {
+			current, found = state, true
+		}
+	}
+	if !found {
+		return State{}, lastErr
+	}
+
+	l.mtx.Lock()
+	transition, effect := l.transition, l.effect
+	l.mtx.Unlock()
+	return newState(current, transition, effect), nil
+}
+
+// newState converts bulb state into WLED state, effect is reported while flow is running
+func newState(s yl.State, transition, effect int) State {
+	on := s.Get(yl.PROP_POWER) == "on"
+	segment := Segment{Stop: 1, Len: 1, Group: 1, On: on, Bri: 255, CCT: 127, Speed: 128, Intensity: 128, Selected: true}
+
+	var color yl.Color
+	switch s.Get(yl.PROP_COLOR_MODE) {
+	case "2":
+		ct, _ := s.Int(yl.PROP_CT)
+		color = yl.ColorFromKelvin(ct)
+		segment.CCT = clamp(int(math.Round(float64(ct-minTemperature)*255/(maxTemperature-minTemperature))), 0, 255)
+	case "3":
+		hue, _ := s.Int(yl.PROP_HUE)
+		sat, _ := s.Int(yl.PROP_SAT)
+		color = yl.ColorFromHSV(float64(hue), float64(sat), 100)
+	default:
+		rgb, _ := s.Int(yl.PROP_RGB)
+		color = yl.ColorFromRGB(rgb)
+	}
+	rgb := color.RGB()
+	segment.Colors[0] = [3]int{rgb >> 16 & 0xff, rgb >> 8 & 0xff, rgb & 0xff}
+	if s.Get(yl.PROP_FLOWING) == "1" {
+		segment.Effect = effect
+	}
+
+	bri := 255
+	if bright, err := s.Int(yl.PROP_BRIGHT); err == nil {
+		bri = clamp(int(math.Round(float64(bright)*255/100)), 1, 255)
+	}
+	return State{
+		On:          on,
+		Bri:         bri,
+		Transition:  transition,
+		Preset:      -1,
+		Playlist:    -1,
+		Nightlight:  nightlight{Duration: 60, TargetBri: 0, Remaining: -1},
+		MainSegment: 0,
+		Segments:    []Segment{segment},
+	}
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// stateRequest is a body of state POST, unsupported fields sent by clients are ignored
+type stateRequest struct {
+	On         json.RawMessage `json:"on"` // true, false or "t" (toggle)
+	Bri        *int            `json:"bri"`
+	Transition *int            `json:"transition"` // default transition, multiple of 100 ms
+	TT         *int            `json:"tt"`         // transition of this request only
+	Verbose    bool            `json:"v"`          // respond with new state
+	Seg        json.RawMessage `json:"seg"`        // segment or array of segments
+}
+
+type segmentRequest struct {
+	ID  *int              `json:"id"`
+	On  json.RawMessage   `json:"on"`
+	Bri *int              `json:"bri"`
+	Col []json.RawMessage `json:"col"` // [r, g, b], [r, g, b, w] or "rrggbb", only primary color is used
+	CCT *int              `json:"cct"` // 0-255 or kelvins
+	FX  *int              `json:"fx"`
+}
+
+// command is a validated state change, nil fields are not changed
+type command struct {
+	power  *bool
+	toggle bool
+	bri    *int // percentage, 0 turns lights off
+	rgb    *int
+	temp   *int
+	flow   *flows.Flow
+	effect *int
+	d      time.Duration
+}
+
+// parseOn parses power value, toggle is returned for "t"
+func parseOn(raw json.RawMessage) (power *bool, toggle bool, err error) {
+	if len(raw) == 0 {
+		return nil, false, nil
+	}
+	var on bool
+	if err := json.Unmarshal(raw, &on); err == nil {
+		return &on, false, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil && s == "t" {
+		return nil, true, nil
+	}
+	return nil, false, &requestError{fmt.Sprintf("invalid on value %s, expected true, false or \"t\"", raw)}
+}
+
+// parseColor parses primary color in [r, g, b(, w)] or "rrggbb" form
+func parseColor(raw json.RawMessage) (int, error) {
+	var channels []int
+	if err := json.Unmarshal(raw, &channels); err == nil {
+		if len(channels) < 3 {
+			return 0, &requestError{fmt.Sprintf("invalid color %s, expected [r, g, b]", raw)}
+		}
+		rgb := 0
+		for _, c := range channels[:3] {
+			if c < 0 || c > 255 {
+				return 0, &requestError{fmt.Sprintf("invalid color %s, channels expected in 0~255 range", raw)}
+			}
+			rgb = rgb<<8 | c
+		}
+		return rgb, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil && len(s) >= 6 {
+		if rgb, err := strconv.ParseUint(s[:6], 16, 32); err == nil {
+			return int(rgb), nil
+		}
+	}
+	return 0, &requestError{fmt.Sprintf("invalid color %s, expected [r, g, b] or \"rrggbb\"", raw)}
+}
+
+// parse validates request, transition is a default transition of the light
+func (r stateRequest) parse(transition int) (command, error) {
+	var (
+		cmd command
+		err error
+	)
+	if cmd.power, cmd.toggle, err = parseOn(r.On); err != nil {
+		return cmd, err
+	}
+	if r.Bri != nil {
+		if cmd.bri, err = parseBri(*r.Bri); err != nil {
+			return cmd, err
+		}
+	}
+	for _, t := range []*int{r.Transition, r.TT} {
+		if t != nil && (*t < 0 || *t > 65535) {
+			return cmd, &requestError{fmt.Sprintf("transition expected in 0~65535 range, got %d", *t)}
+		}
+	}
+	if r.Transition != nil {
+		transition = *r.Transition
+	}
+	if r.TT != nil {
+		transition = *r.TT
+	}
+	cmd.d = time.Duration(transition) * 100 * time.Millisecond
+
+	segment, err := r.segment()
+	if err != nil || segment == nil {
+		return cmd, err
+	}
+	if len(segment.On) > 0 && cmd.power == nil && !cmd.toggle {
+		if cmd.power, cmd.toggle, err = parseOn(segment.On); err != nil {
+			return cmd, err
+		}
+	}
+	if segment.Bri != nil && cmd.bri == nil {
+		if cmd.bri, err = parseBri(*segment.Bri); err != nil {
+			return cmd, err
+		}
+	}
+	if len(segment.Col) > 0 && len(segment.Col[0]) > 0 && string(segment.Col[0]) != "[]" {
+		rgb, err := parseColor(segment.Col[0])
+		if err != nil {
+			return cmd, err
+		}
+		cmd.rgb = &rgb
+	}
+	if segment.CCT != nil {
+		temp := *segment.CCT
+		switch {
+		case temp >= 0 && temp <= 255:
+			temp = minTemperature + int(math.Round(float64(temp)*(maxTemperature-minTemperature)/255))
+		case temp >= 1900 && temp <= 10091:
+			temp = clamp(temp, minTemperature, maxTemperature)
+		default:
+			return cmd, &requestError{fmt.Sprintf("cct expected in 0~255 or 1900~10091 (kelvins) range, got %d", temp)}
+		}
+		cmd.temp = &temp
+	}
+	if segment.FX != nil {
+		if *segment.FX != 0 {
+			flow, err := preset(*segment.FX)
+			if err != nil {
+				return cmd, &requestError{err.Error()}
+			}
+			cmd.flow = &flow
+		}
+		cmd.effect = segment.FX
+	}
+	return cmd, nil
+}
+
+// parseBri converts WLED brightness into percentage, 0 is kept for turning lights off
+func parseBri(v int) (*int, error) {
+	if v < 0 || v > 255 {
+		return nil, &requestError{fmt.Sprintf("bri expected in 0~255 range, got %d", v)}
+	}
+	bri := 0
+	if v > 0 {
+		bri = clamp(int(math.Round(float64(v)*100/255)), 1, 100)
+	}
+	return &bri, nil
+}
+
+// segment returns request of the first segment, nil when segments are not changed
+func (r stateRequest) segment() (*segmentRequest, error) {
+	if len(r.Seg) == 0 {
+		return nil, nil
+	}
+	var segments []segmentRequest
+	if err := json.Unmarshal(r.Seg, &segments); err != nil {
+		var segment segmentRequest
+		if err := json.Unmarshal(r.Seg, &segment); err != nil {
+			return nil, &requestError{fmt.Sprintf("invalid seg: %v", err)}
+		}
+		segments = []segmentRequest{segment}
+	}
+	for i, segment := range segments {
+		id := i
+		if segment.ID != nil {
+			id = *segment.ID
+		}
+		if id == 0 {
+			return &segments[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// controller is implemented by *yl.Bulb
+type controller interface {
+	State() (yl.State, error)
+	SetPower(on bool, d time.Duration) error
+	SetBrightness(brightness int, d time.Duration) error
+	SetRGB(rgb int, d time.Duration) error
+	SetTemperature(temp int, d time.Duration) error
+	StartColorFlow(count int, action yl.CfAction, flowExpression yl.FlowExpression) error
+	StopColorFlow() error
+}
+
+// execute applies command to the bulb. Zero brightness and black color turn the bulb off, as on WLED
+// device. Changes other than power are ignored while the bulb is off
+func (c command) execute(bulb controller) error {
+	state, _ := bulb.State()
+	on := state.Get(yl.PROP_POWER) == "on"
+
+	target := on
+	switch {
+	case c.toggle:
+		target = !on
+	case c.power != nil:
+		target = *c.power
+	}
+	if c.bri != nil && *c.bri == 0 || c.rgb != nil && *c.rgb == 0 {
+		target = false
+	}
+
+	if !target {
+		if on {
+			return bulb.SetPower(false, c.d)
+		}
+		return nil
+	}
+	if !on {
+		if err := bulb.SetPower(true, c.d); err != nil {
+			return err
+		}
+	}
+
+	if c.effect != nil && c.flow == nil && state.Get(yl.PROP_FLOWING) == "1" {
+		if err := bulb.StopColorFlow(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case c.rgb != nil:
+		if err := bulb.SetRGB(*c.rgb, c.d); err != nil {
+			return err
+		}
+	case c.temp != nil:
+		if err := bulb.SetTemperature(*c.temp, c.d); err != nil {
+			return err
+		}
+	}
+	if c.bri != nil {
+		if err := bulb.SetBrightness(*c.bri, c.d); err != nil {
+			return err
+		}
+	}
+	if c.flow != nil {
+		return c.flow.Start(bulb)
+	}
+	return nil
+}
+
+func (l *Light) setState(w http.ResponseWriter, r *http.Request) {
+	var req stateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, &requestError{fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+
+	l.mtx.Lock()
+	transition := l.transition
+	l.mtx.Unlock()
+	cmd, err := req.parse(transition)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	l.mtx.Lock()
+	if req.Transition != nil {
+		l.transition = *req.Transition
+	}
+	if cmd.effect != nil {
+		l.effect = *cmd.effect
+	}
+	l.mtx.Unlock()
+
+	// toggle depends on current state, so it cannot be repeated
+	if err := l.each(func(bulb *yl.Bulb) error { return cmd.execute(bulb) }, !cmd.toggle); err != nil {
+		writeError(w, err)
+		return
+	}
+	if !req.Verbose {
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+		return
+	}
+	state, err := l.State()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
@@ -0,0 +1,350 @@
+// Package wled exposes bulbs from registry with WLED JSON API, so WLED apps and integrations can control
+// them. Every target (single bulb or group selector, see registry.Select) is presented as a separate WLED
+// device with one segment:
+//
+//   GET  /json          state, info, effects and palettes
+//   GET  /json/si       state and info
+//   GET  /json/state
+//   POST /json/state    on, bri, transition, tt, seg[0].on, seg[0].bri, seg[0].col, seg[0].cct, seg[0].fx
+//   GET  /json/info
+//   GET  /json/eff      effect names
+//   GET  /json/pal      palette names
+//
+// Effects are flow presets (see flows.Names), effect 0 ("Solid") stops running flow. Light handles
+// a single target on the root path, as expected by WLED clients, Server handles any target
+// under /{target}/json/...
+package wled
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/flows"
+	"github.com/gethiox/yeelight-go/registry"
+)
+
+// version reported to clients, clients use it for detecting available features
+const version = "0.14.0"
+
+// Light is a HTTP handler presenting bulbs matching target as a single WLED device
+type Light struct {
+	registry *registry.Registry
+	target   string
+
+	mtx        sync.Mutex
+	transition int // default transition, multiple of 100 ms
+	effect     int // last started effect, reported while flow is running
+
+	concurrency int
+}
+
+// New creates handler of bulbs matching target, target is a bulb name or any selector supported
+// by registry.Select
+func New(reg *registry.Registry, target string) *Light {
+	return &Light{registry: reg, target: target, transition: 7, concurrency: 4}
+}
+
+// SetConcurrency sets maximum number of bulbs controlled in parallel
+func (l *Light) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	l.concurrency = concurrency
+}
+
+// effects returns effect names, index of effect is its ID
+func effects() []string {
+	names := []string{"Solid"}
+	for _, name := range flows.Names() {
+		name = strings.Replace(name, "_", " ", -1)
+		names = append(names, strings.ToUpper(name[:1])+name[1:])
+	}
+	return names
+}
+
+// preset returns flow preset of effect
+func preset(fx int) (flows.Flow, error) {
+	names := flows.Names()
+	if fx < 1 || fx > len(names) {
+		return flows.Flow{}, fmt.Errorf("effect %d not found", fx)
+	}
+	return flows.Preset(names[fx-1])
+}
+
+var palettes = []string{"Default"}
+
+func (l *Light) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if r.Method != http.MethodGet && !(path == "/json/state" || path == "/json") {
+		writeJSON(w, http.StatusMethodNotAllowed, errorBody{"method not allowed"})
+		return
+	}
+	if r.Method == http.MethodPost {
+		l.setState(w, r)
+		return
+	}
+
+	switch path {
+	case "/json":
+		state, err := l.State()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"state": state, "info": l.Info(r), "effects": effects(), "palettes": palettes,
+		})
+	case "/json/si":
+		state, err := l.State()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"state": state, "info": l.Info(r)})
+	case "/json/state":
+		state, err := l.State()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	case "/json/info":
+		writeJSON(w, http.StatusOK, l.Info(r))
+	case "/json/eff":
+		writeJSON(w, http.StatusOK, effects())
+	case "/json/pal":
+		writeJSON(w, http.StatusOK, palettes)
+	case "/presets.json":
+		// presets are not supported, but some clients read them
+		writeJSON(w, http.StatusOK, struct{}{})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Info is a WLED device info, fields required by clients are included only
+type Info struct {
+	Version   string `json:"ver"`
+	VersionID int    `json:"vid"`
+	LEDs      struct {
+		Count    int   `json:"count"`
+		RGBW     bool  `json:"rgbw"`
+		WV       int   `json:"wv"`
+		CCT      bool  `json:"cct"`
+		FPS      int   `json:"fps"`
+		Power    int   `json:"pwr"`
+		MaxPower int   `json:"maxpwr"`
+		MaxSeg   int   `json:"maxseg"`
+		SegLC    []int `json:"seglc"`
+		LC       int   `json:"lc"`
+	} `json:"leds"`
+	Name          string `json:"name"`
+	UDPPort       int    `json:"udpport"`
+	Live          bool   `json:"live"`
+	EffectCount   int    `json:"fxcount"`
+	PaletteCount  int    `json:"palcount"`
+	Arch          string `json:"arch"`
+	Core          string `json:"core"`
+	FreeHeap      int    `json:"freeheap"`
+	Uptime        int    `json:"uptime"`
+	Brand         string `json:"brand"`
+	Product       string `json:"product"`
+	MAC           string `json:"mac"`
+	IP            string `json:"ip"`
+	WebSocketPort int    `json:"ws"`
+}
+
+var started = time.Now()
+
+// Info returns device info, MAC address is derived from target, so clients can tell targets apart
+func (l *Light) Info(r *http.Request) Info {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(l.target))
+
+	var info Info
+	info.Version = version
+	info.VersionID = 2310130
+	info.LEDs.Count = 1
+	info.LEDs.CCT = true
+	info.LEDs.FPS = 1
+	info.LEDs.MaxSeg = 1
+	info.LEDs.SegLC = []int{3} // RGB and white balance
+	info.LEDs.LC = 3
+	info.Name = l.target
+	info.UDPPort = 21324
+	info.EffectCount = len(effects())
+	info.PaletteCount = len(palettes)
+	info.Arch = "yeelight"
+	info.Core = "yeelight-go"
+	info.Uptime = int(time.Since(started) / time.Second)
+	info.Brand = "WLED"
+	info.Product = "FOSS"
+	info.MAC = fmt.Sprintf("02cafe%06x", hash.Sum32()&0xffffff)
+	info.IP = hostOf(r)
+	info.WebSocketPort = -1 // WebSocket API is not available
+	return info
+}
+
+func hostOf(r *http.Request) string {
+	host := r.Host
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	return host
+}
+
+// Server is a HTTP handler serving WLED API of any target under /{target}/json/...,
+// for instance /room:kitchen/json/state or /office/desk/json
+type Server struct {
+	registry *registry.Registry
+
+	mtx    sync.Mutex
+	lights map[string]*Light // keyed by target
+}
+
+// NewServer creates handler of targets from given registry
+func NewServer(reg *registry.Registry) *Server {
+	return &Server{registry: reg, lights: make(map[string]*Light)}
+}
+
+// Light returns handler of given target, handlers are kept, so default transition and running
+// effect are remembered between requests
+func (s *Server) Light(target string) *Light {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	light, ok := s.lights[target]
+	if !ok {
+		light = New(s.registry, target)
+		s.lights[target] = light
+	}
+	return light
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	// endpoints never contain "/json" again, so names containing it ("office/json-lamp") are not split
+	i := strings.LastIndex(path, "/json")
+	if strings.HasSuffix(path, "/presets.json") {
+		i = len(path) - len("/presets.json")
+	}
+	if i <= 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	target := path[:i]
+	if _, err := s.registry.Select(target); err != nil {
+		writeError(w, err)
+		return
+	}
+	r.URL.Path = path[i:]
+	s.Light(target).ServeHTTP(w, r)
+}
+
+// errorBody is a JSON body of unsuccessful response
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes error with status code:
+//   400 Bad Request   invalid request body
+//   404 Not Found     no bulbs matching target
+//   502 Bad Gateway   bulbs are unreachable or rejected commands
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadGateway
+	switch err.(type) {
+	case *requestError:
+		code = http.StatusBadRequest
+	case *registry.NotFoundError:
+		code = http.StatusNotFound
+	}
+	writeJSON(w, code, errorBody{err.Error()})
+}
+
+// requestError is an error caused by invalid request
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// each runs function for every bulb matching target as yeelight.Group, function is repeated once on
+// a new connection when previous connection was found closed unless retry is false (see registry.Execute)
+func (l *Light) each(fn func(bulb *yl.Bulb) error, retry bool) error {
+	entries, err := l.registry.Select(l.target)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]string, len(entries)) // keyed by bulb address
+	for _, entry := range entries {
+		names[entry.Address()] = entry.Name
+	}
+	execute := l.registry.Execute
+	if !retry {
+		execute = l.registry.ExecuteOnce
+	}
+
+	group, err := l.registry.GroupOf(entries)
+	failed := yl.GroupError{}
+	if unreachable, ok := err.(yl.GroupError); ok {
+		failed = unreachable
+	}
+	if len(group.Bulbs()) > 0 {
+		group.SetConcurrency(l.concurrency)
+		err := group.Each(func(bulb *yl.Bulb) error {
+			return execute(names[bulb.Address()], func(bulb *yl.Bulb) error {
+				if err := l.enableStateCache(bulb); err != nil {
+					return err
+				}
+				return fn(bulb)
+			})
+		})
+		if errs, ok := err.(yl.GroupError); ok {
+			for address, err := range errs {
+				failed[address] = err
+			}
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	return failed
+}
+
+// bulb returns connected bulb with enabled state cache, so polling clients don't consume quota
+func (l *Light) bulb(entry registry.Entry) (*yl.Bulb, error) {
+	bulb, err := l.registry.Bulb(entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.enableStateCache(bulb); err != nil {
+		_ = l.registry.Disconnect(entry.ID)
+		return nil, err
+	}
+	return bulb, nil
+}
+
+// enableStateCache enables state cache of the bulb unless it's already enabled
+func (l *Light) enableStateCache(bulb *yl.Bulb) error {
+	if _, err := bulb.State(); err == nil {
+		return nil
+	}
+	return bulb.EnableStateCache(time.Minute)
+}
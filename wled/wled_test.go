@@ -0,0 +1,250 @@
+package wled
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+	"github.com/gethiox/yeelight-go/registry"
+	"github.com/gethiox/yeelight-go/yeelighttest"
+)
+
+func TestParseOn(t *testing.T) {
+	tests := []struct {
+		raw    string
+		power  string
+		toggle bool
+		valid  bool
+	}{
+		{``, "nil", false, true},
+		{`true`, "true", false, true},
+		{`false`, "false", false, true},
+		{`"t"`, "nil", true, true},
+		{`"x"`, "nil", false, false},
+		{`1`, "nil", false, false},
+	}
+	for _, test := range tests {
+		power, toggle, err := parseOn(json.RawMessage(test.raw))
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got %v", test.raw, test.valid, err)
+			continue
+		}
+		got := "nil"
+		if power != nil {
+			got = map[bool]string{true: "true", false: "false"}[*power]
+		}
+		if got != test.power || toggle != test.toggle {
+			t.Errorf("%s: expected %s %v, got %s %v", test.raw, test.power, test.toggle, got, toggle)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		raw   string
+		rgb   int
+		valid bool
+	}{
+		{`[255, 128, 0]`, 0xff8000, true},
+		{`[255, 128, 0, 50]`, 0xff8000, true},
+		{`"ff8000"`, 0xff8000, true},
+		{`"FF8000AA"`, 0xff8000, true},
+		{`[255, 128]`, 0, false},
+		{`[256, 0, 0]`, 0, false},
+		{`[-1, 0, 0]`, 0, false},
+		{`"ff80"`, 0, false},
+		{`"gg8000"`, 0, false},
+		{`{}`, 0, false},
+	}
+	for _, test := range tests {
+		rgb, err := parseColor(json.RawMessage(test.raw))
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got %v", test.raw, test.valid, err)
+			continue
+		}
+		if rgb != test.rgb {
+			t.Errorf("%s: expected %06x, got %06x", test.raw, test.rgb, rgb)
+		}
+		if _, ok := err.(*requestError); err != nil && !ok {
+			t.Errorf("%s: expected request error, got %T", test.raw, err)
+		}
+	}
+}
+
+func TestParseBri(t *testing.T) {
+	tests := []struct {
+		bri   int
+		want  int
+		valid bool
+	}{
+		{0, 0, true},
+		{1, 1, true},
+		{2, 1, true},
+		{128, 50, true},
+		{255, 100, true},
+		{256, 0, false},
+		{-1, 0, false},
+	}
+	for _, test := range tests {
+		bri, err := parseBri(test.bri)
+		if (err == nil) != test.valid {
+			t.Errorf("%d: expected valid %v, got %v", test.bri, test.valid, err)
+			continue
+		}
+		if err == nil && *bri != test.want {
+			t.Errorf("%d: expected %d, got %d", test.bri, test.want, *bri)
+		}
+	}
+}
+
+func TestStateRequestParse(t *testing.T) {
+	parse := func(body string) (command, error) {
+		var req stateRequest
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatal(err)
+		}
+		return req.parse(7)
+	}
+
+	cmd, err := parse(`{"on": "t", "bri": 255}`)
+	if err != nil || !cmd.toggle || cmd.power != nil || *cmd.bri != 100 || cmd.d != 700*time.Millisecond {
+		t.Errorf("unexpected command %+v: %v", cmd, err)
+	}
+
+	// tt overrides default transition, segment values are used when not given on the top level
+	cmd, err = parse(`{"transition": 10, "tt": 0, "seg": [{"id": 0, "on": true, "bri": 1, "col": [[0, 0, 255]], "cct": 255}]}`)
+	if err != nil || *cmd.power != true || *cmd.bri != 1 || *cmd.rgb != 0x0000ff || *cmd.temp != maxTemperature || cmd.d != 0 {
+		t.Errorf("unexpected command %+v: %v", cmd, err)
+	}
+
+	// single segment object, kelvins are clamped
+	cmd, err = parse(`{"seg": {"cct": 10000, "fx": 0}}`)
+	if err != nil || *cmd.temp != maxTemperature || cmd.flow != nil || *cmd.effect != 0 {
+		t.Errorf("unexpected command %+v: %v", cmd, err)
+	}
+
+	// segments other than the first one are ignored
+	cmd, err = parse(`{"seg": [{"id": 1, "bri": 255}]}`)
+	if err != nil || cmd.bri != nil {
+		t.Errorf("unexpected command %+v: %v", cmd, err)
+	}
+
+	cmd, err = parse(`{"seg": [{"fx": 1}]}`)
+	if err != nil || cmd.flow == nil || *cmd.effect != 1 {
+		t.Errorf("unexpected command %+v: %v", cmd, err)
+	}
+
+	for _, body := range []string{
+		`{"on": 1}`,
+		`{"bri": 300}`,
+		`{"tt": -1}`,
+		`{"transition": 70000}`,
+		`{"seg": [{"col": [[1, 2]]}]}`,
+		`{"seg": [{"cct": 1000}]}`,
+		`{"seg": [{"fx": 1000}]}`,
+		`{"seg": "nope"}`,
+	} {
+		if _, err := parse(body); err == nil {
+			t.Errorf("%s: expected error", body)
+		}
+	}
+}
+
+func TestServerPaths(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	reg := registry.New("")
+	defer reg.Close()
+	for _, name := range []string{"office/desk", "office/json-lamp"} {
+		if err := reg.Set(registry.Entry{ID: device.ID + name, Name: name, Room: "office", Ip: device.Ip, Port: device.Port}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := NewServer(reg)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		target string
+	}{
+		{http.MethodGet, "/office/desk/json/eff", http.StatusOK, "office/desk"},
+		{http.MethodGet, "/office/desk/json/eff/", http.StatusOK, "office/desk"},
+		{http.MethodGet, "/office/json-lamp/json/pal", http.StatusOK, "office/json-lamp"},
+		{http.MethodGet, "/room:office/json/info", http.StatusOK, "room:office"},
+		{http.MethodGet, "/office/desk/presets.json", http.StatusOK, "office/desk"},
+		{http.MethodGet, "/office/json-lamp/presets.json", http.StatusOK, "office/json-lamp"},
+		{http.MethodGet, "/office/json-lamp/json/nope", http.StatusNotFound, "office/json-lamp"},
+		{http.MethodPost, "/office/desk/json/eff", http.StatusMethodNotAllowed, "office/desk"},
+		{http.MethodGet, "/kitchen/json", http.StatusNotFound, ""},
+		{http.MethodGet, "/json", http.StatusNotFound, ""},
+		{http.MethodGet, "/office/desk", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+			if w.Code != test.code {
+				t.Errorf("expected %d, got %d: %s", test.code, w.Code, w.Body)
+			}
+			if test.target == "" {
+				return
+			}
+			server.mtx.Lock()
+			_, ok := server.lights[test.target]
+			server.mtx.Unlock()
+			if !ok {
+				t.Errorf("request wasn't handled by light of %s", test.target)
+			}
+		})
+	}
+}
+
+func TestSetState(t *testing.T) {
+	device, err := yeelighttest.NewDevice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	reg := registry.New("")
+	defer reg.Close()
+	if err := reg.Set(registry.Entry{ID: device.ID, Name: "desk", Ip: device.Ip, Port: device.Port}); err != nil {
+		t.Fatal(err)
+	}
+	light := New(reg, "desk")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		light.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`{"on": true, "tt": 0, "seg": [{"col": [[255, 0, 0]], "bri": 128}], "v": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if device.Prop(yl.PROP_POWER) != "on" || device.Prop(yl.PROP_RGB) != "16711680" || device.Prop(yl.PROP_BRIGHT) != "50" {
+		t.Errorf("unexpected device state: %+v", device.Commands())
+	}
+
+	// toggle turns bulb off
+	if w := post(`{"on": "t"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if device.Prop(yl.PROP_POWER) != "off" {
+		t.Error("bulb wasn't toggled off")
+	}
+
+	if w := post(`{"bri": 1000}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,684 @@
+// Package yeelighttest provides in-process fake Yeelight device speaking LAN control protocol,
+// so code built on the library can be exercised without real hardware
+package yeelighttest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	yl "github.com/gethiox/yeelight-go"
+)
+
+// Command is a command received by fake device
+type Command struct {
+	Method string
+	Params []interface{}
+	Music  bool // command was received over music mode connection
+}
+
+// Device is a fake color bulb listening on loopback interface. It keeps properties changed
+// by commands, reports changes with notifications and supports music mode. Flows are not played,
+// device only reports them as running
+type Device struct {
+	Ip   string
+	Port int
+	ID   string
+
+	listener net.Listener
+
+	mtx        sync.Mutex
+	props      map[string]string
+	background bool
+	failures   map[string]*yl.DeviceError
+	commands   []Command
+	conns      map[net.Conn]struct{}
+	music      net.Conn
+	closed     bool
+}
+
+var deviceCounter struct {
+	sync.Mutex
+	n int
+}
+
+// NewDevice starts fake device on random loopback port
+func NewDevice() (*Device, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCounter.Lock()
+	deviceCounter.n++
+	id := fmt.Sprintf("0x%016x", deviceCounter.n)
+	deviceCounter.Unlock()
+
+	d := &Device{
+		Ip:       "127.0.0.1",
+		Port:     listener.Addr().(*net.TCPAddr).Port,
+		ID:       id,
+		listener: listener,
+		props: map[string]string{
+			"power": "off", "bright": "100", "ct": "4000", "rgb": "16777215", "hue": "0", "sat": "0",
+			"color_mode": "2", "flowing": "0", "flow_params": "", "delayoff": "0", "music_on": "0", "name": "",
+		},
+		failures: make(map[string]*yl.DeviceError),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	go d.serve()
+	return d, nil
+}
+
+// EnableBackground adds background light, "bg_" commands are rejected without it
+func (d *Device) EnableBackground() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.background = true
+	for k, v := range map[string]string{
+		"bg_power": "off", "bg_bright": "100", "bg_ct": "4000", "bg_rgb": "16777215", "bg_hue": "0",
+		"bg_sat": "0", "bg_lmode": "2", "bg_flowing": "0", "bg_flow_params": "",
+	} {
+		d.props[k] = v
+	}
+}
+
+// Addr returns address of the device
+func (d *Device) Addr() string {
+	return net.JoinHostPort(d.Ip, strconv.Itoa(d.Port))
+}
+
+// Bulb returns bulb connected to the device
+func (d *Device) Bulb() (*yl.Bulb, error) {
+	bulb := yl.NewBulb(d.Ip)
+	bulb.Port = d.Port
+	if err := bulb.Connect(); err != nil {
+		return nil, err
+	}
+	return bulb, nil
+}
+
+// Device returns description of the device, as it would be reported by discovery
+func (d *Device) Device() yl.Device {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	atoi := func(prop string) int {
+		value, _ := strconv.Atoi(d.props[prop])
+		return value
+	}
+	support := []string{
+		"get_prop", "set_default", "set_power", "toggle", "set_bright", "start_cf", "stop_cf", "set_scene",
+		"cron_add", "cron_get", "cron_del", "set_ct_abx", "set_rgb", "set_hsv", "set_adjust", "adjust_bright",
+		"adjust_ct", "adjust_color", "set_music", "set_name",
+	}
+	if d.background {
+		for _, method := range support[1:] {
+			if !strings.HasPrefix(method, "cron") && method != "set_music" && method != "set_name" {
+				support = append(support, "bg_"+method)
+			}
+		}
+		support = append(support, "dev_toggle")
+	}
+	return yl.Device{
+		ID:        d.ID,
+		Model:     "color",
+		FwVer:     1,
+		Support:   support,
+		Ip:        d.Ip,
+		Port:      d.Port,
+		Name:      d.props["name"],
+		Power:     d.props["power"] == "on",
+		Bright:    atoi("bright"),
+		ColorMode: atoi("color_mode"),
+		CT:        atoi("ct"),
+		RGB:       atoi("rgb"),
+		Hue:       atoi("hue"),
+		Sat:       atoi("sat"),
+	}
+}
+
+// Prop returns current property value, empty string for unknown property
+func (d *Device) Prop(prop yl.Property) string {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.props[string(prop)]
+}
+
+// SetProp changes property as it would be changed by other client or physical switch,
+// change is reported with notification
+func (d *Device) SetProp(prop yl.Property, value string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.update(map[string]string{string(prop): value})
+}
+
+// Fail makes device reject given method with given error, nil error removes failure
+func (d *Device) Fail(method string, err *yl.DeviceError) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if err == nil {
+		delete(d.failures, method)
+		return
+	}
+	d.failures[method] = err
+}
+
+// Commands returns all commands received by device so far
+func (d *Device) Commands() []Command {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return append([]Command(nil), d.commands...)
+}
+
+// Reset clears history of received commands
+func (d *Device) Reset() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.commands = nil
+}
+
+// DropConnections closes control connections, device keeps listening for new ones (like after restart)
+func (d *Device) DropConnections() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for conn := range d.conns {
+		conn.Close()
+	}
+}
+
+// Close stops device and drops all connections
+func (d *Device) Close() error {
+	d.mtx.Lock()
+	d.closed = true
+	for conn := range d.conns {
+		conn.Close()
+	}
+	if d.music != nil {
+		d.music.Close()
+	}
+	d.mtx.Unlock()
+	return d.listener.Close()
+}
+
+func (d *Device) serve() {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		d.mtx.Lock()
+		if d.closed {
+			d.mtx.Unlock()
+			conn.Close()
+			return
+		}
+		d.conns[conn] = struct{}{}
+		d.mtx.Unlock()
+
+		go d.handle(conn)
+	}
+}
+
+type request struct {
+	ID     int           `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+func (d *Device) handle(conn net.Conn) {
+	defer func() {
+		d.mtx.Lock()
+		delete(d.conns, conn)
+		d.mtx.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			d.write(conn, map[string]interface{}{"id": 0, "error": map[string]interface{}{"code": -1, "message": "invalid command"}})
+			continue
+		}
+
+		d.mtx.Lock()
+		result, deviceErr := d.execute(req, false)
+		d.mtx.Unlock()
+
+		if deviceErr != nil {
+			d.write(conn, map[string]interface{}{
+				"id": req.ID, "error": map[string]interface{}{"code": deviceErr.Code, "message": deviceErr.Message},
+			})
+			continue
+		}
+		d.write(conn, map[string]interface{}{"id": req.ID, "result": result})
+	}
+}
+
+// handleMusic reads commands sent in music mode, device doesn't respond on them
+func (d *Device) handleMusic(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+
+		var req request
+		if err := json.Unmarshal(bytes.TrimSpace(line), &req); err != nil {
+			continue
+		}
+		d.mtx.Lock()
+		_, _ = d.execute(req, true)
+		d.mtx.Unlock()
+	}
+
+	d.mtx.Lock()
+	if d.music == conn {
+		d.music = nil
+		d.update(map[string]string{"music_on": "0"})
+	}
+	d.mtx.Unlock()
+}
+
+func (d *Device) write(conn net.Conn, message interface{}) {
+	data, _ := json.Marshal(message)
+	_, _ = conn.Write(append(data, '\r', '\n'))
+}
+
+// update changes properties and notifies all connections, lock is required
+func (d *Device) update(changes map[string]string) {
+	changed := make(map[string]string)
+	for k, v := range changes {
+		if d.props[k] != v {
+			d.props[k] = v
+			changed[k] = v
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"method": "props", "params": changed})
+	data = append(data, '\r', '\n')
+	for conn := range d.conns {
+		_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_, _ = conn.Write(data)
+		_ = conn.SetWriteDeadline(time.Time{})
+	}
+}
+
+var (
+	errNotSupported  = &yl.DeviceError{Code: -1, Message: "method not supported"}
+	errInvalidParams = &yl.DeviceError{Code: -1, Message: "invalid params"}
+	errGeneral       = &yl.DeviceError{Code: -5000, Message: "general error"}
+)
+
+// execute runs command, lock is required
+func (d *Device) execute(req request, music bool) ([]interface{}, *yl.DeviceError) {
+	d.commands = append(d.commands, Command{Method: req.Method, Params: req.Params, Music: music})
+
+	if err, ok := d.failures[req.Method]; ok {
+		return nil, err
+	}
+
+	method, prefix := req.Method, ""
+	if strings.HasPrefix(method, "bg_") {
+		if !d.background {
+			return nil, errNotSupported
+		}
+		method, prefix = strings.TrimPrefix(method, "bg_"), "bg_"
+	}
+	colorMode := prefix + "color_mode"
+	if prefix != "" {
+		colorMode = "bg_lmode"
+	}
+
+	p := paramReader{params: req.Params}
+	ok := []interface{}{"ok"}
+
+	switch method {
+	case "get_prop":
+		if prefix != "" {
+			return nil, errNotSupported
+		}
+		var result []interface{}
+		for i := range req.Params {
+			result = append(result, d.props[p.string(i)])
+		}
+		return result, nil
+	case "set_power":
+		power := p.string(0)
+		if power != "on" && power != "off" {
+			return nil, errInvalidParams
+		}
+		changes := map[string]string{prefix + "power": power}
+		if power == "on" && len(req.Params) > 3 {
+			switch p.int(3) {
+			case 1:
+				changes[colorMode] = "2"
+			case 2:
+				changes[colorMode] = "1"
+			case 3:
+				changes[colorMode] = "3"
+			}
+		}
+		d.update(changes)
+	case "toggle":
+		d.update(map[string]string{prefix + "power": map[string]string{"on": "off", "off": "on"}[d.props[prefix+"power"]]})
+	case "dev_toggle":
+		if prefix != "" || !d.background {
+			return nil, errNotSupported
+		}
+		power := map[string]string{"on": "off", "off": "on"}[d.props["power"]]
+		d.update(map[string]string{"power": power, "bg_power": power})
+	case "set_bright":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+		bright := p.int(0)
+		if bright < 1 || bright > 100 {
+			return nil, errInvalidParams
+		}
+		d.update(map[string]string{prefix + "bright": strconv.Itoa(bright)})
+	case "set_ct_abx":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+		ct := p.int(0)
+		if ct < 1700 || ct > 6500 {
+			return nil, errInvalidParams
+		}
+		d.update(map[string]string{prefix + "ct": strconv.Itoa(ct), colorMode: "2", prefix + "flowing": "0"})
+	case "set_rgb":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+		rgb := p.int(0)
+		if rgb < 0 || rgb > 0xffffff {
+			return nil, errInvalidParams
+		}
+		d.update(map[string]string{prefix + "rgb": strconv.Itoa(rgb), colorMode: "1", prefix + "flowing": "0"})
+	case "set_hsv":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+		hue, sat := p.int(0), p.int(1)
+		if hue < 0 || hue > 359 || sat < 0 || sat > 100 {
+			return nil, errInvalidParams
+		}
+		d.update(map[string]string{
+			prefix + "hue": strconv.Itoa(hue), prefix + "sat": strconv.Itoa(sat), colorMode: "3", prefix + "flowing": "0",
+		})
+	case "set_default":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+	case "start_cf":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+		if len(req.Params) != 3 {
+			return nil, errInvalidParams
+		}
+		flow := fmt.Sprintf("%d,%d,%s", p.int(0), p.int(1), p.string(2))
+		if _, _, _, err := yl.ParseFlowParams(flow); err != nil {
+			return nil, errInvalidParams
+		}
+		d.update(map[string]string{prefix + "flowing": "1", prefix + "flow_params": flow})
+	case "stop_cf":
+		d.update(map[string]string{prefix + "flowing": "0"})
+	case "set_scene":
+		changes, err := d.scene(prefix, colorMode, p)
+		if err != nil {
+			return nil, err
+		}
+		changes[prefix+"power"] = "on"
+		d.update(changes)
+	case "cron_add":
+		if prefix != "" {
+			return nil, errNotSupported
+		}
+		minutes := p.int(1)
+		if p.int(0) != 0 || minutes < 1 {
+			return nil, errInvalidParams
+		}
+		d.update(map[string]string{"delayoff": strconv.Itoa(minutes)})
+	case "cron_get":
+		if prefix != "" {
+			return nil, errNotSupported
+		}
+		delay, _ := strconv.Atoi(d.props["delayoff"])
+		if delay == 0 {
+			return []interface{}{}, nil
+		}
+		return []interface{}{map[string]interface{}{"type": 0, "delay": delay, "mix": 0}}, nil
+	case "cron_del":
+		if prefix != "" {
+			return nil, errNotSupported
+		}
+		d.update(map[string]string{"delayoff": "0"})
+	case "set_adjust":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+		if err := d.adjust(prefix, p.string(0), p.string(1)); err != nil {
+			return nil, err
+		}
+	case "adjust_bright", "adjust_ct", "adjust_color":
+		if !d.on(prefix) {
+			return nil, errGeneral
+		}
+		percentage := p.int(0)
+		if percentage < -100 || percentage > 100 {
+			return nil, errInvalidParams
+		}
+		d.adjustBy(prefix, colorMode, strings.TrimPrefix(method, "adjust_"), percentage)
+	case "set_name":
+		if prefix != "" {
+			return nil, errNotSupported
+		}
+		d.update(map[string]string{"name": p.string(0)})
+	case "set_music":
+		if prefix != "" || music {
+			return nil, errNotSupported
+		}
+		return d.setMusic(p)
+	default:
+		return nil, errNotSupported
+	}
+
+	if p.err {
+		return nil, errInvalidParams
+	}
+	return ok, nil
+}
+
+func (d *Device) on(prefix string) bool {
+	return d.props[prefix+"power"] == "on"
+}
+
+func (d *Device) scene(prefix, colorMode string, p paramReader) (map[string]string, *yl.DeviceError) {
+	brightness := func(i int) (string, bool) {
+		bright := p.int(i)
+		return strconv.Itoa(bright), bright >= 1 && bright <= 100
+	}
+
+	switch p.string(0) {
+	case "color":
+		bright, ok := brightness(2)
+		if !ok || p.err {
+			return nil, errInvalidParams
+		}
+		return map[string]string{prefix + "rgb": strconv.Itoa(p.int(1)), prefix + "bright": bright, colorMode: "1"}, nil
+	case "hsv":
+		bright, ok := brightness(3)
+		if !ok || p.err {
+			return nil, errInvalidParams
+		}
+		return map[string]string{
+			prefix + "hue": strconv.Itoa(p.int(1)), prefix + "sat": strconv.Itoa(p.int(2)), prefix + "bright": bright,
+			colorMode: "3",
+		}, nil
+	case "ct":
+		bright, ok := brightness(2)
+		if !ok || p.err {
+			return nil, errInvalidParams
+		}
+		return map[string]string{prefix + "ct": strconv.Itoa(p.int(1)), prefix + "bright": bright, colorMode: "2"}, nil
+	case "cf":
+		flow := fmt.Sprintf("%d,%d,%s", p.int(1), p.int(2), p.string(3))
+		if _, _, _, err := yl.ParseFlowParams(flow); err != nil || p.err {
+			return nil, errInvalidParams
+		}
+		return map[string]string{prefix + "flowing": "1", prefix + "flow_params": flow}, nil
+	case "auto_delay_off":
+		bright, ok := brightness(1)
+		if !ok || p.err || prefix != "" {
+			return nil, errInvalidParams
+		}
+		return map[string]string{"bright": bright, "delayoff": strconv.Itoa(p.int(2))}, nil
+	}
+	return nil, errInvalidParams
+}
+
+func (d *Device) adjust(prefix, action, prop string) *yl.DeviceError {
+	step := map[string]int{"incrase": 10, "increase": 10, "decrase": -10, "decrease": -10, "circle": 10}[action]
+	if step == 0 {
+		return errInvalidParams
+	}
+
+	switch prop {
+	case "bright":
+		d.update(map[string]string{prefix + "bright": strconv.Itoa(clamp(d.int(prefix+"bright")+step, 1, 100, action == "circle"))})
+	case "ct":
+		d.update(map[string]string{prefix + "ct": strconv.Itoa(clamp(d.int(prefix+"ct")+step*48, 1700, 6500, action == "circle"))})
+	case "color":
+		if action != "circle" {
+			return errInvalidParams
+		}
+		d.update(map[string]string{prefix + "hue": strconv.Itoa((d.int(prefix+"hue") + 36) % 360)})
+	default:
+		return errInvalidParams
+	}
+	return nil
+}
+
+func (d *Device) adjustBy(prefix, colorMode, prop string, percentage int) {
+	switch prop {
+	case "bright":
+		d.update(map[string]string{prefix + "bright": strconv.Itoa(clamp(d.int(prefix+"bright")+percentage, 1, 100, false))})
+	case "ct":
+		ct := d.int(prefix+"ct") + percentage*(6500-1700)/100
+		d.update(map[string]string{prefix + "ct": strconv.Itoa(clamp(ct, 1700, 6500, false)), colorMode: "2"})
+	case "color":
+		hue := (d.int(prefix+"hue") + percentage*360/100 + 360) % 360
+		d.update(map[string]string{prefix + "hue": strconv.Itoa(hue), colorMode: "3"})
+	}
+}
+
+func (d *Device) setMusic(p paramReader) ([]interface{}, *yl.DeviceError) {
+	switch p.int(0) {
+	case 0:
+		if d.music != nil {
+			d.music.Close()
+			d.music = nil
+		}
+		d.update(map[string]string{"music_on": "0"})
+	case 1:
+		address := net.JoinHostPort(p.string(1), strconv.Itoa(p.int(2)))
+		if p.err {
+			return nil, errInvalidParams
+		}
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err != nil {
+			return nil, errGeneral
+		}
+		if d.music != nil {
+			d.music.Close()
+		}
+		d.music = conn
+		d.update(map[string]string{"music_on": "1"})
+		go d.handleMusic(conn)
+	default:
+		return nil, errInvalidParams
+	}
+	return []interface{}{"ok"}, nil
+}
+
+func (d *Device) int(prop string) int {
+	value, _ := strconv.Atoi(d.props[prop])
+	return value
+}
+
+func clamp(value, min, max int, wrap bool) int {
+	switch {
+	case value > max && wrap:
+		return min
+	case value > max:
+		return max
+	case value < min:
+		return min
+	}
+	return value
+}
+
+// paramReader reads command parameters, err is set when parameter is missing or has invalid type
+type paramReader struct {
+	params []interface{}
+	err    bool
+}
+
+func (p *paramReader) int(i int) int {
+	if i >= len(p.params) {
+		p.err = true
+		return 0
+	}
+	switch v := p.params[i].(type) {
+	case float64:
+		return int(v)
+	case string:
+		value, err := strconv.Atoi(v)
+		if err != nil {
+			p.err = true
+		}
+		return value
+	}
+	p.err = true
+	return 0
+}
+
+func (p *paramReader) string(i int) string {
+	if i >= len(p.params) {
+		p.err = true
+		return ""
+	}
+	switch v := p.params[i].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	p.err = true
+	return ""
+}